@@ -0,0 +1,604 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// 開発用のデフォルト JWT シークレット（dev 以外では起動を拒否する）
+const DefaultJWTSecret = "super_secret_key_123"
+
+// MinJWTSecretLength は dev 以外での JWT シークレットの最小のバイト数（HS256 の鍵として十分な長さ）
+const MinJWTSecretLength = 32
+
+// placeholderJWTSecrets は設定例などにある、そのまま使ってはいけない JWT シークレット（大文字小文字は区別しない）
+var placeholderJWTSecrets = []string{DefaultJWTSecret, "change-me", "changeme", "secret", "your-secret"}
+
+// MinAdminTokenLength は管理用 API と /metrics のトークンの最小の長さ
+const MinAdminTokenLength = 16
+
+const (
+	EnvDev        = "dev"
+	EnvProduction = "production"
+)
+
+// 設定値の優先順位（後ろほど強い）:
+//
+//	デフォルト値 < 設定ファイル (YAML/TOML) < 環境変数 < コマンドラインフラグ
+//
+// 設定ファイルのパスは -config フラグか APP_CONFIG 環境変数で指定する。
+type Config struct {
+	Env    string       `yaml:"env" toml:"env"`
+	Server ServerConfig `yaml:"server" toml:"server"`
+	DB     DBConfig     `yaml:"db" toml:"db"`
+	JWT    JWTConfig    `yaml:"jwt" toml:"jwt"`
+	Log    LogConfig    `yaml:"log" toml:"log"`
+	Trash  TrashConfig  `yaml:"trash" toml:"trash"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Events      EventsConfig      `yaml:"events" toml:"events"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks" toml:"webhooks"`
+	Jobs        JobsConfig        `yaml:"jobs" toml:"jobs"`
+	Admin       AdminConfig       `yaml:"admin" toml:"admin"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	Metrics     MetricsConfig     `yaml:"metrics" toml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+}
+
+// ServerConfig の各タイムアウトは http.Server に渡す。
+// ShutdownDelay は SIGINT / SIGTERM を受けて /readyz を 503 にしてから、新しい接続を受け付けるのをやめるまで待つ時間
+// （ロードバランサーがプローブで 503 に気づいて、振り分けをやめるまで）。
+// ShutdownTimeout はその後、処理中のリクエストが終わるのを待つ上限
+type ServerConfig struct {
+	Addr            string   `yaml:"addr" toml:"addr"`
+	ReadTimeout     Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownDelay   Duration `yaml:"shutdown_delay" toml:"shutdown_delay"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+type DBConfig struct {
+	Path string `yaml:"path" toml:"path"`
+}
+
+// JWTConfig の PurgeInterval は期限切れのリフレッシュトークンと失効させたアクセストークンを削除する間隔
+type JWTConfig struct {
+	Secret        string   `yaml:"secret" toml:"secret"`
+	AccessTTL     Duration `yaml:"access_ttl" toml:"access_ttl"`
+	RefreshTTL    Duration `yaml:"refresh_ttl" toml:"refresh_ttl"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// LogConfig はログのレベル・形式（json / text）・出力先（stdout / stderr / ファイルのパス）。
+// ファイルは MaxSizeMB を超えたら .1, .2 ... にずらし、MaxBackups 個まで残す
+type LogConfig struct {
+	Level      string            `yaml:"level" toml:"level"`
+	Format     string            `yaml:"format" toml:"format"`
+	Output     string            `yaml:"output" toml:"output"`
+	MaxSizeMB  int               `yaml:"max_size_mb" toml:"max_size_mb"`
+	MaxBackups int               `yaml:"max_backups" toml:"max_backups"`
+	Sampling   LogSamplingConfig `yaml:"sampling" toml:"sampling"`
+}
+
+// LogSamplingConfig は同じメッセージが Tick の間に Initial 回を超えたら、以降は Thereafter 回に 1 回だけ出す（warn 以上は全て出す）。
+// Initial が 0 なら間引かない
+type LogSamplingConfig struct {
+	Initial    int      `yaml:"initial" toml:"initial"`
+	Thereafter int      `yaml:"thereafter" toml:"thereafter"`
+	Tick       Duration `yaml:"tick" toml:"tick"`
+}
+
+// TrashConfig はゴミ箱の Todo を完全に削除するまでの期間と、削除処理の実行間隔
+type TrashConfig struct {
+	Retention     Duration `yaml:"retention" toml:"retention"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// IdempotencyConfig は Idempotency-Key の応答を保存しておく期間と、期限切れの削除処理の実行間隔
+type IdempotencyConfig struct {
+	Retention     Duration `yaml:"retention" toml:"retention"`
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval"`
+}
+
+// EventsConfig は GET /events と /ws のハートビートの間隔
+type EventsConfig struct {
+	Heartbeat Duration `yaml:"heartbeat" toml:"heartbeat"`
+}
+
+// WebhooksConfig は webhook の送信を待つ上限、最初の再送までの間隔、送信の記録を残す期間と、その削除処理の実行間隔。
+// AllowPrivateTargets はループバック・プライベート・リンクローカルなどのアドレスへの送信を許す（dev のみ）
+type WebhooksConfig struct {
+	Timeout             Duration `yaml:"timeout" toml:"timeout"`
+	RetryBase           Duration `yaml:"retry_base" toml:"retry_base"`
+	Retention           Duration `yaml:"retention" toml:"retention"`
+	PurgeInterval       Duration `yaml:"purge_interval" toml:"purge_interval"`
+	AllowPrivateTargets bool     `yaml:"allow_private_targets" toml:"allow_private_targets"`
+}
+
+// JobsConfig はバックグラウンドジョブのワーカー数、ジョブがないときの確認間隔、リースの期間、
+// 最初の再試行までの間隔、終わったジョブを残す期間とその削除間隔、シャットダウン時に実行中のジョブを待つ上限
+type JobsConfig struct {
+	Workers           int      `yaml:"workers" toml:"workers"`
+	PollInterval      Duration `yaml:"poll_interval" toml:"poll_interval"`
+	VisibilityTimeout Duration `yaml:"visibility_timeout" toml:"visibility_timeout"`
+	RetryBase         Duration `yaml:"retry_base" toml:"retry_base"`
+	Retention         Duration `yaml:"retention" toml:"retention"`
+	PurgeInterval     Duration `yaml:"purge_interval" toml:"purge_interval"`
+	DrainTimeout      Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+}
+
+// AdminConfig は管理用 API（/admin）のトークン。空なら管理用 API は使えない
+type AdminConfig struct {
+	Token string `yaml:"token" toml:"token"`
+}
+
+// HealthConfig は /readyz の確認の設定。1 つの確認を待つ上限、結果をキャッシュする期間、
+// 実行を待っているジョブの遅れの上限、SQLite のファイルがあるディスクの空きの下限（MB）
+type HealthConfig struct {
+	CheckTimeout  Duration `yaml:"check_timeout" toml:"check_timeout"`
+	CacheTTL      Duration `yaml:"cache_ttl" toml:"cache_ttl"`
+	MaxJobLag     Duration `yaml:"max_job_lag" toml:"max_job_lag"`
+	MinFreeDiskMB int      `yaml:"min_free_disk_mb" toml:"min_free_disk_mb"`
+}
+
+// MetricsConfig は GET /metrics のトークン（admin.token とは別）。空なら誰でも見られる
+type MetricsConfig struct {
+	Token string `yaml:"token" toml:"token"`
+}
+
+// TracingConfig は trace の送り先（none / stdout / otlp）、OTLP の送信先 URL、記録するリクエストの割合（0〜1）。
+// Endpoint が空なら OTEL_EXPORTER_OTLP_ENDPOINT などの標準の環境変数に従う
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter"`
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// Duration は "15m" や "720h" のような文字列で書ける time.Duration
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default は何も指定しなかったときの設定
+func Default() *Config {
+	return &Config{
+		Env: EnvDev,
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     Duration{15 * time.Second},
+			WriteTimeout:    Duration{30 * time.Second},
+			IdleTimeout:     Duration{2 * time.Minute},
+			ShutdownDelay:   Duration{5 * time.Second},
+			ShutdownTimeout: Duration{30 * time.Second},
+		},
+		DB: DBConfig{Path: "app.db"},
+		JWT: JWTConfig{
+			Secret:        DefaultJWTSecret,
+			AccessTTL:     Duration{15 * time.Minute},
+			RefreshTTL:    Duration{30 * 24 * time.Hour},
+			PurgeInterval: Duration{time.Hour},
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Format:     "json",
+			Output:     "stdout",
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+			Sampling:   LogSamplingConfig{Initial: 100, Thereafter: 100, Tick: Duration{time.Second}},
+		},
+		Trash: TrashConfig{
+			Retention:     Duration{30 * 24 * time.Hour},
+			PurgeInterval: Duration{time.Hour},
+		},
+		Idempotency: IdempotencyConfig{
+			Retention:     Duration{24 * time.Hour},
+			PurgeInterval: Duration{time.Hour},
+		},
+		Events: EventsConfig{Heartbeat: Duration{15 * time.Second}},
+		Webhooks: WebhooksConfig{
+			Timeout:       Duration{10 * time.Second},
+			RetryBase:     Duration{30 * time.Second},
+			Retention:     Duration{7 * 24 * time.Hour},
+			PurgeInterval: Duration{time.Hour},
+		},
+		Jobs: JobsConfig{
+			Workers:           4,
+			PollInterval:      Duration{time.Second},
+			VisibilityTimeout: Duration{5 * time.Minute},
+			RetryBase:         Duration{10 * time.Second},
+			Retention:         Duration{7 * 24 * time.Hour},
+			PurgeInterval:     Duration{time.Hour},
+			DrainTimeout:      Duration{30 * time.Second},
+		},
+		Health: HealthConfig{
+			CheckTimeout:  Duration{2 * time.Second},
+			CacheTTL:      Duration{2 * time.Second},
+			MaxJobLag:     Duration{5 * time.Minute},
+			MinFreeDiskMB: 100,
+		},
+		Tracing: TracingConfig{Exporter: "none", SampleRatio: 1},
+	}
+}
+
+// isPlaceholderJWTSecret は secret がデフォルト値か設定例の値か
+func isPlaceholderJWTSecret(secret string) bool {
+	for _, placeholder := range placeholderJWTSecrets {
+		if strings.EqualFold(strings.TrimSpace(secret), placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDev は開発モードかどうか
+func (c *Config) IsDev() bool {
+	return c.Env == EnvDev
+}
+
+// LogLevel は Log.Level を slog.Level に変換する（Validate 済みが前提）
+func (c *Config) LogLevel() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.Log.Level))
+	return level
+}
+
+// Validate は起動前に設定値をチェックする
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Env != EnvDev && c.Env != EnvProduction {
+		errs = append(errs, fmt.Errorf("env must be %q or %q: %q", EnvDev, EnvProduction, c.Env))
+	}
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if c.Server.ReadTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("server.read_timeout must be positive"))
+	}
+	if c.Server.WriteTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("server.write_timeout must be positive"))
+	}
+	if c.Server.IdleTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("server.idle_timeout must be positive"))
+	}
+	if c.Server.ShutdownDelay.Duration < 0 {
+		errs = append(errs, errors.New("server.shutdown_delay must not be negative"))
+	}
+	if c.Server.ShutdownTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.DB.Path == "" {
+		errs = append(errs, errors.New("db.path is required"))
+	}
+	if c.JWT.Secret == "" {
+		errs = append(errs, errors.New("jwt.secret is required"))
+	}
+	if !c.IsDev() && isPlaceholderJWTSecret(c.JWT.Secret) {
+		errs = append(errs, errors.New("jwt.secret must be changed from the default or example value outside dev mode"))
+	} else if !c.IsDev() && len(c.JWT.Secret) < MinJWTSecretLength {
+		errs = append(errs, fmt.Errorf("jwt.secret must be at least %d bytes outside dev mode", MinJWTSecretLength))
+	}
+	if c.JWT.AccessTTL.Duration <= 0 {
+		errs = append(errs, errors.New("jwt.access_ttl must be positive"))
+	}
+	if c.JWT.RefreshTTL.Duration <= c.JWT.AccessTTL.Duration {
+		errs = append(errs, errors.New("jwt.refresh_ttl must be longer than jwt.access_ttl"))
+	}
+	if c.JWT.PurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("jwt.purge_interval must be positive"))
+	}
+
+	if c.Trash.Retention.Duration <= 0 {
+		errs = append(errs, errors.New("trash.retention must be positive"))
+	}
+	if c.Trash.PurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("trash.purge_interval must be positive"))
+	}
+	if c.Idempotency.Retention.Duration <= 0 {
+		errs = append(errs, errors.New("idempotency.retention must be positive"))
+	}
+	if c.Idempotency.PurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("idempotency.purge_interval must be positive"))
+	}
+	if c.Events.Heartbeat.Duration <= 0 {
+		errs = append(errs, errors.New("events.heartbeat must be positive"))
+	}
+	if c.Webhooks.Timeout.Duration <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout must be positive"))
+	}
+	if c.Webhooks.RetryBase.Duration <= 0 {
+		errs = append(errs, errors.New("webhooks.retry_base must be positive"))
+	}
+	if c.Webhooks.Retention.Duration <= 0 {
+		errs = append(errs, errors.New("webhooks.retention must be positive"))
+	}
+	if c.Webhooks.PurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("webhooks.purge_interval must be positive"))
+	}
+	if !c.IsDev() && c.Webhooks.AllowPrivateTargets {
+		errs = append(errs, errors.New("webhooks.allow_private_targets is only allowed in dev mode"))
+	}
+	if c.Jobs.Workers <= 0 {
+		errs = append(errs, errors.New("jobs.workers must be positive"))
+	}
+	if c.Jobs.PollInterval.Duration <= 0 {
+		errs = append(errs, errors.New("jobs.poll_interval must be positive"))
+	}
+	if c.Jobs.VisibilityTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("jobs.visibility_timeout must be positive"))
+	}
+	if c.Jobs.RetryBase.Duration <= 0 {
+		errs = append(errs, errors.New("jobs.retry_base must be positive"))
+	}
+	if c.Jobs.Retention.Duration <= 0 {
+		errs = append(errs, errors.New("jobs.retention must be positive"))
+	}
+	if c.Jobs.PurgeInterval.Duration <= 0 {
+		errs = append(errs, errors.New("jobs.purge_interval must be positive"))
+	}
+	if c.Jobs.DrainTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("jobs.drain_timeout must be positive"))
+	}
+	if c.Health.CheckTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("health.check_timeout must be positive"))
+	}
+	if c.Health.CacheTTL.Duration < 0 {
+		errs = append(errs, errors.New("health.cache_ttl must not be negative"))
+	}
+	if c.Health.MaxJobLag.Duration <= 0 {
+		errs = append(errs, errors.New("health.max_job_lag must be positive"))
+	}
+	if c.Health.MinFreeDiskMB < 0 {
+		errs = append(errs, errors.New("health.min_free_disk_mb must not be negative"))
+	}
+	if c.Metrics.Token != "" && len(c.Metrics.Token) < MinAdminTokenLength {
+		errs = append(errs, fmt.Errorf("metrics.token must be at least %d characters", MinAdminTokenLength))
+	}
+	if c.Admin.Token != "" && len(c.Admin.Token) < MinAdminTokenLength {
+		errs = append(errs, fmt.Errorf("admin.token must be at least %d characters", MinAdminTokenLength))
+	}
+	if c.Tracing.Exporter != "none" && c.Tracing.Exporter != "stdout" && c.Tracing.Exporter != "otlp" {
+		errs = append(errs, fmt.Errorf("tracing.exporter must be none, stdout or otlp: %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level is invalid: %q", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("log.format must be json or text: %q", c.Log.Format))
+	}
+	if c.Log.Output == "" {
+		errs = append(errs, errors.New("log.output is required"))
+	}
+	if c.Log.MaxSizeMB <= 0 {
+		errs = append(errs, errors.New("log.max_size_mb must be positive"))
+	}
+	if c.Log.MaxBackups < 0 {
+		errs = append(errs, errors.New("log.max_backups must not be negative"))
+	}
+	if c.Log.Sampling.Initial < 0 || c.Log.Sampling.Thereafter < 0 {
+		errs = append(errs, errors.New("log.sampling.initial and log.sampling.thereafter must not be negative"))
+	}
+	if c.Log.Sampling.Tick.Duration <= 0 {
+		errs = append(errs, errors.New("log.sampling.tick must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Load は args（os.Args[1:]）と環境変数から設定を組み立てて検証する。
+// フラグ以外の残りの引数（サブコマンドなど）も返す。
+func Load(args []string) (*Config, []string, error) {
+	return load(args, os.Getenv)
+}
+
+func load(args []string, getenv func(string) string) (*Config, []string, error) {
+	cfg := Default()
+
+	// フラグは最後に適用するので、ここではパースだけしておく
+	fs := flag.NewFlagSet("go-gin-todo-app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", "", "path to config file (.yaml, .yml, .toml)")
+	env := fs.String("env", "", "dev or production")
+	addr := fs.String("addr", "", "HTTP listen address")
+	dbPath := fs.String("db", "", "SQLite database file path")
+	jwtSecret := fs.String("jwt-secret", "", "JWT signing secret")
+	logLevel := fs.String("log-level", "", "debug, info, warn or error")
+	logFormat := fs.String("log-format", "", "json or text")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// 1. 設定ファイル
+	path := *configPath
+	if path == "" {
+		path = getenv("APP_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 2. 環境変数
+	setFromEnv(&cfg.Env, getenv("APP_ENV"))
+	setFromEnv(&cfg.Server.Addr, getenv("APP_ADDR"))
+	setFromEnv(&cfg.DB.Path, getenv("APP_DB_PATH"))
+	setFromEnv(&cfg.JWT.Secret, getenv("APP_JWT_SECRET"))
+	setFromEnv(&cfg.Log.Level, getenv("APP_LOG_LEVEL"))
+	setFromEnv(&cfg.Log.Format, getenv("APP_LOG_FORMAT"))
+	setFromEnv(&cfg.Log.Output, getenv("APP_LOG_OUTPUT"))
+	setFromEnv(&cfg.Admin.Token, getenv("APP_ADMIN_TOKEN"))
+	setFromEnv(&cfg.Metrics.Token, getenv("APP_METRICS_TOKEN"))
+	setFromEnv(&cfg.Tracing.Exporter, getenv("APP_TRACING_EXPORTER"))
+	setFromEnv(&cfg.Tracing.Endpoint, getenv("APP_TRACING_ENDPOINT"))
+	if err := errors.Join(
+		setDurationFromEnv(&cfg.Server.ReadTimeout, "APP_SERVER_READ_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.Server.WriteTimeout, "APP_SERVER_WRITE_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.Server.IdleTimeout, "APP_SERVER_IDLE_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.Server.ShutdownDelay, "APP_SERVER_SHUTDOWN_DELAY", getenv),
+		setDurationFromEnv(&cfg.Server.ShutdownTimeout, "APP_SERVER_SHUTDOWN_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.JWT.AccessTTL, "APP_JWT_ACCESS_TTL", getenv),
+		setDurationFromEnv(&cfg.JWT.RefreshTTL, "APP_JWT_REFRESH_TTL", getenv),
+		setDurationFromEnv(&cfg.JWT.PurgeInterval, "APP_JWT_PURGE_INTERVAL", getenv),
+		setDurationFromEnv(&cfg.Trash.Retention, "APP_TRASH_RETENTION", getenv),
+		setDurationFromEnv(&cfg.Trash.PurgeInterval, "APP_TRASH_PURGE_INTERVAL", getenv),
+		setDurationFromEnv(&cfg.Idempotency.Retention, "APP_IDEMPOTENCY_RETENTION", getenv),
+		setDurationFromEnv(&cfg.Idempotency.PurgeInterval, "APP_IDEMPOTENCY_PURGE_INTERVAL", getenv),
+		setDurationFromEnv(&cfg.Events.Heartbeat, "APP_EVENTS_HEARTBEAT", getenv),
+		setDurationFromEnv(&cfg.Webhooks.Timeout, "APP_WEBHOOKS_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.Webhooks.RetryBase, "APP_WEBHOOKS_RETRY_BASE", getenv),
+		setDurationFromEnv(&cfg.Webhooks.Retention, "APP_WEBHOOKS_RETENTION", getenv),
+		setDurationFromEnv(&cfg.Webhooks.PurgeInterval, "APP_WEBHOOKS_PURGE_INTERVAL", getenv),
+		setBoolFromEnv(&cfg.Webhooks.AllowPrivateTargets, "APP_WEBHOOKS_ALLOW_PRIVATE_TARGETS", getenv),
+		setDurationFromEnv(&cfg.Health.CheckTimeout, "APP_HEALTH_CHECK_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.Health.CacheTTL, "APP_HEALTH_CACHE_TTL", getenv),
+		setDurationFromEnv(&cfg.Health.MaxJobLag, "APP_HEALTH_MAX_JOB_LAG", getenv),
+		setIntFromEnv(&cfg.Health.MinFreeDiskMB, "APP_HEALTH_MIN_FREE_DISK_MB", getenv),
+		setIntFromEnv(&cfg.Jobs.Workers, "APP_JOBS_WORKERS", getenv),
+		setDurationFromEnv(&cfg.Jobs.PollInterval, "APP_JOBS_POLL_INTERVAL", getenv),
+		setDurationFromEnv(&cfg.Jobs.VisibilityTimeout, "APP_JOBS_VISIBILITY_TIMEOUT", getenv),
+		setDurationFromEnv(&cfg.Jobs.RetryBase, "APP_JOBS_RETRY_BASE", getenv),
+		setDurationFromEnv(&cfg.Jobs.Retention, "APP_JOBS_RETENTION", getenv),
+		setDurationFromEnv(&cfg.Jobs.PurgeInterval, "APP_JOBS_PURGE_INTERVAL", getenv),
+		setDurationFromEnv(&cfg.Jobs.DrainTimeout, "APP_JOBS_DRAIN_TIMEOUT", getenv),
+		setFloatFromEnv(&cfg.Tracing.SampleRatio, "APP_TRACING_SAMPLE_RATIO", getenv),
+		setIntFromEnv(&cfg.Log.MaxSizeMB, "APP_LOG_MAX_SIZE_MB", getenv),
+		setIntFromEnv(&cfg.Log.MaxBackups, "APP_LOG_MAX_BACKUPS", getenv),
+		setIntFromEnv(&cfg.Log.Sampling.Initial, "APP_LOG_SAMPLING_INITIAL", getenv),
+		setIntFromEnv(&cfg.Log.Sampling.Thereafter, "APP_LOG_SAMPLING_THEREAFTER", getenv),
+		setDurationFromEnv(&cfg.Log.Sampling.Tick, "APP_LOG_SAMPLING_TICK", getenv),
+	); err != nil {
+		return nil, nil, err
+	}
+
+	// 3. フラグ（明示的に指定されたものだけ上書き）
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Env = *env
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "db":
+			cfg.DB.Path = *dbPath
+		case "jwt-secret":
+			cfg.JWT.Secret = *jwtSecret
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, fs.Args(), nil
+}
+
+// loadFile は設定ファイルを読み込む。知らないキーがあればエラーにする（タイプミスを起動時に見つける）
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, cfg, yaml.DisallowUnknownField())
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(cfg)
+	default:
+		return fmt.Errorf("unsupported config file type: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func setFromEnv(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+func setDurationFromEnv(dst *Duration, key string, getenv func(string) string) error {
+	value := getenv(key)
+	if value == "" {
+		return nil
+	}
+	if err := dst.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("%s is invalid: %w", key, err)
+	}
+	return nil
+}
+
+func setIntFromEnv(dst *int, key string, getenv func(string) string) error {
+	value := getenv(key)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s is invalid: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setFloatFromEnv(dst *float64, key string, getenv func(string) string) error {
+	value := getenv(key)
+	if value == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%s is invalid: %w", key, err)
+	}
+	*dst = f
+	return nil
+}
+
+func setBoolFromEnv(dst *bool, key string, getenv func(string) string) error {
+	value := getenv(key)
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%s is invalid: %w", key, err)
+	}
+	*dst = b
+	return nil
+}
@@ -0,0 +1,452 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func envMap(m map[string]string) func(string) string {
+	return func(key string) string {
+		return m[key]
+	}
+}
+
+// デフォルト < ファイル < 環境変数 < フラグ の順で上書きされること
+func TestLoad_Precedence(t *testing.T) {
+	yamlPath := writeFile(t, "config.yaml", `
+server:
+  addr: ":9000"
+db:
+  path: file.db
+log:
+  level: warn
+`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		expectAddr string
+		expectDB   string
+		expectLog  string
+	}{
+		{
+			name:       "defaults",
+			args:       nil,
+			env:        nil,
+			expectAddr: ":8080",
+			expectDB:   "app.db",
+			expectLog:  "info",
+		},
+		{
+			name:       "file overrides defaults",
+			args:       []string{"-config", yamlPath},
+			env:        nil,
+			expectAddr: ":9000",
+			expectDB:   "file.db",
+			expectLog:  "warn",
+		},
+		{
+			name:       "env overrides file",
+			args:       nil,
+			env:        map[string]string{"APP_CONFIG": yamlPath, "APP_DB_PATH": "env.db"},
+			expectAddr: ":9000",
+			expectDB:   "env.db",
+			expectLog:  "warn",
+		},
+		{
+			name:       "flag overrides env",
+			args:       []string{"-config", yamlPath, "-db", "flag.db", "-log-level", "debug"},
+			env:        map[string]string{"APP_DB_PATH": "env.db"},
+			expectAddr: ":9000",
+			expectDB:   "flag.db",
+			expectLog:  "debug",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			cfg, _, err := load(tt.args, envMap(tt.env))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cfg.Server.Addr != tt.expectAddr {
+				t.Errorf("addr mismatch: expected %s, got %s", tt.expectAddr, cfg.Server.Addr)
+			}
+			if cfg.DB.Path != tt.expectDB {
+				t.Errorf("db path mismatch: expected %s, got %s", tt.expectDB, cfg.DB.Path)
+			}
+			if cfg.Log.Level != tt.expectLog {
+				t.Errorf("log level mismatch: expected %s, got %s", tt.expectLog, cfg.Log.Level)
+			}
+		})
+	}
+}
+
+func TestLoad_TOML(t *testing.T) {
+	tomlPath := writeFile(t, "config.toml", `
+env = "production"
+
+[jwt]
+secret = "from-toml-0123456789abcdef0123456789"
+access_ttl = "5m"
+`)
+
+	cfg, _, err := load([]string{"-config", tomlPath}, envMap(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Env != EnvProduction || cfg.JWT.Secret != "from-toml-0123456789abcdef0123456789" {
+		t.Errorf("toml not applied: %+v", cfg)
+	}
+	if cfg.JWT.AccessTTL.Duration != 5*time.Minute {
+		t.Errorf("access_ttl mismatch: got %s", cfg.JWT.AccessTTL)
+	}
+}
+
+func TestLoad_YAMLDuration(t *testing.T) {
+	yamlPath := writeFile(t, "config.yaml", `
+jwt:
+  refresh_ttl: 48h
+  purge_interval: 6h
+trash:
+  retention: 168h
+idempotency:
+  retention: 12h
+events:
+  heartbeat: 30s
+webhooks:
+  retry_base: 1m
+server:
+  write_timeout: 1m
+  shutdown_delay: 0s
+health:
+  cache_ttl: 0s
+  min_free_disk_mb: 500
+jobs:
+  workers: 8
+  drain_timeout: 1m
+tracing:
+  exporter: otlp
+  sample_ratio: 0.25
+log:
+  format: text
+  output: /var/log/app.log
+  sampling:
+    initial: 10
+    tick: 5s
+`)
+
+	cfg, _, err := load([]string{"-config", yamlPath}, envMap(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.JWT.RefreshTTL.Duration != 48*time.Hour || cfg.JWT.PurgeInterval.Duration != 6*time.Hour {
+		t.Errorf("jwt mismatch: got %s / %s", cfg.JWT.RefreshTTL, cfg.JWT.PurgeInterval)
+	}
+	if cfg.Trash.Retention.Duration != 7*24*time.Hour || cfg.Trash.PurgeInterval.Duration != time.Hour {
+		t.Errorf("trash mismatch: got %s / %s", cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	}
+	if cfg.Idempotency.Retention.Duration != 12*time.Hour {
+		t.Errorf("idempotency retention mismatch: got %s", cfg.Idempotency.Retention)
+	}
+	if cfg.Events.Heartbeat.Duration != 30*time.Second {
+		t.Errorf("events heartbeat mismatch: got %s", cfg.Events.Heartbeat)
+	}
+	if cfg.Webhooks.RetryBase.Duration != time.Minute || cfg.Webhooks.Timeout.Duration != 10*time.Second {
+		t.Errorf("webhooks mismatch: got %s / %s", cfg.Webhooks.RetryBase, cfg.Webhooks.Timeout)
+	}
+	if cfg.Server.WriteTimeout.Duration != time.Minute || cfg.Server.ShutdownTimeout.Duration != 30*time.Second || cfg.Server.ShutdownDelay.Duration != 0 {
+		t.Errorf("server timeouts mismatch: got %+v", cfg.Server)
+	}
+	if cfg.Health.CacheTTL.Duration != 0 || cfg.Health.MinFreeDiskMB != 500 || cfg.Health.CheckTimeout.Duration != 2*time.Second {
+		t.Errorf("health mismatch: got %+v", cfg.Health)
+	}
+	if cfg.Jobs.Workers != 8 || cfg.Jobs.DrainTimeout.Duration != time.Minute || cfg.Jobs.VisibilityTimeout.Duration != 5*time.Minute {
+		t.Errorf("jobs mismatch: got %+v", cfg.Jobs)
+	}
+	if cfg.Tracing.Exporter != "otlp" || cfg.Tracing.SampleRatio != 0.25 {
+		t.Errorf("tracing mismatch: got %+v", cfg.Tracing)
+	}
+	if cfg.Log.Format != "text" || cfg.Log.Output != "/var/log/app.log" || cfg.Log.MaxSizeMB != 100 ||
+		cfg.Log.Sampling.Initial != 10 || cfg.Log.Sampling.Thereafter != 100 || cfg.Log.Sampling.Tick.Duration != 5*time.Second {
+		t.Errorf("log mismatch: got %+v", cfg.Log)
+	}
+}
+
+// 知らないキー（タイプミス）は起動時にエラーにする
+func TestLoad_UnknownKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name:    "yaml top level",
+			file:    "config.yaml",
+			content: "envv: production\n",
+		},
+		{
+			name:    "yaml nested",
+			file:    "config.yaml",
+			content: "server:\n  shutdown_timout: 10s\n",
+		},
+		{
+			name:    "toml top level",
+			file:    "config.toml",
+			content: "envv = \"production\"\n",
+		},
+		{
+			name:    "toml nested",
+			file:    "config.toml",
+			content: "[server]\nshutdown_timout = \"10s\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+			if _, _, err := load([]string{"-config", path}, envMap(nil)); err == nil {
+				t.Errorf("expected error for unknown key")
+			}
+		})
+	}
+}
+
+func TestLoad_ExampleFile(t *testing.T) {
+	if _, _, err := load([]string{"-config", "../config.example.yaml"}, envMap(nil)); err != nil {
+		t.Errorf("config.example.yaml should load: %v", err)
+	}
+}
+
+func TestLoad_RemainingArgs(t *testing.T) {
+	_, args, err := load([]string{"-db", "x.db", "migrate", "status"}, envMap(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(args) != 2 || args[0] != "migrate" || args[1] != "status" {
+		t.Errorf("unexpected remaining args: %v", args)
+	}
+}
+
+func TestLoad_Validate(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		expectErr bool
+	}{
+		{
+			name:      "default secret allowed in dev",
+			env:       nil,
+			expectErr: false,
+		},
+		{
+			name:      "default secret rejected in production",
+			env:       map[string]string{"APP_ENV": "production"},
+			expectErr: true,
+		},
+		{
+			name:      "custom secret allowed in production",
+			env:       map[string]string{"APP_ENV": "production", "APP_JWT_SECRET": strings.Repeat("k", MinJWTSecretLength)},
+			expectErr: false,
+		},
+		{
+			name:      "example secret rejected in production",
+			env:       map[string]string{"APP_ENV": "production", "APP_JWT_SECRET": "change-me"},
+			expectErr: true,
+		},
+		{
+			name:      "placeholder secret rejected regardless of case",
+			env:       map[string]string{"APP_ENV": "production", "APP_JWT_SECRET": "Change-Me"},
+			expectErr: true,
+		},
+		{
+			name:      "short secret rejected in production",
+			env:       map[string]string{"APP_ENV": "production", "APP_JWT_SECRET": strings.Repeat("k", MinJWTSecretLength-1)},
+			expectErr: true,
+		},
+		{
+			name:      "short secret allowed in dev",
+			env:       map[string]string{"APP_JWT_SECRET": "s3cret"},
+			expectErr: false,
+		},
+		{
+			name:      "unknown env",
+			args:      []string{"-env", "staging"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid log level",
+			args:      []string{"-log-level", "verbose"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid log format",
+			args:      []string{"-log-format", "xml"},
+			expectErr: true,
+		},
+		{
+			name:      "non-positive log max size",
+			env:       map[string]string{"APP_LOG_MAX_SIZE_MB": "0"},
+			expectErr: true,
+		},
+		{
+			name:      "negative log sampling",
+			env:       map[string]string{"APP_LOG_SAMPLING_INITIAL": "-1"},
+			expectErr: true,
+		},
+		{
+			name:      "empty db path",
+			args:      []string{"-db", ""},
+			expectErr: true,
+		},
+		{
+			name:      "missing config file",
+			args:      []string{"-config", "does-not-exist.yaml"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid duration env",
+			env:       map[string]string{"APP_JWT_ACCESS_TTL": "soon"},
+			expectErr: true,
+		},
+		{
+			name:      "refresh ttl shorter than access ttl",
+			env:       map[string]string{"APP_JWT_ACCESS_TTL": "2h", "APP_JWT_REFRESH_TTL": "1h"},
+			expectErr: true,
+		},
+		{
+			name:      "negative shutdown delay",
+			env:       map[string]string{"APP_SERVER_SHUTDOWN_DELAY": "-1s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero token purge interval",
+			env:       map[string]string{"APP_JWT_PURGE_INTERVAL": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero trash retention",
+			env:       map[string]string{"APP_TRASH_RETENTION": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero idempotency retention",
+			env:       map[string]string{"APP_IDEMPOTENCY_RETENTION": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero events heartbeat",
+			env:       map[string]string{"APP_EVENTS_HEARTBEAT": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero webhooks timeout",
+			env:       map[string]string{"APP_WEBHOOKS_TIMEOUT": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero server read timeout",
+			env:       map[string]string{"APP_SERVER_READ_TIMEOUT": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero jobs workers",
+			env:       map[string]string{"APP_JOBS_WORKERS": "0"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid jobs workers env",
+			env:       map[string]string{"APP_JOBS_WORKERS": "many"},
+			expectErr: true,
+		},
+		{
+			name:      "zero jobs drain timeout",
+			env:       map[string]string{"APP_JOBS_DRAIN_TIMEOUT": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "zero health check timeout",
+			env:       map[string]string{"APP_HEALTH_CHECK_TIMEOUT": "0s"},
+			expectErr: true,
+		},
+		{
+			name:      "negative health min free disk",
+			env:       map[string]string{"APP_HEALTH_MIN_FREE_DISK_MB": "-1"},
+			expectErr: true,
+		},
+		{
+			name:      "short metrics token",
+			env:       map[string]string{"APP_METRICS_TOKEN": "short"},
+			expectErr: true,
+		},
+		{
+			name:      "unknown tracing exporter",
+			env:       map[string]string{"APP_TRACING_EXPORTER": "jaeger"},
+			expectErr: true,
+		},
+		{
+			name:      "tracing sample ratio out of range",
+			env:       map[string]string{"APP_TRACING_SAMPLE_RATIO": "1.5"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid tracing sample ratio",
+			env:       map[string]string{"APP_TRACING_SAMPLE_RATIO": "all"},
+			expectErr: true,
+		},
+		{
+			name:      "short admin token",
+			env:       map[string]string{"APP_ADMIN_TOKEN": "short"},
+			expectErr: true,
+		},
+		{
+			name:      "private webhook targets allowed in dev",
+			env:       map[string]string{"APP_WEBHOOKS_ALLOW_PRIVATE_TARGETS": "true"},
+			expectErr: false,
+		},
+		{
+			name:      "private webhook targets rejected in production",
+			env:       map[string]string{"APP_ENV": "production", "APP_JWT_SECRET": strings.Repeat("k", MinJWTSecretLength), "APP_WEBHOOKS_ALLOW_PRIVATE_TARGETS": "true"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid private webhook targets env",
+			env:       map[string]string{"APP_WEBHOOKS_ALLOW_PRIVATE_TARGETS": "maybe"},
+			expectErr: true,
+		},
+		{
+			name:      "unknown flag",
+			args:      []string{"-port", "80"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, _, err := load(tt.args, envMap(tt.env))
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error=%v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"log"
+	"strings"
 
 	"github.com/glebarez/sqlite" // ← これが modernc ベースのドライバ
 	"gorm.io/gorm"
@@ -9,11 +10,44 @@ import (
 
 var DB *gorm.DB
 
-func Init() {
+// TimeFormat は SQLite ドライバが datetime 列に書き込む形式。
+// 文字列として比較するクエリ（keyset ページングなど）はこの形式に揃える。
+const TimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// Init は DB を開き、未適用のマイグレーションを全て適用する
+func Init(path string) {
+	Open(path)
+
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		log.Fatal("failed to load migrations:", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		log.Fatal("failed to migrate database:", err)
+	}
+}
+
+// Open は DB を開くだけ（migrate サブコマンド用）
+func Open(path string) {
 	var err error
 
-	DB, err = gorm.Open(sqlite.Open("app.db"), &gorm.Config{})
+	// ワーカーとリクエストが同時に書き込むので、ロックが解けるまで少し待つ
+	dsn := path + "?_pragma=busy_timeout(5000)"
+	if strings.Contains(path, "?") {
+		dsn = path + "&_pragma=busy_timeout(5000)"
+	}
+
+	DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: queryLogger{}})
 	if err != nil {
 		log.Fatal("failed to connect database:", err)
 	}
 }
+
+// Close は DB の接続を閉じる（シャットダウン時）
+func Close() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold を超えたクエリは warn で出す
+const SlowQueryThreshold = 200 * time.Millisecond
+
+// queryLogger は GORM のログを ctx のロガー（logger.FromContext）に出す。
+// リクエスト中のクエリ（WithContext したもの）には request_id などが付く。
+// 失敗したクエリは error、遅いクエリは warn、それ以外は debug
+type queryLogger struct{}
+
+func (l queryLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (queryLogger) Info(ctx context.Context, msg string, args ...any) {
+	logger.Info(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Warn(ctx context.Context, msg string, args ...any) {
+	logger.Warn(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Error(ctx context.Context, msg string, args ...any) {
+	logger.Error(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	log := logger.FromContext(ctx)
+
+	level, msg := slog.LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "query failed"
+	case elapsed > SlowQueryThreshold:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	// SQL を組み立てるのは出力するときだけ
+	if !log.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	args := []any{"sql", sql, "rows", rows, "duration", elapsed.String()}
+	if level == slog.LevelError {
+		args = append(args, "reason", err.Error())
+	}
+	log.Log(ctx, level, msg, args...)
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// ファイル名は "0001_create_users.up.sql" / "0001_create_users.down.sql" の形式
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+	ErrNoMigration      = errors.New("no migration to roll back")
+)
+
+// Migration は 1 バージョン分の up/down SQL
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum は up/down SQL の SHA-256（適用後の改変検知用）
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up + "\x00" + m.Down))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationStatus は status コマンドで表示する 1 行分の情報
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// schema_migrations テーブルの行
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	Checksum  string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// LoadMigrations は fsys 直下の *.sql をバージョン順に読み込む
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", e.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names: %s, %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator は schema_migrations を使ってマイグレーションを管理する
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator は埋め込み済みのマイグレーションを使う Migrator を返す
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sub, err := fs.Sub(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	return NewMigratorWith(db, migrations), nil
+}
+
+// NewMigratorWith は任意のマイグレーション一覧を使う Migrator を返す
+func NewMigratorWith(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+func (m *Migrator) ensureTable() error {
+	return m.db.Exec("CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` integer PRIMARY KEY," +
+		"`name` text NOT NULL," +
+		"`checksum` text NOT NULL," +
+		"`applied_at` datetime NOT NULL)").Error
+}
+
+// applied は適用済みのマイグレーションを返し、チェックサムを検証する
+func (m *Migrator) applied() (map[int]schemaMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	known := map[int]Migration{}
+	for _, mg := range m.migrations {
+		known[mg.Version] = mg
+	}
+
+	result := map[int]schemaMigration{}
+	for _, row := range rows {
+		if mg, ok := known[row.Version]; ok && mg.Checksum() != row.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, row.Version, row.Name)
+		}
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Status は全マイグレーションの適用状況を返す
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if row, ok := applied[mg.Version]; ok {
+			appliedAt := row.AppliedAt
+			st.Applied = true
+			st.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Up は未適用のマイグレーションを順番に全て適用し、適用した件数を返す
+func (m *Migrator) Up() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		if err := m.apply(mg); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down は最後に適用したマイグレーションを 1 つ巻き戻す
+func (m *Migrator) Down() (*Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if err := m.rollback(mg); err != nil {
+			return nil, err
+		}
+		return &mg, nil
+	}
+	return nil, ErrNoMigration
+}
+
+// Redo は最後のマイグレーションを巻き戻してから再適用する
+func (m *Migrator) Redo() (*Migration, error) {
+	mg, err := m.Down()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.apply(*mg); err != nil {
+		return nil, err
+	}
+	return mg, nil
+}
+
+func (m *Migrator) apply(mg Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mg.Up).Error; err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", mg.Version, mg.Name, err)
+		}
+		return tx.Create(&schemaMigration{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			Checksum:  mg.Checksum(),
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+func (m *Migrator) rollback(mg Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mg.Down).Error; err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", mg.Version, mg.Name, err)
+		}
+		return tx.Delete(&schemaMigration{}, mg.Version).Error
+	})
+}
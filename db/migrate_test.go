@@ -0,0 +1,180 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// テスト用に一時ディレクトリへ SQLite ファイルを作る
+func openTempDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	conn, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := conn.DB()
+		sqlDB.Close()
+	})
+	return conn
+}
+
+func tableExists(t *testing.T, conn *gorm.DB, name string) bool {
+	t.Helper()
+
+	var count int64
+	conn.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	return count > 0
+}
+
+// 全マイグレーションを up → down → up できること
+func TestMigrator_AllMigrations(t *testing.T) {
+	conn := openTempDB(t)
+
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	total := len(statuses)
+	if total == 0 {
+		t.Fatalf("expected embedded migrations")
+	}
+
+	n, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("up failed: %v", err)
+	}
+	if n != total {
+		t.Errorf("expected %d applied, got %d", total, n)
+	}
+	for _, table := range []string{"users", "todos"} {
+		if !tableExists(t, conn, table) {
+			t.Errorf("table %s should exist after up", table)
+		}
+	}
+
+	// 2 回目は何も適用しない
+	n, err = migrator.Up()
+	if err != nil || n != 0 {
+		t.Errorf("second up should be no-op: n=%d err=%v", n, err)
+	}
+
+	// redo は最後の 1 件を巻き戻して再適用する
+	if _, err := migrator.Redo(); err != nil {
+		t.Fatalf("redo failed: %v", err)
+	}
+
+	// 全部巻き戻す
+	for i := 0; i < total; i++ {
+		if _, err := migrator.Down(); err != nil {
+			t.Fatalf("down #%d failed: %v", i, err)
+		}
+	}
+	if _, err := migrator.Down(); !errors.Is(err, db.ErrNoMigration) {
+		t.Errorf("expected ErrNoMigration, got %v", err)
+	}
+	for _, table := range []string{"users", "todos"} {
+		if tableExists(t, conn, table) {
+			t.Errorf("table %s should not exist after down", table)
+		}
+	}
+
+	statuses, _ = migrator.Status()
+	for _, st := range statuses {
+		if st.Applied {
+			t.Errorf("migration %d should be pending", st.Version)
+		}
+	}
+
+	// もう一度全部適用できること
+	if n, err := migrator.Up(); err != nil || n != total {
+		t.Errorf("re-up failed: n=%d err=%v", n, err)
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	conn := openTempDB(t)
+
+	original := []db.Migration{
+		{Version: 1, Name: "create_items", Up: "CREATE TABLE items (id integer);", Down: "DROP TABLE items;"},
+	}
+	if _, err := db.NewMigratorWith(conn, original).Up(); err != nil {
+		t.Fatalf("up failed: %v", err)
+	}
+
+	modified := []db.Migration{
+		{Version: 1, Name: "create_items", Up: "CREATE TABLE items (id integer, name text);", Down: "DROP TABLE items;"},
+	}
+	_, err := db.NewMigratorWith(conn, modified).Up()
+	if !errors.Is(err, db.ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		files     fstest.MapFS
+		expectErr bool
+		expectLen int
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"0002_b.up.sql":   {Data: []byte("SELECT 2;")},
+				"0002_b.down.sql": {Data: []byte("SELECT 2;")},
+				"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+				"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			expectErr: false,
+			expectLen: 2,
+		},
+		{
+			name: "missing down file",
+			files: fstest.MapFS{
+				"0001_a.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"create_users.sql": {Data: []byte("SELECT 1;")},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			migrations, err := db.LoadMigrations(tt.files)
+
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error=%v, got %v", tt.expectErr, err)
+			}
+			if len(migrations) != tt.expectLen {
+				t.Errorf("expected %d migrations, got %d", tt.expectLen, len(migrations))
+			}
+			for i := 1; i < len(migrations); i++ {
+				if migrations[i-1].Version >= migrations[i].Version {
+					t.Errorf("migrations not sorted: %v", migrations)
+				}
+			}
+		})
+	}
+}
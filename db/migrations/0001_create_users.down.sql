@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_users_deleted_at`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `user_id` integer NOT NULL,
+  `family_id` text NOT NULL,
+  `token_hash` text NOT NULL,
+  `access_jti` text NOT NULL,
+  `access_expires_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `revoked_at` datetime,
+  `replaced_by_id` integer,
+  CONSTRAINT `uni_refresh_tokens_token_hash` UNIQUE (`token_hash`)
+);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_access_jti` ON `refresh_tokens`(`access_jti`);
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (
+  `jti` text PRIMARY KEY,
+  `user_id` integer NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_revoked_tokens_expires_at` ON `revoked_tokens`(`expires_at`);
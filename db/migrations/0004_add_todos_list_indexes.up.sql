@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS `idx_todos_user_created` ON `todos`(`user_id`, `created_at`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_todos_user_updated` ON `todos`(`user_id`, `updated_at`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_todos_user_title` ON `todos`(`user_id`, `title`, `id`);
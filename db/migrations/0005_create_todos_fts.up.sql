@@ -0,0 +1,23 @@
+-- todos.title の全文検索用（外部コンテンツテーブル、トリガーで同期）
+CREATE VIRTUAL TABLE IF NOT EXISTS `todos_fts` USING fts5(
+  title,
+  content='todos',
+  content_rowid='id',
+  tokenize='unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS `todos_fts_after_insert` AFTER INSERT ON `todos` BEGIN
+  INSERT INTO `todos_fts`(rowid, title) VALUES (new.id, new.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS `todos_fts_after_delete` AFTER DELETE ON `todos` BEGIN
+  INSERT INTO `todos_fts`(`todos_fts`, rowid, title) VALUES ('delete', old.id, old.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS `todos_fts_after_update` AFTER UPDATE OF title ON `todos` BEGIN
+  INSERT INTO `todos_fts`(`todos_fts`, rowid, title) VALUES ('delete', old.id, old.title);
+  INSERT INTO `todos_fts`(rowid, title) VALUES (new.id, new.title);
+END;
+
+-- 既存の todos を取り込む
+INSERT INTO `todos_fts`(`todos_fts`) VALUES ('rebuild');
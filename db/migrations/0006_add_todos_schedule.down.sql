@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_todos_user_due`;
+ALTER TABLE `todos` DROP COLUMN `completed_at`;
+ALTER TABLE `todos` DROP COLUMN `start_at`;
+ALTER TABLE `todos` DROP COLUMN `due_all_day`;
+ALTER TABLE `todos` DROP COLUMN `due_at`;
@@ -0,0 +1,5 @@
+ALTER TABLE `todos` ADD COLUMN `due_at` datetime;
+ALTER TABLE `todos` ADD COLUMN `due_all_day` numeric NOT NULL DEFAULT 0;
+ALTER TABLE `todos` ADD COLUMN `start_at` datetime;
+ALTER TABLE `todos` ADD COLUMN `completed_at` datetime;
+CREATE INDEX IF NOT EXISTS `idx_todos_user_due` ON `todos`(`user_id`, `due_at`);
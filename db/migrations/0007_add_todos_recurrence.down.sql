@@ -0,0 +1,3 @@
+ALTER TABLE `todos` DROP COLUMN `recurrence_start`;
+ALTER TABLE `todos` DROP COLUMN `recurrence_tz`;
+ALTER TABLE `todos` DROP COLUMN `rrule`;
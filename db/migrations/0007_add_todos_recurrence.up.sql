@@ -0,0 +1,3 @@
+ALTER TABLE `todos` ADD COLUMN `rrule` text NOT NULL DEFAULT '';
+ALTER TABLE `todos` ADD COLUMN `recurrence_tz` text NOT NULL DEFAULT '';
+ALTER TABLE `todos` ADD COLUMN `recurrence_start` datetime;
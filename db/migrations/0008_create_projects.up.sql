@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `projects` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `user_id` integer NOT NULL,
+  `name` text NOT NULL,
+  `color` text NOT NULL DEFAULT '',
+  `position` integer NOT NULL DEFAULT 0,
+  `archived_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_projects_deleted_at` ON `projects`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_projects_user_position` ON `projects`(`user_id`, `position`, `id`);
+
+ALTER TABLE `todos` ADD COLUMN `project_id` integer;
+CREATE INDEX IF NOT EXISTS `idx_todos_user_project` ON `todos`(`user_id`, `project_id`);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `tags` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `user_id` integer NOT NULL,
+  `name` text NOT NULL COLLATE NOCASE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_tags_user_name` ON `tags`(`user_id`, `name`);
+
+CREATE TABLE IF NOT EXISTS `todo_tags` (
+  `todo_id` integer NOT NULL,
+  `tag_id` integer NOT NULL,
+  PRIMARY KEY (`todo_id`, `tag_id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_todo_tags_tag_id` ON `todo_tags`(`tag_id`, `todo_id`);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_todos_user_parent`;
+ALTER TABLE `todos` DROP COLUMN `auto_complete`;
+ALTER TABLE `todos` DROP COLUMN `parent_id`;
@@ -0,0 +1,3 @@
+ALTER TABLE `todos` ADD COLUMN `parent_id` integer;
+ALTER TABLE `todos` ADD COLUMN `auto_complete` numeric NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS `idx_todos_user_parent` ON `todos`(`user_id`, `parent_id`);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `user_id` integer NOT NULL,
+  `key` text NOT NULL,
+  `fingerprint` text NOT NULL,
+  `status_code` integer NOT NULL DEFAULT 0,
+  `content_type` text NOT NULL DEFAULT '',
+  `response_body` blob
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_idempotency_keys_user_key` ON `idempotency_keys`(`user_id`, `key`);
+CREATE INDEX IF NOT EXISTS `idx_idempotency_keys_created_at` ON `idempotency_keys`(`created_at`);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS `todos_sync_after_delete`;
+DROP TRIGGER IF EXISTS `todos_sync_after_update`;
+DROP TRIGGER IF EXISTS `todos_sync_after_insert`;
+DROP TABLE IF EXISTS `sync_purges`;
+DROP TABLE IF EXISTS `sync_sequence`;
+DROP INDEX IF EXISTS `idx_todos_user_change_seq`;
+ALTER TABLE `todos` DROP COLUMN `change_seq`;
+ALTER TABLE `todos` DROP COLUMN `created_seq`;
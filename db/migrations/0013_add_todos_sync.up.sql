@@ -0,0 +1,39 @@
+-- 差分同期用の変更番号。todos の行を作成・更新するたびにトリガーで全体で単調増加する番号を振る
+ALTER TABLE `todos` ADD COLUMN `created_seq` integer NOT NULL DEFAULT 0;
+ALTER TABLE `todos` ADD COLUMN `change_seq` integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS `idx_todos_user_change_seq` ON `todos`(`user_id`, `change_seq`);
+
+CREATE TABLE IF NOT EXISTS `sync_sequence` (
+  `id` integer PRIMARY KEY CHECK (`id` = 1),
+  `seq` integer NOT NULL
+);
+
+-- 完全に削除した todo の最後の変更番号（これより前から同期するクライアントは削除を受け取れない）
+CREATE TABLE IF NOT EXISTS `sync_purges` (
+  `user_id` integer PRIMARY KEY,
+  `seq` integer NOT NULL
+);
+
+-- 既存の todos は id 順に番号を振る
+UPDATE `todos` SET `created_seq` = `id`, `change_seq` = `id`;
+INSERT INTO `sync_sequence`(`id`, `seq`) VALUES (1, (SELECT coalesce(max(`id`), 0) FROM `todos`));
+
+CREATE TRIGGER IF NOT EXISTS `todos_sync_after_insert` AFTER INSERT ON `todos` BEGIN
+  UPDATE `sync_sequence` SET `seq` = `seq` + 1 WHERE `id` = 1;
+  UPDATE `todos` SET
+    `created_seq` = (SELECT `seq` FROM `sync_sequence` WHERE `id` = 1),
+    `change_seq` = (SELECT `seq` FROM `sync_sequence` WHERE `id` = 1)
+  WHERE `id` = new.`id`;
+END;
+
+-- 変更番号だけの更新（このトリガー自身の UPDATE）では振り直さない
+CREATE TRIGGER IF NOT EXISTS `todos_sync_after_update` AFTER UPDATE ON `todos`
+WHEN new.`change_seq` = old.`change_seq` BEGIN
+  UPDATE `sync_sequence` SET `seq` = `seq` + 1 WHERE `id` = 1;
+  UPDATE `todos` SET `change_seq` = (SELECT `seq` FROM `sync_sequence` WHERE `id` = 1) WHERE `id` = new.`id`;
+END;
+
+CREATE TRIGGER IF NOT EXISTS `todos_sync_after_delete` AFTER DELETE ON `todos` BEGIN
+  INSERT INTO `sync_purges`(`user_id`, `seq`) VALUES (old.`user_id`, old.`change_seq`)
+  ON CONFLICT(`user_id`) DO UPDATE SET `seq` = max(`seq`, excluded.`seq`);
+END;
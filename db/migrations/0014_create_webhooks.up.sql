@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `webhooks` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `user_id` integer NOT NULL,
+  `url` text NOT NULL,
+  `events` text NOT NULL,
+  `secret` text NOT NULL,
+  `active` numeric NOT NULL DEFAULT 1,
+  `failure_count` integer NOT NULL DEFAULT 0,
+  `disabled_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_webhooks_user_id` ON `webhooks`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `webhook_id` integer NOT NULL,
+  `user_id` integer NOT NULL,
+  `event` text NOT NULL,
+  `payload` blob NOT NULL,
+  `status` text NOT NULL DEFAULT 'pending',
+  `attempts` integer NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime,
+  `response_status` integer NOT NULL DEFAULT 0,
+  `error` text NOT NULL DEFAULT '',
+  `delivered_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_due` ON `webhook_deliveries`(`status`, `next_attempt_at`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_created_at` ON `webhook_deliveries`(`created_at`);
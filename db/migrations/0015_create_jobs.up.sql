@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `jobs` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `type` text NOT NULL,
+  `payload` blob NOT NULL,
+  `status` text NOT NULL DEFAULT 'pending',
+  `attempts` integer NOT NULL DEFAULT 0,
+  `max_attempts` integer NOT NULL,
+  `run_at` datetime NOT NULL,
+  `leased_until` datetime,
+  `lease_token` text NOT NULL DEFAULT '',
+  `last_error` text NOT NULL DEFAULT '',
+  `finished_at` datetime,
+  `unique_key` text
+);
+CREATE INDEX IF NOT EXISTS `idx_jobs_status_run_at` ON `jobs`(`status`, `run_at`);
+CREATE INDEX IF NOT EXISTS `idx_jobs_status_leased_until` ON `jobs`(`status`, `leased_until`);
+-- 同じ unique_key の未完了のジョブは 1 つだけ
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_jobs_unique_key` ON `jobs`(`unique_key`)
+  WHERE `unique_key` IS NOT NULL AND `status` IN ('pending', 'running');
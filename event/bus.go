@@ -0,0 +1,158 @@
+// Package event はプロセス内のイベントバス。
+//
+// サービス層が Publish したイベントを、同じユーザーの購読者（SSE / WebSocket の接続）に配る。
+// 直近のイベントを履歴として持ち、再接続したクライアントには Last-Event-ID の次から送り直す。
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// イベントの種類
+const (
+	TodoCreated = "todo.created"
+	TodoUpdated = "todo.updated"
+	TodoDeleted = "todo.deleted"
+
+	// 購読の制御用（Publish はしない）
+	Reset     = "reset"     // 履歴から送り直せないので、クライアントは取得し直す
+	Heartbeat = "heartbeat" // 接続を保つための定期的な送信
+)
+
+const (
+	DefaultHistorySize = 1000 // 再接続で送り直せる直近のイベント数（全ユーザー合計）
+	subscriberBuffer   = 64   // これ以上溜まった遅い購読者は切断する（再接続で送り直す）
+)
+
+// Event は 1 件のイベント。ID はプロセス内で単調増加する
+type Event struct {
+	ID     uint64    `json:"id"`
+	Type   string    `json:"type"`
+	UserID uint      `json:"-"`
+	Data   any       `json:"data"`
+	Time   time.Time `json:"time"`
+}
+
+// Subscription は 1 つの購読。C が閉じられたら購読は終わっている（遅すぎる・Close された）
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	userID uint
+}
+
+type Bus struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event // 古い順、最大 historySize 件
+	size    int
+	subs    map[uint]map[*Subscription]struct{}
+	closed  bool
+}
+
+// historySize は再接続で送り直せる直近のイベント数
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{size: historySize, subs: make(map[uint]map[*Subscription]struct{})}
+}
+
+// Publish はイベントを履歴に追加して、userID の購読者に送る
+func (b *Bus) Publish(userID uint, typ string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.nextID++
+	ev := Event{ID: b.nextID, Type: typ, UserID: userID, Data: data, Time: time.Now()}
+
+	if len(b.history) == b.size {
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	b.history = append(b.history, ev)
+
+	for sub := range b.subs[userID] {
+		select {
+		case sub.c <- ev:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe は userID のイベントを購読する。
+// lastEventID が 0 でなければ、その次からの履歴を返す。
+// 履歴が残っていない（古すぎる・再起動前の ID）なら resumed は false で、クライアントは取得し直す必要がある
+func (b *Bus) Subscribe(userID uint, lastEventID uint64) (sub *Subscription, backlog []Event, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, userID: userID}
+	if b.closed {
+		close(c)
+		return sub, nil, false
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+
+	// lastEventID の次のイベントが履歴に残っていれば送り直せる
+	oldest := b.nextID + 1
+	if len(b.history) > 0 {
+		oldest = b.history[0].ID
+	}
+	if lastEventID > b.nextID || lastEventID+1 < oldest {
+		return sub, nil, false
+	}
+	for _, ev := range b.history {
+		if ev.ID > lastEventID && ev.UserID == userID {
+			backlog = append(backlog, ev)
+		}
+	}
+	return sub, backlog, true
+}
+
+// Unsubscribe は購読をやめる（何度呼んでもよい）
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// Close は全ての購読を終わらせ、以降の Publish を無視する（シャットダウン用）
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	subs, ok := b.subs[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	close(sub.c)
+}
@@ -0,0 +1,143 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/event"
+)
+
+// receive は購読に届いているイベントを全て取り出す
+func receive(sub *event.Subscription) []event.Event {
+	var events []event.Event
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return events
+			}
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+// closed は購読が終わっているかどうか
+func closed(sub *event.Subscription) bool {
+	for {
+		select {
+		case _, ok := <-sub.C:
+			if !ok {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+// --- Publish ---
+func TestBus_Publish(t *testing.T) {
+
+	bus := event.NewBus(10)
+	sub1, _, _ := bus.Subscribe(1, 0)
+	sub2, _, _ := bus.Subscribe(2, 0)
+
+	bus.Publish(1, event.TodoCreated, "a")
+	bus.Publish(2, event.TodoUpdated, "b")
+	bus.Publish(1, event.TodoDeleted, "c")
+
+	events := receive(sub1)
+	if len(events) != 2 || events[0].Data != "a" || events[1].Data != "c" {
+		t.Fatalf("unexpected events for user 1: %+v", events)
+	}
+	if events[0].ID >= events[1].ID || events[0].Type != event.TodoCreated {
+		t.Errorf("unexpected event order: %+v", events)
+	}
+	if events := receive(sub2); len(events) != 1 || events[0].Data != "b" {
+		t.Errorf("unexpected events for user 2: %+v", events)
+	}
+
+	bus.Unsubscribe(sub1)
+	bus.Unsubscribe(sub1) // 2 回目は何もしない
+	bus.Publish(1, event.TodoCreated, "d")
+	if !closed(sub1) {
+		t.Errorf("expected unsubscribed channel to be closed")
+	}
+}
+
+// --- Subscribe (Last-Event-ID) ---
+func TestBus_Subscribe_Resume(t *testing.T) {
+
+	bus := event.NewBus(3)
+	for _, data := range []string{"a", "b", "c", "d"} { // id 1..4、履歴は 2..4
+		bus.Publish(1, event.TodoUpdated, data)
+	}
+	bus.Publish(2, event.TodoUpdated, "other") // id 5、履歴は 3..5
+
+	tests := []struct {
+		name          string
+		lastEventID   uint64
+		expectData    []string
+		expectResumed bool
+	}{
+		{name: "no last event id", lastEventID: 0, expectResumed: true},
+		{name: "resume from history", lastEventID: 2, expectData: []string{"c", "d"}, expectResumed: true},
+		{name: "up to date", lastEventID: 5, expectResumed: true},
+		{name: "too old", lastEventID: 1, expectResumed: false},
+		{name: "unknown id (before restart)", lastEventID: 99, expectResumed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			sub, backlog, resumed := bus.Subscribe(1, tt.lastEventID)
+			defer bus.Unsubscribe(sub)
+
+			if resumed != tt.expectResumed {
+				t.Errorf("expected resumed=%v, got %v", tt.expectResumed, resumed)
+			}
+			var data []string
+			for _, ev := range backlog {
+				data = append(data, ev.Data.(string))
+			}
+			if len(data) != len(tt.expectData) {
+				t.Fatalf("expected %v, got %v", tt.expectData, data)
+			}
+			for i := range data {
+				if data[i] != tt.expectData[i] {
+					t.Errorf("expected %v, got %v", tt.expectData, data)
+				}
+			}
+		})
+	}
+}
+
+// --- 遅い購読者 / Close ---
+func TestBus_SlowSubscriberAndClose(t *testing.T) {
+
+	bus := event.NewBus(0)
+	slow, _, _ := bus.Subscribe(1, 0)
+	other, _, _ := bus.Subscribe(2, 0)
+
+	// 読まれないまま溢れた購読は切断される
+	for i := 0; i < 100; i++ {
+		bus.Publish(1, event.TodoUpdated, i)
+	}
+	if !closed(slow) {
+		t.Errorf("expected slow subscriber to be dropped")
+	}
+	if closed(other) {
+		t.Errorf("expected other subscriber to stay open")
+	}
+
+	bus.Close()
+	if !closed(other) {
+		t.Errorf("expected subscriber to be closed by Close")
+	}
+	sub, _, resumed := bus.Subscribe(1, 0)
+	if resumed || !closed(sub) {
+		t.Errorf("expected subscribe after Close to be closed")
+	}
+	bus.Publish(1, event.TodoUpdated, "ignored") // Close 後は何もしない
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
-	"github.com/a5415091-collab/go-gin-todo-app/jwt"
 	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/metrics"
 	"github.com/a5415091-collab/go-gin-todo-app/service"
 	"github.com/gin-gonic/gin"
 )
@@ -19,64 +21,161 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 
 // POST /signup
 func (h *AuthHandler) Signup(c *gin.Context) {
-	logger.Logger.Info("request received", "handler", "Signup")
+	logger.Info(c.Request.Context(), "request received")
 
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required,min=6,max=64"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Logger.Warn("signup validation failed", "reason", err.Error())
+		logger.Warn(c.Request.Context(), "signup validation failed", "reason", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "invalid email or password",
 		})
 		return
 	}
 
-	err := h.authService.Signup(req.Email, req.Password)
+	err := h.authService.Signup(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		logger.Logger.Error("signup failed", "email", req.Email, "reason", err.Error())
+		logger.Error(c.Request.Context(), "signup failed", "email", req.Email, "reason", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	logger.Logger.Info("user signup", "email", req.Email)
+	logger.Info(c.Request.Context(), "user signup", "email", req.Email)
 
 	c.JSON(http.StatusOK, gin.H{"message": "signup success"})
 }
 
 // POST /login
 func (h *AuthHandler) Login(c *gin.Context) {
-	logger.Logger.Info("request received", "handler", "Login")
+	logger.Info(c.Request.Context(), "request received")
 
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required,min=6,max=64"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Logger.Warn("login validation failed", "reason", err.Error())
+		logger.Warn(c.Request.Context(), "login validation failed", "reason", err.Error())
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure).Inc()
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "invalid email or password",
 		})
 		return
 	}
 
-	user, err := h.authService.Login(req.Email, req.Password)
+	user, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		logger.Logger.Warn("login failed", "email", req.Email, "reason", err.Error())
+		logger.Warn(c.Request.Context(), "login failed", "email", req.Email, "reason", err.Error())
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure).Inc()
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 		return
 	}
 
-	token, err := jwt.CreateToken(user.ID)
+	tokens, err := h.authService.IssueTokens(c.Request.Context(), user.ID)
 	if err != nil {
+		logger.Error(c.Request.Context(), "failed to create token", "userID", user.ID, "reason", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
 		return
 	}
 
-	logger.Logger.Info("user login success", "email", req.Email)
+	logger.Info(c.Request.Context(), "user login success", "email", req.Email)
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginSuccess).Inc()
 	c.JSON(http.StatusOK, gin.H{
-		"message": "login success",
-		"token":   token,
+		"message":       "login success",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(time.Until(tokens.ExpiresAt).Seconds()),
 	})
 }
+
+// POST /token/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "refresh validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, service.ErrRefreshTokenReused) {
+		logger.Warn(c.Request.Context(), "refresh token reuse detected", "reason", err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		logger.Warn(c.Request.Context(), "refresh failed", "reason", err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "refresh failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "token refresh success")
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "refresh success",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(time.Until(tokens.ExpiresAt).Seconds()),
+	})
+}
+
+// POST /logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	jti := c.GetString("jti")
+	expiresAt := c.GetTime("tokenExpiresAt")
+	logger.Info(c.Request.Context(), "request received")
+
+	if err := h.authService.Logout(c.Request.Context(), userID, jti, expiresAt); err != nil {
+		logger.Error(c.Request.Context(), "logout failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "user logout")
+	c.JSON(http.StatusOK, gin.H{"message": "logout success"})
+}
+
+// POST /logout-all
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	if err := h.authService.LogoutAll(c.Request.Context(), userID); err != nil {
+		logger.Error(c.Request.Context(), "logout all failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "user logout all")
+	c.JSON(http.StatusOK, gin.H{"message": "logout success"})
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/event"
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// SSE の再接続までの待ち時間（ミリ秒、EventSource の retry）
+const sseRetryMillis = 3000
+
+// WebSocket の 1 回の送信を待つ上限（超えたら遅いクライアントとして切断する）
+const wsWriteTimeout = 10 * time.Second
+
+type EventHandler struct {
+	bus       *event.Bus
+	heartbeat time.Duration
+}
+
+// heartbeat は何も起きていなくても接続を保つために送る間隔
+func NewEventHandler(bus *event.Bus, heartbeat time.Duration) *EventHandler {
+	return &EventHandler{bus, heartbeat}
+}
+
+// --- GET /events (Server-Sent Events) ---
+// 例: new EventSource("/events?access_token=...")。再接続時は Last-Event-ID の次から送り直す
+func (h *EventHandler) StreamEvents(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	lastEventID := lastEventID(c)
+	logger.Info(c.Request.Context(), "request received", "lastEventID", lastEventID)
+
+	keepOpen(c)
+	sub, backlog, resumed := h.bus.Subscribe(userID, lastEventID)
+	defer h.bus.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // リバースプロキシでバッファしない
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillis)
+	if !resumed {
+		writeSSE(w, event.Event{Type: event.Reset, Time: time.Now()})
+	}
+	for _, ev := range backlog {
+		writeSSE(w, ev)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	expired := tokenExpired(c)
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			logger.Info(c.Request.Context(), "event stream closed by client")
+			return
+		case <-expired.C:
+			// トークンの期限で切る（クライアントは新しいトークンで再接続する）
+			logger.Info(c.Request.Context(), "event stream token expired")
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				// 遅すぎて切られたか、シャットダウンでバスが閉じた（クライアントは Last-Event-ID で再接続する）
+				logger.Warn(c.Request.Context(), "event stream dropped")
+				return
+			}
+			writeSSE(w, ev)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// writeSSE は 1 件のイベントを SSE の形式で書く（data は Event 全体の JSON）
+func writeSSE(w io.Writer, ev event.Event) {
+	data, _ := json.Marshal(ev)
+	if ev.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", ev.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+}
+
+// --- GET /ws (WebSocket) ---
+// 例: new WebSocket("/ws?access_token=...&last_event_id=42")。
+// イベントは 1 件ずつ JSON のテキストメッセージで送り、heartbeat ごとに {"type": "heartbeat"} を送る
+func (h *EventHandler) StreamWebSocket(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	lastEventID := lastEventID(c)
+	logger.Info(c.Request.Context(), "request received", "lastEventID", lastEventID)
+
+	keepOpen(c)
+	server := websocket.Server{
+		// トークンで認証するので Origin は確認しない（ブラウザ以外のクライアントは Origin を付けない）
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			sub, backlog, resumed := h.bus.Subscribe(userID, lastEventID)
+			defer h.bus.Unsubscribe(sub)
+
+			send := func(ev event.Event) bool {
+				ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if err := websocket.JSON.Send(ws, ev); err != nil {
+					logger.Warn(c.Request.Context(), "websocket send failed", "reason", err.Error())
+					return false
+				}
+				return true
+			}
+
+			if !resumed && !send(event.Event{Type: event.Reset, Time: time.Now()}) {
+				return
+			}
+			for _, ev := range backlog {
+				if !send(ev) {
+					return
+				}
+			}
+
+			// クライアントからのメッセージは読み捨てて、切断だけを検知する
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var msg string
+				for websocket.Message.Receive(ws, &msg) == nil {
+				}
+			}()
+
+			heartbeat := time.NewTicker(h.heartbeat)
+			defer heartbeat.Stop()
+			expired := tokenExpired(c)
+			defer expired.Stop()
+
+			for {
+				select {
+				case <-closed:
+					logger.Info(c.Request.Context(), "websocket closed by client")
+					return
+				case <-expired.C:
+					logger.Info(c.Request.Context(), "websocket token expired")
+					return
+				case ev, ok := <-sub.C:
+					if !ok {
+						logger.Warn(c.Request.Context(), "websocket dropped")
+						return
+					}
+					if !send(ev) {
+						return
+					}
+				case <-heartbeat.C:
+					if !send(event.Event{Type: event.Heartbeat, Time: time.Now()}) {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// keepOpen は http.Server の ReadTimeout / WriteTimeout を接続から外す。
+// 配信は長く続くので、付けたままだとタイムアウトで切れてしまう
+func keepOpen(c *gin.Context) {
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		logger.Warn(c.Request.Context(), "failed to clear read deadline", "reason", err.Error())
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn(c.Request.Context(), "failed to clear write deadline", "reason", err.Error())
+	}
+}
+
+// lastEventID は Last-Event-ID ヘッダー（EventSource の再接続）か ?last_event_id= を読む。なければ 0
+func lastEventID(c *gin.Context) uint64 {
+	s := c.GetHeader("Last-Event-ID")
+	if s == "" {
+		s = c.Query("last_event_id")
+	}
+	id, _ := strconv.ParseUint(s, 10, 64)
+	return id
+}
+
+// tokenExpired はアクセストークンの期限に発火するタイマー（AuthMiddleware が設定した期限）
+func tokenExpired(c *gin.Context) *time.Timer {
+	return time.NewTimer(time.Until(c.GetTime("tokenExpiresAt")))
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/a5415091-collab/go-gin-todo-app/health"
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{registry}
+}
+
+// --- GET /livez ---
+// プロセスが応答できるか（依存先は確認しない。失敗したら再起動するためのもの）
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// --- GET /readyz ---
+// 依存先を確認して、リクエストを受けられるか返す。受けられなければ 503。
+// 例: {"status": "fail", "checks": {"db": {"status": "ok", ...}, "jobs": {"status": "fail", "error": "..."}}}
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.registry.Report(c.Request.Context())
+	if !report.OK() {
+		logger.Warn(c.Request.Context(), "readiness check failed", "status", report.Status)
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/a5415091-collab/go-gin-todo-app/jobs"
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	queue *jobs.Queue
+}
+
+func NewJobHandler(queue *jobs.Queue) *JobHandler {
+	return &JobHandler{queue}
+}
+
+// --- GET /admin/jobs (一覧) ---
+// 例: /admin/jobs?status=dead&type=purge.trash&limit=20
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	var req struct {
+		Status string `form:"status"`
+		Type   string `form:"type"`
+		Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "get jobs validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: limit must be 1-200"})
+		return
+	}
+	statuses := []string{model.JobPending, model.JobRunning, model.JobSucceeded, model.JobDead}
+	if req.Status != "" && !slices.Contains(statuses, req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: status must be pending, running, succeeded or dead"})
+		return
+	}
+	logger.Info(c.Request.Context(), "request received", "status", req.Status, "type", req.Type)
+
+	list, err := h.queue.List(req.Status, req.Type, req.Limit)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get jobs", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	counts, err := h.queue.Counts()
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to count jobs", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get jobs success", "count", len(list))
+	c.JSON(http.StatusOK, gin.H{"jobs": list, "counts": counts})
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// LogHandler は実行中のログレベルを見る・変える（再起動すると log.level に戻る）
+type LogHandler struct{}
+
+func NewLogHandler() *LogHandler {
+	return &LogHandler{}
+}
+
+// --- GET /admin/log/level ---
+func (h *LogHandler) GetLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(logger.Level().String())})
+}
+
+// --- PUT /admin/log/level ---
+// 例: {"level": "debug"}
+func (h *LogHandler) SetLevel(c *gin.Context) {
+	var req struct {
+		Level string `json:"level" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "set log level validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: level is required"})
+		return
+	}
+	level, err := logger.ParseLevel(req.Level)
+	if errors.Is(err, logger.ErrInvalidLevel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := logger.Level()
+	logger.SetLevel(level)
+	// 変えたことは warn 以上にしても残るように warn で出す
+	logger.Warn(c.Request.Context(), "log level changed", "from", previous.String(), "to", level.String())
+	c.JSON(http.StatusOK, gin.H{"level": strings.ToLower(level.String())})
+}
@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectHandler struct {
+	projectService service.ProjectService
+}
+
+func NewProjectHandler(projectService service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{projectService}
+}
+
+// --- GET /projects (一覧) ---
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	// 例: /projects?archived=true（アーカイブ済みも含める）
+	var req struct {
+		Archived bool `form:"archived"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "get projects validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: archived must be true/false"})
+		return
+	}
+
+	projects, err := h.projectService.FindAll(c.Request.Context(), userID, req.Archived)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get projects", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get projects success", "count", len(projects))
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+// --- GET /projects/:id (詳細) ---
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "projectID", id)
+
+	project, err := h.projectService.FindByID(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		logger.Warn(c.Request.Context(), "project not found", "projectID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get project success", "projectID", id)
+	c.JSON(http.StatusOK, project)
+}
+
+// --- POST /projects (新規作成) ---
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		Name  string `json:"name" binding:"required,min=1,max=100"`
+		Color string `json:"color"` // "#RRGGBB"
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "create project validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required and must be 1-100 characters"})
+		return
+	}
+
+	project, err := h.projectService.Create(c.Request.Context(), userID, service.ProjectInput{Name: req.Name, Color: req.Color})
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "create project validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to create project", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "create project success", "projectID", project.ID)
+	c.JSON(http.StatusOK, project)
+}
+
+// --- PUT /projects/:id (更新・並び替え) ---
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "projectID", id)
+
+	var req struct {
+		Name     string `json:"name" binding:"required,min=1,max=100"`
+		Color    string `json:"color"`
+		Position *int   `json:"position"` // 0 始まり。省略なら並び順を変えない
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "update project validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required and must be 1-100 characters"})
+		return
+	}
+
+	input := service.ProjectInput{Name: req.Name, Color: req.Color, Position: req.Position}
+	project, err := h.projectService.Update(c.Request.Context(), userID, uint(id), input)
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "update project validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrProjectNotFound) {
+		logger.Warn(c.Request.Context(), "project not found", "projectID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "update project failed", "projectID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "update project success", "projectID", id)
+	c.JSON(http.StatusOK, project)
+}
+
+// --- POST /projects/:id/archive?todos=complete ---
+func (h *ProjectHandler) ArchiveProject(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "projectID", id)
+
+	// todos=keep（デフォルト）は Todo をそのまま残し、complete は未完了の Todo を完了にする
+	var req struct {
+		Todos string `form:"todos" binding:"omitempty,oneof=keep complete"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "archive project validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: todos must be keep or complete"})
+		return
+	}
+
+	project, err := h.projectService.Archive(c.Request.Context(), userID, uint(id), req.Todos == "complete")
+	if errors.Is(err, service.ErrProjectNotFound) {
+		logger.Warn(c.Request.Context(), "project not found", "projectID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "archive project failed", "projectID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "archive project success", "projectID", id)
+	c.JSON(http.StatusOK, project)
+}
+
+// --- POST /projects/:id/unarchive ---
+func (h *ProjectHandler) UnarchiveProject(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "projectID", id)
+
+	project, err := h.projectService.Unarchive(c.Request.Context(), userID, uint(id))
+	if errors.Is(err, service.ErrProjectNotFound) {
+		logger.Warn(c.Request.Context(), "project not found", "projectID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "unarchive project failed", "projectID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "unarchive project success", "projectID", id)
+	c.JSON(http.StatusOK, project)
+}
+
+// --- DELETE /projects/:id?todos=unassign|delete ---
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "projectID", id)
+
+	err := h.projectService.Delete(c.Request.Context(), userID, uint(id), c.Query("todos"))
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "delete project validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrProjectNotFound) {
+		logger.Warn(c.Request.Context(), "project not found", "projectID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "delete project failed", "projectID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "delete project success", "projectID", id)
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
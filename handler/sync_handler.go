@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	syncService service.SyncService
+}
+
+func NewSyncHandler(syncService service.SyncService) *SyncHandler {
+	return &SyncHandler{syncService}
+}
+
+// --- GET /sync (差分の取得) ---
+// 例: /sync?since=<前回の next_token>&limit=500（since を省略すると全件）
+func (h *SyncHandler) GetChanges(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		Since string `form:"since"`
+		Limit int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "sync validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: limit must be 1-1000"})
+		return
+	}
+
+	changes, err := h.syncService.Pull(c.Request.Context(), userID, req.Since, req.Limit)
+	if errors.Is(err, service.ErrInvalidSyncToken) {
+		logger.Warn(c.Request.Context(), "sync invalid token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrSyncTokenExpired) {
+		logger.Warn(c.Request.Context(), "sync token expired")
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "sync failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "sync success",
+		"created", len(changes.Created), "updated", len(changes.Updated), "deleted", len(changes.Deleted))
+	c.JSON(http.StatusOK, changes)
+}
+
+// クライアントの変更 1 件分の結果
+type syncResult struct {
+	Index    int         `json:"index"`
+	ClientID string      `json:"client_id,omitempty"`
+	ID       uint        `json:"id,omitempty"`
+	Status   string      `json:"status"` // applied | conflict | rejected
+	Todo     *model.Todo `json:"todo,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// --- POST /sync (クライアントの変更の送信) ---
+// 例: {"changes": [{"op": "create", "client_id": "tmp-1", "todo": {"title": "a", "done": true}},
+// {"op": "update", "id": 3, "base_version": 2, "patch": {"title": "b"}}]}
+func (h *SyncHandler) PushChanges(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		Changes []struct {
+			Op          string          `json:"op" binding:"required"` // create | update | delete
+			ClientID    string          `json:"client_id"`
+			ID          uint            `json:"id"`
+			BaseVersion uint            `json:"base_version"`
+			Patch       json.RawMessage `json:"patch"` // update: 変更した項目だけ（JSON Merge Patch）
+			Todo        struct {
+				ProjectID    *uint  `json:"project_id"`
+				ParentID     *uint  `json:"parent_id"`
+				AutoComplete bool   `json:"auto_complete"`
+				Title        string `json:"title"`
+				Done         bool   `json:"done"`
+				DueAt        string `json:"due_at"`
+				StartAt      string `json:"start_at"`
+				RRule        string `json:"rrule"`
+				TZ           string `json:"tz"`
+			} `json:"todo"` // create
+		} `json:"changes" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "sync push validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "changes are required and each must have an op"})
+		return
+	}
+
+	changes := make([]service.SyncChange, len(req.Changes))
+	for i, change := range req.Changes {
+		changes[i] = service.SyncChange{
+			Op:          change.Op,
+			ClientID:    change.ClientID,
+			ID:          change.ID,
+			BaseVersion: change.BaseVersion,
+			Input: service.TodoInput{
+				ProjectID:    change.Todo.ProjectID,
+				ParentID:     change.Todo.ParentID,
+				AutoComplete: change.Todo.AutoComplete,
+				Title:        change.Todo.Title,
+				Due:          change.Todo.DueAt,
+				Start:        change.Todo.StartAt,
+				RRule:        change.Todo.RRule,
+				TZ:           change.Todo.TZ,
+			},
+			Done:  change.Todo.Done,
+			Patch: change.Patch,
+		}
+	}
+
+	results, err := h.syncService.Push(c.Request.Context(), userID, changes)
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "sync push validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "sync push failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]syncResult, len(results))
+	conflicts := 0
+	for i, result := range results {
+		items[i] = syncResult{Index: i, ClientID: result.ClientID, ID: result.ID, Status: result.Status, Todo: result.Todo}
+		if result.Err != nil {
+			items[i].Error = result.Err.Error()
+		}
+		if result.Status == service.SyncConflict {
+			conflicts++
+		}
+	}
+
+	logger.Info(c.Request.Context(), "sync push success", "changes", len(results), "conflicts", conflicts)
+	c.JSON(http.StatusOK, gin.H{"results": items})
+}
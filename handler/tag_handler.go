@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagService service.TagService
+}
+
+func NewTagHandler(tagService service.TagService) *TagHandler {
+	return &TagHandler{tagService}
+}
+
+// --- GET /tags (一覧) ---
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	tags, err := h.tagService.FindAll(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get tags", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get tags success", "count", len(tags))
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// --- POST /tags (新規作成) ---
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "create tag validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	tag, err := h.tagService.Create(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		h.writeError(c, "create tag failed", err)
+		return
+	}
+
+	logger.Info(c.Request.Context(), "create tag success", "tagID", tag.ID)
+	c.JSON(http.StatusOK, tag)
+}
+
+// --- PUT /tags/:id (名前変更) ---
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "tagID", id)
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "rename tag validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	tag, err := h.tagService.Rename(c.Request.Context(), userID, uint(id), req.Name)
+	if err != nil {
+		h.writeError(c, "rename tag failed", err)
+		return
+	}
+
+	logger.Info(c.Request.Context(), "rename tag success", "tagID", id)
+	c.JSON(http.StatusOK, tag)
+}
+
+// --- POST /tags/:id/merge (別のタグにまとめる) ---
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "tagID", id)
+
+	var req struct {
+		Into uint `json:"into" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "merge tag validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "into (tag id) is required"})
+		return
+	}
+
+	tag, err := h.tagService.Merge(c.Request.Context(), userID, uint(id), req.Into)
+	if err != nil {
+		h.writeError(c, "merge tag failed", err)
+		return
+	}
+
+	logger.Info(c.Request.Context(), "merge tag success", "tagID", id, "into", req.Into)
+	c.JSON(http.StatusOK, tag)
+}
+
+// --- DELETE /tags/:id ---
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "tagID", id)
+
+	if err := h.tagService.Delete(c.Request.Context(), userID, uint(id)); err != nil {
+		h.writeError(c, "delete tag failed", err)
+		return
+	}
+
+	logger.Info(c.Request.Context(), "delete tag success", "tagID", id)
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// --- PUT /todos/:id/tags/:tag_id (タグを付ける) ---
+func (h *TagHandler) AttachTag(c *gin.Context) {
+	h.changeTodoTag(c, h.tagService.Attach)
+}
+
+// --- DELETE /todos/:id/tags/:tag_id (タグを外す) ---
+func (h *TagHandler) DetachTag(c *gin.Context) {
+	h.changeTodoTag(c, h.tagService.Detach)
+}
+
+func (h *TagHandler) changeTodoTag(c *gin.Context, change func(ctx context.Context, userID, todoID, tagID uint) (*model.Todo, error)) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	todoID, _ := strconv.Atoi(c.Param("id"))
+	tagID, _ := strconv.Atoi(c.Param("tag_id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", todoID, "tagID", tagID)
+
+	todo, err := change(c.Request.Context(), userID, uint(todoID), uint(tagID))
+	if err != nil {
+		h.writeError(c, "change todo tag failed", err)
+		return
+	}
+
+	logger.Info(c.Request.Context(), "change todo tag success", "todoID", todoID, "tagID", tagID)
+	c.JSON(http.StatusOK, todo)
+}
+
+// writeError はタグ操作のエラーをステータスコードに変換する
+func (h *TagHandler) writeError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		logger.Warn(c.Request.Context(), msg, "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTagNotFound), errors.Is(err, service.ErrTodoNotFound):
+		logger.Warn(c.Request.Context(), msg, "reason", err.Error())
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTagExists):
+		logger.Warn(c.Request.Context(), msg, "reason", err.Error())
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logger.Error(c.Request.Context(), msg, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
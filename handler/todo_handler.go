@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
 	"github.com/a5415091-collab/go-gin-todo-app/service"
 	"github.com/gin-gonic/gin"
 )
@@ -21,9 +28,8 @@ func NewTodoHandler(todoService service.TodoService) *TodoHandler {
 func (h *TodoHandler) GetTodos(c *gin.Context) {
 	userIDAny, exists := c.Get("userID")
 	if !exists {
-		logger.Logger.Warn(
+		logger.Warn(c.Request.Context(),
 			"userID not found in context",
-			"handler", "GetTodos",
 			"error", "missing userID",
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
@@ -31,26 +37,215 @@ func (h *TodoHandler) GetTodos(c *gin.Context) {
 	}
 
 	userID := userIDAny.(uint)
-	logger.Logger.Info("request received", "handler", "GetTodos", "userID", userID)
+	logger.Info(c.Request.Context(), "request received")
 
-	todos, err := h.todoService.FindAll(userID)
+	h.listTodos(c, userID, nil)
+}
+
+// --- GET /projects/:id/todos (プロジェクト内の一覧) ---
+func (h *TodoHandler) GetProjectTodos(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "projectID", id)
+
+	projectID := uint(id)
+	h.listTodos(c, userID, &projectID)
+}
+
+// listTodos は GET /todos と GET /projects/:id/todos 共通のページング付き一覧
+func (h *TodoHandler) listTodos(c *gin.Context, userID uint, projectID *uint) {
+	// 例: /todos?limit=20&done=false&title=report&tags_all=urgent,backend&sort=updated_at&order=desc&cursor=...
+	var req struct {
+		Limit       int        `form:"limit" binding:"omitempty,min=1,max=200"`
+		Cursor      string     `form:"cursor"`
+		Done        *bool      `form:"done"`
+		CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+		CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+		UpdatedFrom *time.Time `form:"updated_from" time_format:"2006-01-02T15:04:05Z07:00"`
+		UpdatedTo   *time.Time `form:"updated_to" time_format:"2006-01-02T15:04:05Z07:00"`
+		Title       string     `form:"title" binding:"max=100"`
+		TagsAny     []string   `form:"tags_any" collection_format:"csv"`
+		TagsAll     []string   `form:"tags_all" collection_format:"csv"`
+		TagsNone    []string   `form:"tags_none" collection_format:"csv"`
+		Sort        string     `form:"sort" binding:"omitempty,oneof=created_at updated_at title"`
+		Order       string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "get todos validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid query: limit must be 1-200, times must be RFC3339, sort must be created_at/updated_at/title and order asc/desc",
+		})
+		return
+	}
+
+	page, err := h.todoService.FindAll(c.Request.Context(), userID, service.TodoListParams{
+		ProjectID:   projectID,
+		Limit:       req.Limit,
+		Cursor:      req.Cursor,
+		Done:        req.Done,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		UpdatedFrom: req.UpdatedFrom,
+		UpdatedTo:   req.UpdatedTo,
+		Title:       req.Title,
+		TagsAny:     req.TagsAny,
+		TagsAll:     req.TagsAll,
+		TagsNone:    req.TagsNone,
+		Sort:        req.Sort,
+		Order:       req.Order,
+	})
+	if errors.Is(err, service.ErrInvalidCursor) {
+		logger.Warn(c.Request.Context(), "get todos invalid cursor")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrProjectNotFound) {
+		logger.Warn(c.Request.Context(), "project not found", "projectID", *projectID)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get todos", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 一覧の ETag はレスポンスの内容から作る
+	body, err := json.Marshal(page)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to encode todos", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sum := sha256.Sum256(body)
+	if notModified(c, fmt.Sprintf(`"%x"`, sum[:16])) {
+		logger.Info(c.Request.Context(), "get todos not modified")
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get todos success", "count", len(page.Todos))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// --- GET /todos/search?q= (全文検索) ---
+func (h *TodoHandler) SearchTodos(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		Q     string `form:"q" binding:"required,max=200"`
+		Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "search todos validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required (max 200 chars) and limit must be 1-100"})
+		return
+	}
+
+	results, err := h.todoService.Search(c.Request.Context(), userID, req.Q, req.Limit)
+	if errors.Is(err, service.ErrEmptySearchQuery) || errors.Is(err, service.ErrTooManySearchTerms) {
+		logger.Warn(c.Request.Context(), "search todos invalid query", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to search todos", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "search todos success", "count", len(results))
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// --- GET /todos/overdue (期日超過) ---
+func (h *TodoHandler) GetOverdueTodos(c *gin.Context) {
+	h.getDueTodos(c, service.DueViewOverdue)
+}
+
+// --- GET /todos/today (今日が期日) ---
+func (h *TodoHandler) GetTodayTodos(c *gin.Context) {
+	h.getDueTodos(c, service.DueViewToday)
+}
+
+// --- GET /todos/upcoming?days=7 (明日以降) ---
+func (h *TodoHandler) GetUpcomingTodos(c *gin.Context) {
+	h.getDueTodos(c, service.DueViewUpcoming)
+}
+
+// 期日ビュー共通。タイムゾーンは ?tz= か X-Timezone ヘッダ（IANA 名、デフォルト UTC）
+func (h *TodoHandler) getDueTodos(c *gin.Context, view string) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		TZ   string `form:"tz"`
+		Days int    `form:"days" binding:"omitempty,min=1,max=90"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "due todos validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be 1-90"})
+		return
+	}
+
+	tz := req.TZ
+	if tz == "" {
+		tz = c.GetHeader("X-Timezone")
+	}
+	loc, err := time.LoadLocation(tz) // 空文字は UTC
 	if err != nil {
-		logger.Logger.Error("failed to get todos", "userID", userID, "reason", err.Error())
+		logger.Warn(c.Request.Context(), "due todos invalid timezone", "tz", tz)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timezone"})
+		return
+	}
+
+	todos, err := h.todoService.FindDue(c.Request.Context(), userID, view, loc, req.Days)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get due todos", "view", view, "reason", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	logger.Logger.Info("get todos success", "userID", userID, "count", len(todos))
-	c.JSON(http.StatusOK, todos)
+	logger.Info(c.Request.Context(), "get due todos success", "view", view, "count", len(todos))
+	c.JSON(http.StatusOK, gin.H{"todos": todos})
 }
 
 // --- GET /todos/:id (詳細) ---
 func (h *TodoHandler) GetTodo(c *gin.Context) {
 	userIDAny, exists := c.Get("userID")
 	if !exists {
-		logger.Logger.Warn(
+		logger.Warn(c.Request.Context(),
 			"userID not found in context",
-			"handler", "GetTodo",
 			"error", "missing userID",
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
@@ -59,16 +254,141 @@ func (h *TodoHandler) GetTodo(c *gin.Context) {
 
 	userID := userIDAny.(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
-	logger.Logger.Info("request received", "handler", "GetTodo", "userID", userID, "todoID", uint(id))
+	logger.Info(c.Request.Context(), "request received", "todoID", uint(id))
 
-	todo, err := h.todoService.FindByID(userID, uint(id))
+	todo, err := h.todoService.FindByID(c.Request.Context(), userID, uint(id))
 	if err != nil {
-		logger.Logger.Warn("todo not found", "todoID", id)
+		logger.Warn(c.Request.Context(), "todo not found", "todoID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+		return
+	}
+	if notModified(c, service.TodoETag(todo)) {
+		logger.Info(c.Request.Context(), "get todo not modified", "todoID", id)
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get todo success", "todoID", id)
+	c.JSON(http.StatusOK, todo)
+}
+
+// --- GET /todos/:id/occurrences (繰り返しの今後の期日) ---
+func (h *TodoHandler) GetOccurrences(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
+
+	// 例: /todos/1/occurrences?count=10
+	var req struct {
+		Count int `form:"count" binding:"omitempty,min=1,max=50"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "get occurrences validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: count must be 1-50"})
+		return
+	}
+
+	result, err := h.todoService.Occurrences(c.Request.Context(), userID, uint(id), req.Count)
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "get occurrences validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Warn(c.Request.Context(), "todo not found", "todoID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get occurrences success", "todoID", id, "count", len(result.Occurrences))
+	c.JSON(http.StatusOK, result)
+}
+
+// --- GET /todos/:id/children (サブタスク一覧) ---
+func (h *TodoHandler) GetChildren(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
+
+	children, err := h.todoService.Children(c.Request.Context(), userID, uint(id))
+	if errors.Is(err, service.ErrTodoNotFound) {
+		logger.Warn(c.Request.Context(), "todo not found", "todoID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get children", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get children success", "todoID", id, "count", len(children))
+	c.JSON(http.StatusOK, gin.H{"todos": children})
+}
+
+// --- POST /todos/:id/move (子孫ごと別の親へ移動) ---
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
+
+	// {"parent_id": 3} で 3 の子へ、{"parent_id": null} でルートへ
+	var req struct {
+		ParentID *uint `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "move todo validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id must be a todo id or null"})
+		return
+	}
+
+	todo, err := h.todoService.Move(c.Request.Context(), userID, uint(id), req.ParentID)
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "move todo validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrTodoNotFound) {
+		logger.Warn(c.Request.Context(), "todo not found", "todoID", id)
 		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
 		return
 	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "move todo failed", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	logger.Logger.Info("get todo success", "todoID", id)
+	logger.Info(c.Request.Context(), "move todo success", "todoID", id)
 	c.JSON(http.StatusOK, todo)
 }
 
@@ -76,9 +396,8 @@ func (h *TodoHandler) GetTodo(c *gin.Context) {
 func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	userIDAny, exists := c.Get("userID")
 	if !exists {
-		logger.Logger.Warn(
+		logger.Warn(c.Request.Context(),
 			"userID not found in context",
-			"handler", "CreateTodo",
 			"error", "missing userID",
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
@@ -86,25 +405,46 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	}
 
 	userID := userIDAny.(uint)
-	logger.Logger.Info("request received", "handler", "CreateTodo", "userID", userID)
+	logger.Info(c.Request.Context(), "request received")
 
 	var req struct {
-		Title string `json:"title" binding:"required,min=1,max=100"`
+		ProjectID    *uint  `json:"project_id"` // 省略・null なら未分類
+		ParentID     *uint  `json:"parent_id"`  // サブタスクにする場合の親
+		AutoComplete bool   `json:"auto_complete"`
+		Title        string `json:"title" binding:"required,min=1,max=100"`
+		DueAt        string `json:"due_at"`   // "2006-01-02"（終日）または RFC3339
+		StartAt      string `json:"start_at"` // RFC3339
+		RRule        string `json:"rrule"`    // "FREQ=WEEKLY;BYDAY=MO" など（due_at が必要）
+		TZ           string `json:"tz"`       // 時刻指定の繰り返しのタイムゾーン（IANA 名）
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Logger.Warn("create todo validation failed", "reason", err.Error())
+		logger.Warn(c.Request.Context(), "create todo validation failed", "reason", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required and must be 1-100 characters"})
 		return
 	}
 
-	todo, err := h.todoService.Create(userID, req.Title)
+	todo, err := h.todoService.Create(c.Request.Context(), userID, service.TodoInput{
+		ProjectID:    req.ProjectID,
+		ParentID:     req.ParentID,
+		AutoComplete: req.AutoComplete,
+		Title:        req.Title,
+		Due:          req.DueAt,
+		Start:        req.StartAt,
+		RRule:        req.RRule,
+		TZ:           req.TZ,
+	})
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "create todo validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		logger.Logger.Error("failed to create todo", "userID", userID, "reason", err.Error())
+		logger.Error(c.Request.Context(), "failed to create todo", "reason", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	logger.Logger.Info("create todo success", "todoID", todo.ID, "userID", userID)
+	logger.Info(c.Request.Context(), "create todo success", "todoID", todo.ID)
 	c.JSON(http.StatusOK, todo)
 }
 
@@ -112,9 +452,8 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 	userIDAny, exists := c.Get("userID")
 	if !exists {
-		logger.Logger.Warn(
+		logger.Warn(c.Request.Context(),
 			"userID not found in context",
-			"handler", "UpdateTodo",
 			"error", "missing userID",
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
@@ -123,28 +462,118 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 
 	userID := userIDAny.(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
-	logger.Logger.Info("request received", "handler", "UpdateTodo", "userID", userID, "todoID", id)
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
 
 	var req struct {
-		Title string `json:"title" binding:"required,min=1,max=100"`
-		Done  *bool  `json:"done" binding:"required"`
+		ProjectID    *uint  `json:"project_id"`
+		AutoComplete bool   `json:"auto_complete"`
+		Title        string `json:"title" binding:"required,min=1,max=100"`
+		Done         *bool  `json:"done" binding:"required"`
+		DueAt        string `json:"due_at"`
+		StartAt      string `json:"start_at"`
+		RRule        string `json:"rrule"`
+		TZ           string `json:"tz"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Logger.Warn("update validation failed", "reason", err.Error())
+		logger.Warn(c.Request.Context(), "update validation failed", "reason", err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "invalid request: title must be 1-100 chars and done must be true/false",
 		})
 		return
 	}
 
-	todo, err := h.todoService.Update(userID, uint(id), req.Title, req.Done)
+	input := service.TodoInput{
+		ProjectID:    req.ProjectID,
+		AutoComplete: req.AutoComplete,
+		Title:        req.Title,
+		Due:          req.DueAt,
+		Start:        req.StartAt,
+		RRule:        req.RRule,
+		TZ:           req.TZ,
+	}
+	todo, err := h.todoService.Update(c.Request.Context(), userID, uint(id), input, req.Done, precondition(c))
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "update validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if writeConflict(c, "update", id, err) {
+		return
+	}
 	if err != nil {
-		logger.Logger.Error("update failed", "todoID", id, "reason", err.Error())
+		logger.Error(c.Request.Context(), "update failed", "todoID", id, "reason", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	logger.Logger.Info("update todo success", "todoID", id)
+	logger.Info(c.Request.Context(), "update todo success", "todoID", id)
+	c.Header("ETag", service.TodoETag(todo))
+	c.JSON(http.StatusOK, todo)
+}
+
+// PATCH で受け付ける Content-Type（application/json はマージパッチとして扱う）
+var patchContentTypes = map[string]string{
+	"application/merge-patch+json": service.PatchMerge,
+	"application/json":             service.PatchMerge,
+	"application/json-patch+json":  service.PatchJSON,
+}
+
+// --- PATCH /todos/:id (部分更新) ---
+// 例: {"done": true}（merge-patch）、[{"op": "replace", "path": "/title", "value": "new"}]（json-patch）
+func (h *TodoHandler) PatchTodo(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
+
+	kind, ok := patchContentTypes[c.ContentType()]
+	if !ok {
+		logger.Warn(c.Request.Context(), "unsupported patch content type", "contentType", c.ContentType())
+		c.Header("Accept-Patch", "application/merge-patch+json, application/json-patch+json")
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be application/merge-patch+json or application/json-patch+json",
+		})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		logger.Warn(c.Request.Context(), "failed to read patch body", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	todo, err := h.todoService.Patch(c.Request.Context(), userID, uint(id), kind, body, precondition(c))
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "patch validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrPatchConflict) {
+		logger.Warn(c.Request.Context(), "patch test failed", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if writeConflict(c, "patch", id, err) {
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "patch failed", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "patch todo success", "todoID", id)
+	c.Header("ETag", service.TodoETag(todo))
 	c.JSON(http.StatusOK, todo)
 }
 
@@ -152,9 +581,8 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	userIDAny, exists := c.Get("userID")
 	if !exists {
-		logger.Logger.Warn(
+		logger.Warn(c.Request.Context(),
 			"userID not found in context",
-			"handler", "DeleteTodo",
 			"error", "missing userID",
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
@@ -163,15 +591,226 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 
 	userID := userIDAny.(uint)
 	id, _ := strconv.Atoi(c.Param("id"))
-	logger.Logger.Info("request received", "handler", "DeleteTodo", "userID", userID, "todoID", id)
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
 
-	err := h.todoService.Delete(userID, uint(id))
+	err := h.todoService.Delete(c.Request.Context(), userID, uint(id), precondition(c))
+	if writeConflict(c, "delete", id, err) {
+		return
+	}
 	if err != nil {
-		logger.Logger.Error("delete failed", "todoID", id, "reason", err.Error())
+		logger.Error(c.Request.Context(), "delete failed", "todoID", id, "reason", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	logger.Logger.Info("delete todo success", "todoID", id)
+	logger.Info(c.Request.Context(), "delete todo success", "todoID", id)
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
+
+// --- POST /todos/:id/restore (ゴミ箱から戻す) ---
+func (h *TodoHandler) RestoreTodo(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
+
+	todo, err := h.todoService.Restore(c.Request.Context(), userID, uint(id))
+	if errors.Is(err, service.ErrTodoNotFound) {
+		logger.Warn(c.Request.Context(), "todo not found in trash", "todoID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found in trash"})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "restore todo failed", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "restore todo success", "todoID", id)
+	c.JSON(http.StatusOK, todo)
+}
+
+// 一括操作の結果 1 件分（status は同じ操作を単体の API で行った場合のステータス）
+type bulkResult struct {
+	Index  int         `json:"index"`
+	Op     string      `json:"op"`
+	ID     uint        `json:"id,omitempty"`
+	Status int         `json:"status"`
+	ETag   string      `json:"etag,omitempty"`
+	Todo   *model.Todo `json:"todo,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// --- POST /todos/bulk (一括操作) ---
+// 例: {"mode": "per_item", "operations": [{"op": "complete", "id": 1, "if_match": "\"3\""}, {"op": "delete", "id": 2}]}
+// mode が atomic（既定）なら 1 つでも失敗すると全て取り消して、失敗した操作のステータスを返す
+func (h *TodoHandler) BulkTodos(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req struct {
+		Mode       string `json:"mode"` // atomic | per_item
+		Operations []struct {
+			Op      string `json:"op" binding:"required"` // create | update | complete | delete
+			ID      uint   `json:"id"`
+			IfMatch string `json:"if_match"` // If-Match ヘッダーと同じ形式
+			Todo    struct {
+				ProjectID    *uint  `json:"project_id"`
+				ParentID     *uint  `json:"parent_id"`
+				AutoComplete bool   `json:"auto_complete"`
+				Title        string `json:"title"`
+				Done         *bool  `json:"done"`
+				DueAt        string `json:"due_at"`
+				StartAt      string `json:"start_at"`
+				RRule        string `json:"rrule"`
+				TZ           string `json:"tz"`
+			} `json:"todo"` // create / update
+		} `json:"operations" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "bulk validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operations are required and each must have an op"})
+		return
+	}
+
+	ops := make([]service.BulkOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = service.BulkOperation{
+			Op: op.Op,
+			ID: op.ID,
+			Input: service.TodoInput{
+				ProjectID:    op.Todo.ProjectID,
+				ParentID:     op.Todo.ParentID,
+				AutoComplete: op.Todo.AutoComplete,
+				Title:        op.Todo.Title,
+				Due:          op.Todo.DueAt,
+				Start:        op.Todo.StartAt,
+				RRule:        op.Todo.RRule,
+				TZ:           op.Todo.TZ,
+			},
+			Done: op.Todo.Done,
+			Pre:  service.Precondition{IfMatch: parseETags(op.IfMatch)},
+		}
+	}
+
+	results, err := h.todoService.Bulk(c.Request.Context(), userID, req.Mode, ops)
+	var bulkErr *service.BulkError
+	if errors.As(err, &bulkErr) {
+		status, message := bulkStatus(bulkErr.Err)
+		logger.Warn(c.Request.Context(), "bulk operation failed", "index", bulkErr.Index, "reason", bulkErr.Err.Error())
+		c.JSON(status, gin.H{"error": message, "index": bulkErr.Index})
+		return
+	}
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "bulk validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "bulk failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]bulkResult, len(results))
+	failed := 0
+	for i, result := range results {
+		items[i] = bulkResult{Index: i, Op: result.Op, ID: result.ID, Todo: result.Todo}
+		items[i].Status, items[i].Error = bulkStatus(result.Err)
+		if result.Err != nil {
+			failed++
+		}
+		if result.Todo != nil {
+			items[i].ETag = service.TodoETag(result.Todo)
+		}
+	}
+
+	logger.Info(c.Request.Context(), "bulk success", "operations", len(results), "failed", failed)
+	c.JSON(http.StatusOK, gin.H{"results": items})
+}
+
+// bulkStatus は一括操作 1 件分のエラーを、単体の API と同じステータスとメッセージにする
+func bulkStatus(err error) (int, string) {
+	switch {
+	case err == nil:
+		return http.StatusOK, ""
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, service.ErrTodoNotFound):
+		return http.StatusNotFound, "todo not found"
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, "todo has been changed (ETag does not match)"
+	case errors.Is(err, service.ErrTodoModified):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// precondition は If-Match / If-None-Match ヘッダーを読む
+func precondition(c *gin.Context) service.Precondition {
+	return service.Precondition{
+		IfMatch:     parseETags(c.GetHeader("If-Match")),
+		IfNoneMatch: parseETags(c.GetHeader("If-None-Match")),
+	}
+}
+
+// parseETags はカンマ区切りの ETag（例: `"3", W/"4"`）を分ける。ヘッダーがなければ nil
+func parseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notModified は ETag ヘッダーを付け、If-None-Match と一致したら 304 を返す
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	pre := service.Precondition{IfNoneMatch: parseETags(c.GetHeader("If-None-Match"))}
+	if pre.Check(etag) != nil {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// writeConflict は更新・削除の条件の失敗（412）と同時更新（409）、対象がない（404）を返す
+func writeConflict(c *gin.Context, action string, id int, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrTodoNotFound):
+		logger.Warn(c.Request.Context(), "todo not found", "todoID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+	case errors.Is(err, service.ErrPreconditionFailed):
+		logger.Warn(c.Request.Context(), action+" precondition failed", "todoID", id)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "todo has been changed (ETag does not match)"})
+	case errors.Is(err, service.ErrTodoModified):
+		logger.Warn(c.Request.Context(), action+" conflict", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
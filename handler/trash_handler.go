@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TrashHandler struct {
+	trashService service.TrashService
+}
+
+func NewTrashHandler(trashService service.TrashService) *TrashHandler {
+	return &TrashHandler{trashService}
+}
+
+// --- GET /trash (ゴミ箱の一覧) ---
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	items, err := h.trashService.FindAll(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get trash", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get trash success", "count", len(items))
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// --- DELETE /trash/:id (完全に削除) ---
+func (h *TrashHandler) DeleteTrashTodo(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "todoID", id)
+
+	err := h.trashService.Delete(c.Request.Context(), userID, uint(id))
+	if errors.Is(err, service.ErrTodoNotFound) {
+		logger.Warn(c.Request.Context(), "todo not found in trash", "todoID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found in trash"})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "purge todo failed", "todoID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "purge todo success", "todoID", id)
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// --- DELETE /trash (ゴミ箱を空にする) ---
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	deleted, err := h.trashService.Empty(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(c.Request.Context(), "empty trash failed", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "empty trash success", "deleted", deleted)
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
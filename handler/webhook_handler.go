@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService}
+}
+
+// 作成・更新時の body
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required,min=1"` // 例: ["todo.created", "todo.updated"]
+	Secret string   `json:"secret"`                          // 省略すると作成時は自動で生成し、更新時は変えない
+	Active *bool    `json:"active"`                          // 更新時のみ。true で止まった webhook を再開する
+}
+
+// --- GET /webhooks (一覧) ---
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	webhooks, err := h.webhookService.FindAll(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get webhooks", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get webhooks success", "count", len(webhooks))
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// --- GET /webhooks/:id (詳細) ---
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "webhookID", id)
+
+	webhook, err := h.webhookService.FindByID(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		logger.Warn(c.Request.Context(), "webhook not found", "webhookID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get webhook success", "webhookID", id)
+	c.JSON(http.StatusOK, webhook)
+}
+
+// --- POST /webhooks (新規作成) ---
+// secret は作成時の応答にだけ含まれる
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	logger.Info(c.Request.Context(), "request received")
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "create webhook validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url and events are required"})
+		return
+	}
+
+	webhook, err := h.webhookService.Create(c.Request.Context(), userID, service.WebhookInput{URL: req.URL, Events: req.Events, Secret: req.Secret})
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "create webhook validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to create webhook", "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "create webhook success", "webhookID", webhook.ID)
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook, "secret": webhook.Secret})
+}
+
+// --- PUT /webhooks/:id (更新・再開) ---
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "webhookID", id)
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn(c.Request.Context(), "update webhook validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url and events are required"})
+		return
+	}
+
+	input := service.WebhookInput{URL: req.URL, Events: req.Events, Secret: req.Secret, Active: req.Active}
+	webhook, err := h.webhookService.Update(c.Request.Context(), userID, uint(id), input)
+	if errors.Is(err, service.ErrValidation) {
+		logger.Warn(c.Request.Context(), "update webhook validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		logger.Warn(c.Request.Context(), "webhook not found", "webhookID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "update webhook failed", "webhookID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "update webhook success", "webhookID", id)
+	c.JSON(http.StatusOK, webhook)
+}
+
+// --- DELETE /webhooks/:id ---
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "webhookID", id)
+
+	err := h.webhookService.Delete(c.Request.Context(), userID, uint(id))
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		logger.Warn(c.Request.Context(), "webhook not found", "webhookID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "delete webhook failed", "webhookID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "delete webhook success", "webhookID", id)
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// --- GET /webhooks/:id/deliveries (送信の記録) ---
+// 例: /webhooks/1/deliveries?limit=20（新しい順）
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	logger.Info(c.Request.Context(), "request received", "webhookID", id)
+
+	var req struct {
+		Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Warn(c.Request.Context(), "get deliveries validation failed", "reason", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: limit must be 1-100"})
+		return
+	}
+
+	deliveries, err := h.webhookService.Deliveries(c.Request.Context(), userID, uint(id), req.Limit)
+	if errors.Is(err, service.ErrWebhookNotFound) {
+		logger.Warn(c.Request.Context(), "webhook not found", "webhookID", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "failed to get deliveries", "webhookID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "get deliveries success", "webhookID", id, "count", len(deliveries))
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// --- POST /webhooks/:id/deliveries/:delivery_id/redeliver (再送) ---
+func (h *WebhookHandler) RedeliverDelivery(c *gin.Context) {
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		logger.Warn(c.Request.Context(),
+			"userID not found in context",
+			"error", "missing userID",
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user id not found"})
+		return
+	}
+
+	userID := userIDAny.(uint)
+	id, _ := strconv.Atoi(c.Param("id"))
+	deliveryID, _ := strconv.Atoi(c.Param("delivery_id"))
+	logger.Info(c.Request.Context(), "request received", "webhookID", id, "deliveryID", deliveryID)
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), userID, uint(id), uint(deliveryID))
+	if errors.Is(err, service.ErrWebhookNotFound) || errors.Is(err, service.ErrWebhookDeliveryNotFound) {
+		logger.Warn(c.Request.Context(), "redeliver target not found", "webhookID", id, "deliveryID", deliveryID)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrWebhookDisabled) {
+		logger.Warn(c.Request.Context(), "redeliver to disabled webhook", "webhookID", id)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error(c.Request.Context(), "redeliver failed", "webhookID", id, "reason", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(c.Request.Context(), "redeliver accepted", "webhookID", id, "deliveryID", delivery.ID)
+	c.JSON(http.StatusAccepted, delivery)
+}
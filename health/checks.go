@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/jobs"
+	"gorm.io/gorm"
+)
+
+// DB は DB に接続でき、SQLite のファイルを読めるか確認する（Ping だけではファイルを読まない）
+func DB(conn *gorm.DB) Check {
+	return func(ctx context.Context) (any, error) {
+		sqlDB, err := conn.DB()
+		if err != nil {
+			return nil, err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return nil, err
+		}
+		var n int
+		if err := conn.WithContext(ctx).Raw("SELECT count(*) FROM sqlite_master").Scan(&n).Error; err != nil {
+			return nil, err
+		}
+		stats := sqlDB.Stats()
+		return map[string]int{"open_connections": stats.OpenConnections, "in_use": stats.InUse}, nil
+	}
+}
+
+// Migrations は未適用のマイグレーションがないか確認する（このバイナリが使うテーブルや列が DB にまだない状態で動いていないか）
+func Migrations(conn *gorm.DB) Check {
+	return func(ctx context.Context) (any, error) {
+		migrator, err := db.NewMigrator(conn.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		statuses, err := migrator.Status()
+		if err != nil {
+			return nil, err
+		}
+
+		pending, version := 0, 0
+		for _, st := range statuses {
+			if st.Applied {
+				version = st.Version
+			} else {
+				pending++
+			}
+		}
+		detail := map[string]int{"version": version, "pending": pending}
+		if pending > 0 {
+			return detail, fmt.Errorf("%d migration(s) not applied", pending)
+		}
+		return detail, nil
+	}
+}
+
+// JobLag は実行を待っているジョブの遅れが max を超えていないか確認する（ワーカーが止まっていないか）
+func JobLag(queue *jobs.Queue, max time.Duration) Check {
+	return func(ctx context.Context) (any, error) {
+		lag, err := queue.Lag(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		detail := map[string]string{"lag": lag.String(), "max": max.String()}
+		if lag > max {
+			return detail, fmt.Errorf("oldest due job has been waiting for %s", lag.Round(time.Second))
+		}
+		return detail, nil
+	}
+}
+
+// DiskSpace は SQLite のファイルがあるディスクの空きが minFree バイト以上あるか確認する
+func DiskSpace(path string, minFree uint64) Check {
+	dir := filepath.Dir(path)
+	return func(ctx context.Context) (any, error) {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return nil, err
+		}
+		detail := map[string]uint64{"free_bytes": free, "min_free_bytes": minFree}
+		if free < minFree {
+			return detail, fmt.Errorf("only %d bytes free", free)
+		}
+		return detail, nil
+	}
+}
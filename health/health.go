@@ -0,0 +1,157 @@
+// Package health は /livez・/readyz 用の依存先の確認をまとめる。
+//
+// Registry に名前を付けて Check を登録すると、Report で全ての Check を並行に実行して結果をまとめる。
+// 結果は少しの間キャッシュするので、プローブが頻繁に来ても DB などを叩きすぎない。
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 結果の状態
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check は 1 つの依存先を確認する。detail は応答の checks.<name>.detail にそのまま入る（nil なら省略）
+type Check func(ctx context.Context) (detail any, err error)
+
+// Result は 1 つの Check の結果
+type Result struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+	Detail   any    `json:"detail,omitempty"`
+}
+
+// Report は全ての Check の結果。1 つでも失敗したら Status は fail
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks,omitempty"`
+}
+
+// OK は依存先が全て使える（シャットダウン中でもない）
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Registry struct {
+	timeout time.Duration // 1 つの Check を待つ上限
+	ttl     time.Duration // 結果をキャッシュする期間
+
+	mu           sync.Mutex // Check の実行中も持つので、同時に来たプローブは同じ結果を使う
+	checks       []namedCheck
+	cached       *Report
+	shuttingDown bool
+}
+
+func NewRegistry(timeout time.Duration, ttl time.Duration) *Registry {
+	return &Registry{timeout: timeout, ttl: ttl}
+}
+
+// Register は name の Check を追加する（同じ名前なら置き換える）
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checks {
+		if r.checks[i].name == name {
+			r.checks[i].check = check
+			r.cached = nil
+			return
+		}
+	}
+	r.checks = append(r.checks, namedCheck{name, check})
+	r.cached = nil
+}
+
+// ShutDown はシャットダウンを始めたことを記録する。以降の Report は Check を実行せずに shutting_down を返す
+func (r *Registry) ShutDown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.shuttingDown = true
+}
+
+// Report は全ての Check の結果を返す。前回の結果が ttl 以内ならそれを返す。
+// 結果は他のプローブとも共有してキャッシュするので、ctx の取り消し（切断したプローブ）では Check を止めない
+func (r *Registry) Report(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.shuttingDown {
+		return Report{Status: StatusShuttingDown, CheckedAt: now}
+	}
+	if r.cached != nil && now.Sub(r.cached.CheckedAt) < r.ttl {
+		return *r.cached
+	}
+
+	report := Report{Status: StatusOK, CheckedAt: now, Checks: make(map[string]Result, len(r.checks))}
+	results := make([]Result, len(r.checks))
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c.check)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range r.checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	r.cached = &report
+	return report
+}
+
+// run は 1 つの Check を timeout まで待つ。ctx を見ない Check でも timeout で失敗にする
+func (r *Registry) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	defer cancel()
+
+	type outcome struct {
+		detail any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- outcome{err: fmt.Errorf("panic: %v", p)}
+			}
+		}()
+		detail, err := check(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-ctx.Done():
+		out.err = fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+
+	result := Result{Status: StatusOK, Duration: time.Since(start).String(), Detail: out.detail}
+	if out.err != nil {
+		result.Status = StatusFail
+		result.Error = out.err.Error()
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/health"
+)
+
+// --- Report（結果のまとめ） ---
+func TestRegistry_Report(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		checks       map[string]health.Check
+		expectStatus string
+		expectFailed []string
+	}{
+		{
+			name: "all ok",
+			checks: map[string]health.Check{
+				"a": func(ctx context.Context) (any, error) { return map[string]int{"n": 1}, nil },
+				"b": func(ctx context.Context) (any, error) { return nil, nil },
+			},
+			expectStatus: health.StatusOK,
+		},
+		{
+			name: "one failed",
+			checks: map[string]health.Check{
+				"a": func(ctx context.Context) (any, error) { return nil, nil },
+				"b": func(ctx context.Context) (any, error) { return nil, errors.New("down") },
+			},
+			expectStatus: health.StatusFail,
+			expectFailed: []string{"b"},
+		},
+		{
+			name: "timeout and panic",
+			checks: map[string]health.Check{
+				// ctx を見ない Check も timeout で失敗にする
+				"slow":  func(ctx context.Context) (any, error) { time.Sleep(time.Second); return nil, nil },
+				"panic": func(ctx context.Context) (any, error) { panic("unexpected") },
+			},
+			expectStatus: health.StatusFail,
+			expectFailed: []string{"slow", "panic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			registry := health.NewRegistry(50*time.Millisecond, 0)
+			for name, check := range tt.checks {
+				registry.Register(name, check)
+			}
+
+			start := time.Now()
+			report := registry.Report(context.Background())
+
+			if report.Status != tt.expectStatus {
+				t.Errorf("expected %s, got %s", tt.expectStatus, report.Status)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("expected %d checks, got %+v", len(tt.checks), report.Checks)
+			}
+			for _, name := range tt.expectFailed {
+				if result := report.Checks[name]; result.Status != health.StatusFail || result.Error == "" {
+					t.Errorf("%s: expected failure, got %+v", name, result)
+				}
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("checks should run concurrently with timeout, took %s", elapsed)
+			}
+		})
+	}
+}
+
+// --- キャッシュとシャットダウン ---
+func TestRegistry_Cache(t *testing.T) {
+
+	var calls atomic.Int32
+	registry := health.NewRegistry(time.Second, time.Hour)
+	registry.Register("db", func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		return nil, nil
+	})
+
+	first := registry.Report(context.Background())
+	second := registry.Report(context.Background())
+	if calls.Load() != 1 || !second.CheckedAt.Equal(first.CheckedAt) {
+		t.Errorf("expected cached report, got %d calls", calls.Load())
+	}
+
+	// 登録し直したらキャッシュを捨てる
+	registry.Register("db", func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		return nil, errors.New("down")
+	})
+	if report := registry.Report(context.Background()); report.OK() || calls.Load() != 2 {
+		t.Errorf("expected fresh failed report, got %+v", report)
+	}
+
+	registry.ShutDown()
+	if report := registry.Report(context.Background()); report.Status != health.StatusShuttingDown || calls.Load() != 2 {
+		t.Errorf("expected shutting_down without running checks, got %+v", report)
+	}
+}
+
+// --- 切断したプローブ ---
+// ctx が取り消されても Check は止めず、取り消しによる失敗をキャッシュしない
+func TestRegistry_CanceledProbe(t *testing.T) {
+
+	registry := health.NewRegistry(time.Second, time.Hour)
+	registry.Register("db", func(ctx context.Context) (any, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return nil, nil
+		}
+	})
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := registry.Report(canceled); !report.OK() {
+		t.Errorf("expected canceled probe not to fail checks, got %+v", report)
+	}
+	if report := registry.Report(context.Background()); !report.OK() {
+		t.Errorf("expected cached ok report, got %+v", report)
+	}
+}
+
+// --- DB / Migrations / DiskSpace ---
+func TestChecks(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "health.db")
+	db.Init(path)
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	if _, err := health.DB(db.DB)(ctx); err != nil {
+		t.Errorf("db: unexpected error: %v", err)
+	}
+	if detail, err := health.Migrations(db.DB)(ctx); err != nil || detail.(map[string]int)["pending"] != 0 {
+		t.Errorf("migrations: unexpected result: %v, %v", detail, err)
+	}
+
+	// 最後のマイグレーションを戻すと未適用が 1 つになる
+	migrator, _ := db.NewMigrator(db.DB)
+	if _, err := migrator.Down(); err != nil {
+		t.Fatalf("failed to roll back: %v", err)
+	}
+	if detail, err := health.Migrations(db.DB)(ctx); err == nil || detail.(map[string]int)["pending"] != 1 {
+		t.Errorf("migrations: expected 1 pending, got %v, %v", detail, err)
+	}
+
+	if _, err := health.DiskSpace(path, 0)(ctx); err != nil {
+		t.Errorf("disk: unexpected error: %v", err)
+	}
+	if _, err := health.DiskSpace(path, math.MaxUint64)(ctx); err == nil {
+		t.Errorf("disk: expected error for huge minimum")
+	}
+
+	db.Close()
+	if _, err := health.DB(db.DB)(ctx); err == nil {
+		t.Errorf("db: expected error after close")
+	}
+}
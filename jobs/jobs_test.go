@@ -0,0 +1,304 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/jobs"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+// キューは SQL（リース・一意キー）が肝なので、一時ディレクトリの SQLite で確認する
+func newQueue(t *testing.T, options jobs.Options) *jobs.Queue {
+	t.Helper()
+
+	db.Init(filepath.Join(t.TempDir(), "jobs.db"))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB.DB()
+		sqlDB.Close()
+	})
+	return jobs.NewQueue(repository.NewJobRepository(), options)
+}
+
+func findJob(t *testing.T, id uint) *model.Job {
+	t.Helper()
+
+	var job model.Job
+	if err := db.DB.First(&job, id).Error; err != nil {
+		t.Fatalf("failed to find job: %v", err)
+	}
+	return &job
+}
+
+// --- Enqueue / Lease ---
+func TestQueue_Lease(t *testing.T) {
+
+	q := newQueue(t, jobs.Options{VisibilityTimeout: time.Minute, RetryBase: time.Second})
+
+	first, _ := q.Enqueue("a", map[string]int{"n": 1})
+	later, _ := q.Enqueue("b", nil, jobs.RunAt(time.Now().Add(time.Hour)))
+
+	// 一意キーが同じ未完了のジョブは追加しない
+	if _, err := q.Enqueue("c", nil, jobs.UniqueKey("k")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := q.Enqueue("c", nil, jobs.UniqueKey("k")); !errors.Is(err, jobs.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate, got %v", err)
+	}
+	now := time.Now()
+
+	// 実行を待っている一番古いジョブの遅れ（RunAt 前のジョブは数えない）
+	if lag, err := q.Lag(now.Add(time.Minute)); err != nil || lag < time.Minute || lag > time.Minute+time.Second {
+		t.Errorf("expected lag about 1m, got %s, %v", lag, err)
+	}
+
+	job, err := q.Lease(now)
+	if err != nil || job == nil || job.ID != first.ID {
+		t.Fatalf("expected first job, got %+v, %v", job, err)
+	}
+	if job.Status != model.JobRunning || job.Attempts != 1 || job.LeaseToken == "" || string(job.Payload) != `{"n":1}` {
+		t.Errorf("unexpected leased job: %+v", job)
+	}
+
+	// 実行中のジョブと RunAt 前のジョブは取らない
+	next, _ := q.Lease(now)
+	if next == nil || next.Type != "c" {
+		t.Fatalf("expected job c, got %+v", next)
+	}
+	if none, _ := q.Lease(now); none != nil {
+		t.Errorf("expected no job, got %+v", none)
+	}
+
+	// リースの期限が切れたジョブは別のワーカーがやり直す。前のワーカーの結果は捨てる
+	expired, _ := q.Lease(now.Add(2 * time.Minute))
+	if expired == nil || expired.ID != first.ID || expired.Attempts != 2 {
+		t.Fatalf("expected expired job to be leased again, got %+v", expired)
+	}
+	if err := q.Complete(job, now); !errors.Is(err, repository.ErrLeaseLost) {
+		t.Errorf("expected ErrLeaseLost, got %v", err)
+	}
+	if err := q.Complete(expired, now); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if job, _ := q.Lease(now.Add(2 * time.Hour)); job == nil || (job.ID != later.ID && job.Type != "c") {
+		t.Errorf("expected delayed job after RunAt, got %+v", job)
+	}
+}
+
+// --- Fail（再試行と dead-letter） ---
+func TestQueue_Fail(t *testing.T) {
+
+	q := newQueue(t, jobs.Options{VisibilityTimeout: time.Minute, RetryBase: 10 * time.Second})
+
+	created, _ := q.Enqueue("a", nil, jobs.MaxAttempts(2))
+	now := time.Now()
+
+	job, _ := q.Lease(now)
+	if err := q.Fail(job, now, errors.New("boom"), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved := findJob(t, created.ID)
+	if saved.Status != model.JobPending || saved.LastError != "boom" || saved.RunAt.Sub(now) != 10*time.Second {
+		t.Errorf("expected retry after 10s, got %+v", saved)
+	}
+	if none, _ := q.Lease(now.Add(5 * time.Second)); none != nil {
+		t.Errorf("expected no job before backoff, got %+v", none)
+	}
+
+	job, _ = q.Lease(now.Add(10 * time.Second))
+	if job == nil || job.Attempts != 2 {
+		t.Fatalf("expected retried job, got %+v", job)
+	}
+	if err := q.Fail(job, now, errors.New("boom again"), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved := findJob(t, created.ID); saved.Status != model.JobDead || saved.FinishedAt == nil {
+		t.Errorf("expected dead job, got %+v", saved)
+	}
+
+	// permanent なら 1 回目で dead-letter
+	other, _ := q.Enqueue("b", nil)
+	job, _ = q.Lease(time.Now())
+	q.Fail(job, now, errors.New("bad input"), true)
+	if saved := findJob(t, other.ID); saved.Status != model.JobDead {
+		t.Errorf("expected dead job, got %+v", saved)
+	}
+
+	counts, _ := q.Counts()
+	if counts[model.JobDead] != 2 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	if dead, _ := q.List(model.JobDead, "", 0); len(dead) != 2 || dead[0].ID != other.ID {
+		t.Errorf("unexpected dead jobs: %+v", dead)
+	}
+
+	if purged, err := q.PurgeFinished(now.Add(time.Hour)); err != nil || purged != 2 {
+		t.Errorf("expected 2 purged, got %d, %v", purged, err)
+	}
+}
+
+// --- Lease（リースの期限切れで上限を使い切ったジョブ） ---
+func TestQueue_LeaseExpiredDead(t *testing.T) {
+
+	q := newQueue(t, jobs.Options{VisibilityTimeout: time.Minute, RetryBase: time.Second})
+
+	created, _ := q.Enqueue("a", nil, jobs.MaxAttempts(2))
+	now := time.Now()
+
+	// 2 回ともワーカーが落ちて結果を保存しなかった
+	if job, _ := q.Lease(now); job == nil || job.Attempts != 1 {
+		t.Fatalf("expected first attempt, got %+v", job)
+	}
+	job, _ := q.Lease(now.Add(2 * time.Minute))
+	if job == nil || job.Attempts != 2 {
+		t.Fatalf("expected second attempt, got %+v", job)
+	}
+
+	// 3 回目は取らずに dead-letter にする
+	later := now.Add(4 * time.Minute)
+	if none, err := q.Lease(later); err != nil || none != nil {
+		t.Fatalf("expected no job, got %+v, %v", none, err)
+	}
+	saved := findJob(t, created.ID)
+	if saved.Status != model.JobDead || saved.Attempts != 2 || saved.LastError == "" || saved.FinishedAt == nil || saved.LeasedUntil != nil {
+		t.Errorf("expected dead job, got %+v", saved)
+	}
+
+	// 落ちたワーカーの結果は捨てる
+	if err := q.Complete(job, later); !errors.Is(err, repository.ErrLeaseLost) {
+		t.Errorf("expected ErrLeaseLost, got %v", err)
+	}
+}
+
+// --- Pool ---
+func TestPool(t *testing.T) {
+
+	q := newQueue(t, jobs.Options{VisibilityTimeout: time.Minute, RetryBase: time.Hour})
+
+	var mu sync.Mutex
+	handled := map[string]int{}
+	pool := jobs.NewPool(q, 3, 10*time.Millisecond)
+	pool.Handle("ok", func(ctx context.Context, job *model.Job) error {
+		mu.Lock()
+		handled[string(job.Payload)]++
+		mu.Unlock()
+		return nil
+	})
+	pool.Handle("fail", func(ctx context.Context, job *model.Job) error {
+		return errors.New("temporary")
+	})
+	pool.Handle("invalid", func(ctx context.Context, job *model.Job) error {
+		return jobs.Permanent(errors.New("invalid payload"))
+	})
+	pool.Handle("panic", func(ctx context.Context, job *model.Job) error {
+		panic("unexpected")
+	})
+
+	ids := map[string]uint{}
+	for _, typ := range []string{"fail", "invalid", "panic", "unknown"} {
+		job, _ := q.Enqueue(typ, nil)
+		ids[typ] = job.ID
+	}
+	for i := 0; i < 10; i++ {
+		q.Enqueue("ok", i)
+	}
+
+	pool.Start()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		counts, _ := q.Counts()
+		if counts[model.JobSucceeded] == 10 && counts[model.JobDead] == 2 && counts[model.JobRunning] == 0 &&
+			counts[model.JobPending] == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("jobs were not processed: %v", counts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(handled) != 10 {
+		t.Errorf("expected each ok job once, got %v", handled)
+	}
+	for payload, n := range handled {
+		if n != 1 {
+			t.Errorf("job %s handled %d times", payload, n)
+		}
+	}
+
+	expect := map[string]string{
+		"fail":    model.JobPending, // 再試行待ち
+		"invalid": model.JobDead,
+		"panic":   model.JobPending,
+		"unknown": model.JobDead,
+	}
+	for typ, status := range expect {
+		if job := findJob(t, ids[typ]); job.Status != status || job.LastError == "" {
+			t.Errorf("%s: expected %s with error, got %+v", typ, status, job)
+		}
+	}
+}
+
+// --- Shutdown（実行中のジョブを待つ） ---
+func TestPool_Shutdown(t *testing.T) {
+
+	q := newQueue(t, jobs.Options{VisibilityTimeout: time.Minute, RetryBase: time.Hour})
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	pool := jobs.NewPool(q, 1, 10*time.Millisecond)
+	pool.Handle("slow", func(ctx context.Context, job *model.Job) error {
+		close(started)
+		select {
+		case <-time.After(200 * time.Millisecond):
+			finished.Store(true)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	slow, _ := q.Enqueue("slow", nil)
+	pool.Start()
+	<-started
+
+	// 実行中のジョブが終わるまで待つ
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !finished.Load() || findJob(t, slow.ID).Status != model.JobSucceeded {
+		t.Errorf("expected running job to finish before shutdown")
+	}
+
+	// 待ちきれなければ取り消して再試行に回す
+	stuck, _ := q.Enqueue("stuck", nil)
+	pool = jobs.NewPool(q, 1, 10*time.Millisecond)
+	pool.Handle("stuck", func(ctx context.Context, job *model.Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	pool.Start()
+	for findJob(t, stuck.ID).Status != model.JobRunning {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if job := findJob(t, stuck.ID); job.Status != model.JobPending {
+		t.Errorf("expected canceled job to be retried, got %+v", job)
+	}
+}
@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+// Handler はジョブを実行する。エラーを返すと再試行する。
+// ctx は Shutdown で待ちきれなかったときと、リースを失ったときに取り消される。
+// ctx のロガー（logger.FromContext）には job_id と job_type が付いている
+type Handler func(ctx context.Context, job *model.Job) error
+
+// Permanent で包んだエラーは再試行せずに dead-letter にする（入力が正しくないなど、やり直しても成功しない）
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Pool は workers 個のワーカーでキューのジョブを実行する
+type Pool struct {
+	queue    *Queue
+	workers  int
+	poll     time.Duration // ジョブがないときに次に確認するまでの間隔
+	handlers map[string]Handler
+
+	stop   chan struct{} // 閉じたら新しいジョブを取らない
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPool(queue *Queue, workers int, poll time.Duration) *Pool {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pool{
+		queue:    queue,
+		workers:  workers,
+		poll:     poll,
+		handlers: make(map[string]Handler),
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle は typ のジョブのハンドラーを登録する（Start の前に呼ぶ）
+func (p *Pool) Handle(typ string, handler Handler) {
+	p.handlers[typ] = handler
+}
+
+// Start はワーカーを起動する
+func (p *Pool) Start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+// Shutdown は新しいジョブを取るのをやめて、実行中のジョブが終わるのを待つ。
+// ctx が先に終わったら実行中のジョブを取り消して（再試行に回る）、ctx のエラーを返す
+func (p *Pool) Shutdown(ctx context.Context) error {
+	close(p.stop)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		job, err := p.queue.Lease(time.Now())
+		if err != nil {
+			logger.Error(p.ctx, "failed to lease job", "reason", err.Error())
+		}
+		if job == nil {
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(p.poll):
+			}
+			continue
+		}
+		p.run(job)
+	}
+}
+
+// run は 1 件を実行して結果を保存する
+func (p *Pool) run(job *model.Job) {
+	ctx := logger.With(p.ctx, "job_id", job.ID, "job_type", job.Type)
+	logger.Info(ctx, "job started", "attempt", job.Attempts)
+	start := time.Now()
+
+	var err error
+	handler, ok := p.handlers[job.Type]
+	switch {
+	case !ok:
+		err = Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	default:
+		err = p.call(ctx, handler, job)
+	}
+
+	now := time.Now()
+	var saveErr error
+	if err == nil {
+		logger.Info(ctx, "job succeeded", "duration", now.Sub(start).String())
+		saveErr = p.queue.Complete(job, now)
+	} else {
+		var permanent *permanentError
+		logger.Warn(ctx, "job failed", "attempt", job.Attempts, "reason", err.Error())
+		saveErr = p.queue.Fail(job, now, err, errors.As(err, &permanent))
+	}
+
+	if errors.Is(saveErr, repository.ErrLeaseLost) {
+		logger.Warn(ctx, "job lease was lost, result discarded")
+	} else if saveErr != nil {
+		logger.Error(ctx, "failed to save job result", "reason", saveErr.Error())
+	}
+}
+
+// call はリースを延ばしながらハンドラーを実行する。panic はエラーにする
+func (p *Pool) call(ctx context.Context, handler Handler, job *model.Job) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// VisibilityTimeout の半分ごとにリースを延ばす
+	go func() {
+		ticker := time.NewTicker(p.queue.options.VisibilityTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.queue.Extend(job, time.Now()); err != nil {
+					logger.Warn(ctx, "failed to extend job lease", "reason", err.Error())
+					if errors.Is(err, repository.ErrLeaseLost) {
+						cancel()
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// Schedule は interval ごとに typ のジョブを追加する（ctx が終わるまで）。
+// 前回のジョブがまだ終わっていなければ追加しない
+func (q *Queue) Schedule(ctx context.Context, typ string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := q.Enqueue(typ, nil, UniqueKey(typ)); err != nil && !errors.Is(err, ErrDuplicate) {
+			logger.Error(ctx, "failed to schedule job", "type", typ, "reason", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package jobs は DB に保存するバックグラウンドジョブのキューと、それを実行するワーカー。
+//
+// Enqueue したジョブは Pool のワーカーがリース（一定時間だけ自分のものにする）して実行する。
+// ワーカーが落ちてリースの期限が切れたジョブは別のワーカーがやり直し、
+// 失敗したジョブは間隔を空けて再試行し、上限まで失敗したら dead-letter として残す。
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultListLimit   = 50
+	MaxListLimit       = 200
+	maxBackoff         = time.Hour
+)
+
+// ErrDuplicate は同じ UniqueKey の未完了のジョブが既にある
+var ErrDuplicate = repository.ErrDuplicateJob
+
+// Options はキューの設定
+type Options struct {
+	VisibilityTimeout time.Duration // リースの期間。過ぎたら別のワーカーがやり直す
+	RetryBase         time.Duration // 最初の再試行までの間隔（以降は倍ずつ、最大 1 時間）
+	Retention         time.Duration // 終わったジョブを残す期間
+}
+
+// EnqueueOption は Enqueue の追加の指定
+type EnqueueOption func(job *model.Job)
+
+// RunAt は at まで実行しない
+func RunAt(at time.Time) EnqueueOption {
+	return func(job *model.Job) { job.RunAt = at }
+}
+
+// MaxAttempts は実行する回数の上限（最初の実行を含む）
+func MaxAttempts(n int) EnqueueOption {
+	return func(job *model.Job) { job.MaxAttempts = n }
+}
+
+// UniqueKey は同じキーの未完了のジョブがあれば追加しない（ErrDuplicate）
+func UniqueKey(key string) EnqueueOption {
+	return func(job *model.Job) { job.UniqueKey = &key }
+}
+
+type Queue struct {
+	jobRepo repository.JobRepository
+	options Options
+}
+
+func NewQueue(jobRepo repository.JobRepository, options Options) *Queue {
+	return &Queue{jobRepo, options}
+}
+
+// Enqueue は typ のジョブを追加する。payload は JSON にして保存し、ハンドラーは Job.Payload から読む
+func (q *Queue) Enqueue(typ string, payload any, opts ...EnqueueOption) (*model.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+
+	job := &model.Job{
+		Type:        typ,
+		Payload:     data,
+		Status:      model.JobPending,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if job.MaxAttempts <= 0 {
+		return nil, errors.New("max attempts must be positive")
+	}
+
+	if err := q.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Lease は実行できるジョブを 1 つ取る（なければ nil）
+func (q *Queue) Lease(now time.Time) (*model.Job, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return q.jobRepo.Lease(now, now.Add(q.options.VisibilityTimeout), hex.EncodeToString(b))
+}
+
+// Extend は実行中のジョブのリースを VisibilityTimeout だけ延ばす
+func (q *Queue) Extend(job *model.Job, now time.Time) error {
+	return q.jobRepo.Extend(job.ID, job.LeaseToken, now.Add(q.options.VisibilityTimeout))
+}
+
+func (q *Queue) Complete(job *model.Job, now time.Time) error {
+	return q.jobRepo.Complete(job.ID, job.LeaseToken, now)
+}
+
+// Fail は失敗したジョブを再試行する。上限まで失敗したか permanent なら dead-letter にする
+func (q *Queue) Fail(job *model.Job, now time.Time, cause error, permanent bool) error {
+	if permanent || job.Attempts >= job.MaxAttempts {
+		return q.jobRepo.Dead(job.ID, job.LeaseToken, now, cause.Error())
+	}
+	return q.jobRepo.Retry(job.ID, job.LeaseToken, now.Add(q.backoff(job.Attempts)), cause.Error())
+}
+
+// backoff は attempts 回失敗した後、次に実行するまでの間隔
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.options.RetryBase
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// List はジョブを新しい順に返す（管理用）。status / typ が空なら絞らない
+func (q *Queue) List(status string, typ string, limit int) ([]model.Job, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	jobs, err := q.jobRepo.List(status, typ, limit)
+	if err != nil {
+		return nil, err
+	}
+	if jobs == nil {
+		jobs = []model.Job{}
+	}
+	return jobs, nil
+}
+
+// Counts は状態ごとのジョブの数
+func (q *Queue) Counts() (map[string]int64, error) {
+	return q.jobRepo.CountByStatus()
+}
+
+// Lag は RunAt を過ぎても実行されていない一番古いジョブの遅れ（なければ 0）
+func (q *Queue) Lag(now time.Time) (time.Duration, error) {
+	job, err := q.jobRepo.OldestDue(now)
+	if err != nil || job == nil {
+		return 0, err
+	}
+	return now.Sub(job.RunAt), nil
+}
+
+// PurgeFinished は Retention を過ぎた終わったジョブを削除する
+func (q *Queue) PurgeFinished(now time.Time) (int64, error) {
+	return q.jobRepo.DeleteFinishedBefore(now.Add(-q.options.Retention))
+}
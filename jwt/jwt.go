@@ -1,28 +1,61 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var secretKey = []byte("super_secret_key_123") // ← 好きなキーにしてOK（外部に出さない）
+var secretKey []byte // config から Init で設定する（外部に出さない）
+
+var (
+	accessTTL  = 15 * time.Minute
+	refreshTTL = 30 * 24 * time.Hour
+)
+
+// Init は署名に使うシークレットとトークンの有効期限を設定する
+func Init(secret string, accessTokenTTL, refreshTokenTTL time.Duration) {
+	secretKey = []byte(secret)
+	accessTTL = accessTokenTTL
+	refreshTTL = refreshTokenTTL
+}
+
+// AccessToken は発行したアクセストークンと失効管理用の jti
+type AccessToken struct {
+	Token     string
+	JTI       string
+	ExpiresAt time.Time
+}
 
 // -----------------------------
-// JWTを作る関数（login時に使う）
+// JWTを作る関数（login / refresh 時に使う）
 // -----------------------------
-func CreateToken(userID uint) (string, error) {
+func CreateToken(userID uint) (*AccessToken, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(accessTTL)
+
 	// トークンに入れる情報（Claims）
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(), // 有効期限 24h
+		"jti":     jti,              // 失効リストのキー
+		"exp":     expiresAt.Unix(), // 有効期限（短め）
 	}
 
 	// 署名アルゴリズム HS256 を使ってトークンを作る
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// secretKey で署名して実体の文字列にする
-	return token.SignedString(secretKey)
+	signed, err := token.SignedString(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &AccessToken{Token: signed, JTI: jti, ExpiresAt: expiresAt}, nil
 }
 
 // -----------------------------
@@ -31,5 +64,37 @@ func CreateToken(userID uint) (string, error) {
 func VerifyToken(tokenString string) (*jwt.Token, error) {
 	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return secretKey, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+}
+
+// -----------------------------
+// リフレッシュトークン（JWT ではなくランダム文字列）
+// -----------------------------
+
+// NewRefreshToken はリフレッシュトークンとその有効期限を返す
+func NewRefreshToken() (string, time.Time, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, time.Now().Add(refreshTTL), nil
+}
+
+// NewFamilyID はリフレッシュトークンの系列（ログイン 1 回分）の ID を返す
+func NewFamilyID() (string, error) {
+	return randomString(16)
+}
+
+// HashToken は DB に保存するためのハッシュ（平文は保存しない）
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
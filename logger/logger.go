@@ -1,15 +1,141 @@
+// Package logger は slog のロガーの初期化と、リクエストごとのロガー（context）。
+//
+// 出力は次の順に通る:
+//
+//	traceHandler（trace_id / span_id）→ sampleHandler（同じメッセージを間引く）→ JSON / テキスト（redact で伏せ字）→ 標準出力 / ファイル
 package logger
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 出力の形式
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// 出力先（それ以外はファイルのパス）
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
 )
 
-var Logger *slog.Logger
+// Init するまで（テストなど）は標準のロガーに出力する
+var Logger = slog.Default()
+
+// level は実行中に SetLevel で変えられる
+var level = new(slog.LevelVar)
+
+// output は Close で閉じるファイル（標準出力なら nil）
+var output io.Closer
+
+// Options はロガーの設定
+type Options struct {
+	Level      slog.Level
+	Format     string // json / text
+	Output     string // stdout / stderr / ファイルのパス
+	MaxSizeMB  int    // ファイルがこれを超えたら .1, .2 ... にずらす
+	MaxBackups int    // 残す古いファイルの数
+	Sampling   SamplingOptions
+}
+
+func Init(options Options) error {
+	var w io.Writer
+	switch options.Output {
+	case OutputStdout, "":
+		w = os.Stdout
+	case OutputStderr:
+		w = os.Stderr
+	default:
+		file, err := openRotatingFile(options.Output, int64(options.MaxSizeMB)<<20, options.MaxBackups)
+		if err != nil {
+			return fmt.Errorf("open log file: %w", err)
+		}
+		w, output = file, file
+	}
+
+	level.Set(options.Level)
+	handlerOptions := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	switch options.Format {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	case FormatText:
+		handler = slog.NewTextHandler(w, handlerOptions)
+	default:
+		return fmt.Errorf("unknown log format: %q", options.Format)
+	}
+	if options.Sampling.Initial > 0 {
+		handler = newSampleHandler(handler, options.Sampling)
+	}
+
+	Logger = slog.New(traceHandler{handler})
+	return nil
+}
+
+// Level は今のログレベル
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel はログレベルを変える（管理用 API から）。Init で作った全てのロガーに効く
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Close はログのファイルを閉じる（シャットダウン時）
+func Close() error {
+	if output == nil {
+		return nil
+	}
+	err := output.Close()
+	output = nil
+	return err
+}
+
+// traceHandler は *Context で渡された ctx に span があれば、trace_id と span_id を付ける
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
+// ErrInvalidLevel は ParseLevel で解釈できないレベル
+var ErrInvalidLevel = errors.New("level must be debug, info, warn or error")
 
-func Init() {
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	})
-	Logger = slog.New(handler)
+// ParseLevel は "debug" / "info" / "warn" / "error"（大文字小文字は問わない）を slog.Level にする
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, ErrInvalidLevel
+	}
+	return l, nil
 }
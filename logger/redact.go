@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted は伏せた値の代わりに出す文字列
+const Redacted = "[REDACTED]"
+
+// secretKeys を含むキー（大文字小文字は問わない）の値は丸ごと伏せる
+var secretKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+var (
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+	bcryptPattern = regexp.MustCompile(`\$2[aby]\$\d{2}\$[./A-Za-z0-9]{53}`) // SQL のログに出るパスワードのハッシュ
+)
+
+// redact は slog.HandlerOptions.ReplaceAttr。メッセージを含む全ての属性で、
+// パスワード・トークンらしいキーの値を伏せ、文字列の中のメールアドレスを a***@example.com に、トークンを伏せ字にする
+func redact(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+
+	switch v := a.Value.Any().(type) {
+	case string:
+		a.Value = slog.StringValue(RedactString(v))
+	case error:
+		a.Value = slog.StringValue(RedactString(v.Error()))
+	}
+	return a
+}
+
+// RedactString は s の中のメールアドレスとトークンを伏せる（含んでいそうなときだけ正規表現を使う）
+func RedactString(s string) string {
+	if strings.Contains(strings.ToLower(s), "bearer") {
+		s = bearerPattern.ReplaceAllString(s, Redacted)
+	}
+	if strings.Contains(s, "eyJ") {
+		s = jwtPattern.ReplaceAllString(s, Redacted)
+	}
+	if strings.Contains(s, "$2") {
+		s = bcryptPattern.ReplaceAllString(s, Redacted)
+	}
+	if strings.Contains(s, "@") {
+		s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	}
+	return s
+}
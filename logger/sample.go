@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingOptions は同じメッセージが Tick の間に Initial 回を超えたら、以降は Thereafter 回に 1 回だけ出す。
+// warn 以上は間引かない。Initial が 0 なら間引かない（Thereafter が 0 なら Initial 回の後は出さない）
+type SamplingOptions struct {
+	Initial    int
+	Thereafter int
+	Tick       time.Duration
+}
+
+// sampleHandler はリクエストごとの info など、数の多いメッセージを間引く
+type sampleHandler struct {
+	slog.Handler
+	sampler *sampler // WithAttrs で作ったロガーとも数を共有する
+}
+
+func newSampleHandler(next slog.Handler, options SamplingOptions) *sampleHandler {
+	return &sampleHandler{next, &sampler{options: options, counts: make(map[sampleKey]int)}}
+}
+
+func (h *sampleHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && !h.sampler.allow(r.Level, r.Message, r.Time) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *sampleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampleHandler{h.Handler.WithAttrs(attrs), h.sampler}
+}
+
+func (h *sampleHandler) WithGroup(name string) slog.Handler {
+	return &sampleHandler{h.Handler.WithGroup(name), h.sampler}
+}
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+type sampler struct {
+	options SamplingOptions
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[sampleKey]int // Tick ごとに空にする（メッセージの種類が増えても溜まらない）
+}
+
+func (s *sampler) allow(level slog.Level, msg string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.windowStart) >= s.options.Tick {
+		clear(s.counts)
+		s.windowStart = now
+	}
+	key := sampleKey{level, msg}
+	s.counts[key]++
+	n := s.counts[key]
+
+	if n <= s.options.Initial {
+		return true
+	}
+	return s.options.Thereafter > 0 && (n-s.options.Initial)%s.options.Thereafter == 0
+}
@@ -1,58 +1,415 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // タイムゾーン DB を埋め込む（?tz= の解釈用）
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/a5415091-collab/go-gin-todo-app/config"
 	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/event"
 	"github.com/a5415091-collab/go-gin-todo-app/handler"
+	"github.com/a5415091-collab/go-gin-todo-app/health"
+	"github.com/a5415091-collab/go-gin-todo-app/jobs"
+	"github.com/a5415091-collab/go-gin-todo-app/jwt"
 	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/metrics"
 	"github.com/a5415091-collab/go-gin-todo-app/middleware"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
 	"github.com/a5415091-collab/go-gin-todo-app/repository"
 	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/a5415091-collab/go-gin-todo-app/tracing"
 )
 
 func main() {
-	r := gin.Default()
+	// 設定読み込み（ファイル < 環境変数 < フラグ）
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// LOG 初期化
-	logger.Init()
+	// マイグレーション用サブコマンド: go run main.go [flags] migrate [status|up|down|redo]
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrate(cfg, args[1:])
+		return
+	}
 
-	// DB 初期化
-	db.Init()
+	if !cfg.IsDev() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	// アクセスログは gin.Logger ではなく AccessLogMiddleware で slog に出す
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// SIGINT / SIGTERM で止める
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	// LOG 初期化（レベルは PUT /admin/log/level で変えられる）
+	if err := logger.Init(logger.Options{
+		Level:      cfg.LogLevel(),
+		Format:     cfg.Log.Format,
+		Output:     cfg.Log.Output,
+		MaxSizeMB:  cfg.Log.MaxSizeMB,
+		MaxBackups: cfg.Log.MaxBackups,
+		Sampling: logger.SamplingOptions{
+			Initial:    cfg.Log.Sampling.Initial,
+			Thereafter: cfg.Log.Sampling.Thereafter,
+			Tick:       cfg.Log.Sampling.Tick.Duration,
+		},
+	}); err != nil {
+		log.Fatal("failed to init logger:", err)
+	}
+
+	// トレース初期化（tracing.exporter が none なら span は作らない）
+	shutdownTracing, err := tracing.Init(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal("failed to init tracing:", err)
+	}
+
+	// DB 初期化（クエリの時間をメトリクスに、クエリの span をトレースに記録する）
+	db.Init(cfg.DB.Path)
+	if err := db.DB.Use(metrics.GORMPlugin{}); err != nil {
+		log.Fatal("failed to register metrics plugin:", err)
+	}
+	if err := db.DB.Use(tracing.GORMPlugin{}); err != nil {
+		log.Fatal("failed to register tracing plugin:", err)
+	}
+
+	// JWT 初期化
+	jwt.Init(cfg.JWT.Secret, cfg.JWT.AccessTTL.Duration, cfg.JWT.RefreshTTL.Duration)
 
 	// Repository 作成
 	userRepo := repository.NewUserRepository()
 	todoRepo := repository.NewTodoRepository()
+	tokenRepo := repository.NewTokenRepository()
+	projectRepo := repository.NewProjectRepository()
+	tagRepo := repository.NewTagRepository()
+	idempotencyRepo := repository.NewIdempotencyRepository()
+	webhookRepo := repository.NewWebhookRepository()
+	jobRepo := repository.NewJobRepository()
+
+	// バックグラウンドジョブのキュー
+	queue := jobs.NewQueue(jobRepo, jobs.Options{
+		VisibilityTimeout: cfg.Jobs.VisibilityTimeout.Duration,
+		RetryBase:         cfg.Jobs.RetryBase.Duration,
+		Retention:         cfg.Jobs.Retention.Duration,
+	})
+
+	// todo の変更をリアルタイムに配信するイベントバス
+	bus := event.NewBus(event.DefaultHistorySize)
 
 	// Service 作成
-	authService := service.NewAuthService(userRepo)
-	todoService := service.NewTodoService(todoRepo)
+	webhookService := service.NewWebhookService(webhookRepo, service.WebhookOptions{
+		Timeout:             cfg.Webhooks.Timeout.Duration,
+		RetryBase:           cfg.Webhooks.RetryBase.Duration,
+		Retention:           cfg.Webhooks.Retention.Duration,
+		AllowPrivateTargets: cfg.Webhooks.AllowPrivateTargets,
+	})
+	events := service.EventPublishers{bus, webhookService, metrics.Todos{}}
+
+	authService := service.NewAuthService(userRepo, tokenRepo)
+	todoService := service.NewTodoService(todoRepo, projectRepo, events)
+	projectService := service.NewProjectService(projectRepo)
+	tagService := service.NewTagService(tagRepo, todoRepo)
+	trashService := service.NewTrashService(todoRepo, cfg.Trash.Retention.Duration)
+	syncService := service.NewSyncService(todoRepo, projectRepo, events)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.Retention.Duration)
 
 	// Handler に service を渡す
 	authHandler := handler.NewAuthHandler(authService)
 	todoHandler := handler.NewTodoHandler(todoService)
+	projectHandler := handler.NewProjectHandler(projectService)
+	tagHandler := handler.NewTagHandler(tagService)
+	trashHandler := handler.NewTrashHandler(trashService)
+	syncHandler := handler.NewSyncHandler(syncService)
+	eventHandler := handler.NewEventHandler(bus, cfg.Events.Heartbeat.Duration)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	jobHandler := handler.NewJobHandler(queue)
+	logHandler := handler.NewLogHandler()
 
-	// 動作確認用
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	// /readyz で確認する依存先
+	checks := health.NewRegistry(cfg.Health.CheckTimeout.Duration, cfg.Health.CacheTTL.Duration)
+	checks.Register("db", health.DB(db.DB))
+	checks.Register("migrations", health.Migrations(db.DB))
+	checks.Register("jobs", health.JobLag(queue, cfg.Health.MaxJobLag.Duration))
+	checks.Register("disk", health.DiskSpace(cfg.DB.Path, uint64(cfg.Health.MinFreeDiskMB)<<20))
+	healthHandler := handler.NewHealthHandler(checks)
+
+	// バックグラウンドジョブのワーカー
+	pool := jobs.NewPool(queue, cfg.Jobs.Workers, cfg.Jobs.PollInterval.Duration)
+	pool.Handle("purge.trash", purgeJob("trash", trashService.PurgeExpired))
+	pool.Handle("purge.tokens", purgeJob("tokens", authService.PurgeExpired))
+	pool.Handle("purge.idempotency_keys", purgeJob("idempotency keys", idempotencyService.PurgeExpired))
+	pool.Handle("purge.webhook_deliveries", purgeJob("webhook deliveries", webhookService.PurgeExpired))
+	pool.Handle("purge.jobs", purgeJob("jobs", queue.PurgeFinished))
+	pool.Start()
+
+	// ワーカー以外のバックグラウンド処理（シャットダウン時に止めて、終わるのを待つ）
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	goBackground := func(run func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			run(backgroundCtx)
+		}()
+	}
+
+	// 保存期間を過ぎたゴミ箱の Todo・期限切れのトークン・Idempotency-Key・webhook の送信の記録・終わったジョブを定期的に削除する
+	schedule := func(typ string, interval time.Duration) {
+		goBackground(func(ctx context.Context) { queue.Schedule(ctx, typ, interval) })
+	}
+	schedule("purge.trash", cfg.Trash.PurgeInterval.Duration)
+	schedule("purge.tokens", cfg.JWT.PurgeInterval.Duration)
+	schedule("purge.idempotency_keys", cfg.Idempotency.PurgeInterval.Duration)
+	schedule("purge.webhook_deliveries", cfg.Webhooks.PurgeInterval.Duration)
+	schedule("purge.jobs", cfg.Jobs.PurgeInterval.Duration)
+
+	// webhook の送信（失敗したものは間隔を空けて再送する）
+	goBackground(webhookService.Run)
+
+	// リクエストごとの span（死活監視と /metrics は除く）
+	r.Use(tracing.Middleware())
+	// X-Request-ID を決めて、request_id などを付けたロガーを context に入れる
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(middleware.AccessLogMiddleware())
+
+	// 全てのリクエストの数と時間を数える
+	r.Use(middleware.MetricsMiddleware())
+	r.GET("/metrics", middleware.MetricsAuthMiddleware(cfg.Metrics.Token), gin.WrapH(metrics.Handler()))
+
+	// 死活監視。/readyz はシャットダウン中は 503 にして、ロードバランサーに新しいリクエストを送らせない
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
+	r.GET("/health", healthHandler.Readyz) // 以前からのパス
 
 	// 認証系
 	r.POST("/signup", authHandler.Signup)
 	r.POST("/login", authHandler.Login)
+	r.POST("/token/refresh", authHandler.Refresh)
 
 	// TODO系（認証が必要なグループ）
 	authGroup := r.Group("/")
-	authGroup.Use(middleware.AuthMiddleware())
+	authGroup.Use(middleware.AuthMiddleware(authService))
+	authGroup.Use(middleware.IdempotencyMiddleware(idempotencyService))
+
+	authGroup.POST("/logout", authHandler.Logout)
+	authGroup.POST("/logout-all", authHandler.LogoutAll)
 
 	authGroup.GET("/todos", todoHandler.GetTodos)
+	authGroup.GET("/todos/search", todoHandler.SearchTodos)
+	authGroup.GET("/todos/overdue", todoHandler.GetOverdueTodos)
+	authGroup.GET("/todos/today", todoHandler.GetTodayTodos)
+	authGroup.GET("/todos/upcoming", todoHandler.GetUpcomingTodos)
 	authGroup.GET("/todos/:id", todoHandler.GetTodo)
+	authGroup.GET("/todos/:id/occurrences", todoHandler.GetOccurrences)
+	authGroup.GET("/todos/:id/children", todoHandler.GetChildren)
+	authGroup.POST("/todos/:id/move", todoHandler.MoveTodo)
 	authGroup.POST("/todos", todoHandler.CreateTodo)
+	authGroup.POST("/todos/bulk", todoHandler.BulkTodos)
 	authGroup.PUT("/todos/:id", todoHandler.UpdateTodo)
+	authGroup.PATCH("/todos/:id", todoHandler.PatchTodo)
 	authGroup.DELETE("/todos/:id", todoHandler.DeleteTodo)
+	authGroup.POST("/todos/:id/restore", todoHandler.RestoreTodo)
+	authGroup.PUT("/todos/:id/tags/:tag_id", tagHandler.AttachTag)
+	authGroup.DELETE("/todos/:id/tags/:tag_id", tagHandler.DetachTag)
+
+	authGroup.GET("/projects", projectHandler.GetProjects)
+	authGroup.GET("/projects/:id", projectHandler.GetProject)
+	authGroup.GET("/projects/:id/todos", todoHandler.GetProjectTodos)
+	authGroup.POST("/projects", projectHandler.CreateProject)
+	authGroup.PUT("/projects/:id", projectHandler.UpdateProject)
+	authGroup.POST("/projects/:id/archive", projectHandler.ArchiveProject)
+	authGroup.POST("/projects/:id/unarchive", projectHandler.UnarchiveProject)
+	authGroup.DELETE("/projects/:id", projectHandler.DeleteProject)
+
+	authGroup.GET("/tags", tagHandler.GetTags)
+	authGroup.POST("/tags", tagHandler.CreateTag)
+	authGroup.PUT("/tags/:id", tagHandler.RenameTag)
+	authGroup.POST("/tags/:id/merge", tagHandler.MergeTag)
+	authGroup.DELETE("/tags/:id", tagHandler.DeleteTag)
+
+	authGroup.GET("/trash", trashHandler.GetTrash)
+	authGroup.DELETE("/trash", trashHandler.EmptyTrash)
+	authGroup.DELETE("/trash/:id", trashHandler.DeleteTrashTodo)
+
+	authGroup.GET("/sync", syncHandler.GetChanges)
+	authGroup.POST("/sync", syncHandler.PushChanges)
+
+	authGroup.GET("/webhooks", webhookHandler.GetWebhooks)
+	authGroup.GET("/webhooks/:id", webhookHandler.GetWebhook)
+	authGroup.POST("/webhooks", webhookHandler.CreateWebhook)
+	authGroup.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+	authGroup.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+	authGroup.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+	authGroup.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverDelivery)
+
+	// リアルタイム配信（EventSource / WebSocket はヘッダーを付けられないので ?access_token= でも認証する）
+	streamGroup := r.Group("/")
+	streamGroup.Use(middleware.StreamAuthMiddleware(authService))
+
+	streamGroup.GET("/events", eventHandler.StreamEvents)
+	streamGroup.GET("/ws", eventHandler.StreamWebSocket)
+
+	// 管理用（admin.token が空なら使えない）
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(middleware.AdminMiddleware(cfg.Admin.Token))
+
+	adminGroup.GET("/jobs", jobHandler.GetJobs)
+	adminGroup.GET("/log/level", logHandler.GetLevel)
+	adminGroup.PUT("/log/level", logHandler.SetLevel)
+
+	srv := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout.Duration,
+		WriteTimeout: cfg.Server.WriteTimeout.Duration,
+		IdleTimeout:  cfg.Server.IdleTimeout.Duration,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Logger.Info("server started", "addr", cfg.Server.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		logger.Logger.Info("shutdown signal received")
+	case err := <-serveErr:
+		logger.Logger.Error("server stopped", "reason", err.Error())
+		exitCode = 1
+	}
+	stop() // もう一度シグナルを受けたらすぐに終わる
+
+	// シャットダウンは次の順に止める:
+	//
+	//  1. /readyz を 503 にして、ロードバランサーが気づくまで server.shutdown_delay だけ待つ
+	//  2. イベントバスを閉じる（GET /events と /ws の接続を切る。クライアントは Last-Event-ID で再接続する）
+	//  3. 新しい接続を受け付けるのをやめて、処理中のリクエストを待つ（server.shutdown_timeout を過ぎたら切る）
+	//  4. 定期的なジョブの追加と webhook の送信を止める
+	//  5. 実行中のジョブを待つ（jobs.drain_timeout を過ぎたら取り消して再試行に回す）
+	//  6. DB を閉じる
+	//  7. 残っている span を送る
+	//  8. ログのファイルを閉じる
+	start := time.Now()
+	checks.ShutDown()
+	if delay := cfg.Server.ShutdownDelay.Duration; delay > 0 {
+		logger.Logger.Info("waiting for load balancer to stop routing", "delay", delay.String())
+		time.Sleep(delay)
+	}
+	bus.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Logger.Warn("requests did not finish before shutdown timeout", "reason", err.Error())
+		srv.Close()
+	}
+
+	stopBackground()
+	background.Wait()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Jobs.DrainTimeout.Duration)
+	if err := pool.Shutdown(drainCtx); err != nil {
+		logger.Logger.Warn("jobs did not finish before drain timeout", "reason", err.Error())
+	}
+
+	if err := db.Close(); err != nil {
+		logger.Logger.Error("failed to close database", "reason", err.Error())
+		exitCode = 1
+	}
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Logger.Warn("failed to flush traces", "reason", err.Error())
+	}
+	logger.Logger.Info("shutdown complete", "duration", time.Since(start).String())
+	if err := logger.Close(); err != nil {
+		log.Println("failed to close log file:", err)
+	}
+
+	cancel()
+	cancelDrain()
+	cancelFlush()
+	os.Exit(exitCode)
+}
+
+// purgeJob は purge で期限切れのデータを削除するジョブのハンドラー
+func purgeJob(name string, purge func(now time.Time) (int64, error)) jobs.Handler {
+	return func(ctx context.Context, job *model.Job) error {
+		purged, err := purge(time.Now())
+		if err != nil {
+			return fmt.Errorf("%s purge failed: %w", name, err)
+		}
+		if purged > 0 {
+			logger.Info(ctx, name+" purged", "deleted", purged)
+		}
+		return nil
+	}
+}
+
+// migrate サブコマンドの実行
+func runMigrate(cfg *config.Config, args []string) {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
 
-	r.Run(":8080")
+	db.Open(cfg.DB.Path)
+	migrator, err := db.NewMigrator(db.DB)
+	if err != nil {
+		log.Fatal("failed to load migrations:", err)
+	}
 
+	switch cmd {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, st := range statuses {
+			appliedAt := "pending"
+			if st.Applied {
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", st.Version, st.Name, appliedAt)
+		}
+	case "up":
+		n, err := migrator.Up()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+	case "down":
+		mg, err := migrator.Down()
+		if errors.Is(err, db.ErrNoMigration) {
+			fmt.Println("nothing to roll back")
+			return
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("rolled back %04d_%s\n", mg.Version, mg.Name)
+	case "redo":
+		mg, err := migrator.Redo()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("redid %04d_%s\n", mg.Version, mg.Name)
+	default:
+		log.Fatalf("unknown migrate command: %s (status|up|down|redo)", cmd)
+	}
 }
@@ -0,0 +1,92 @@
+// Package metrics は GET /metrics で公開する Prometheus のメトリクス。
+//
+// メトリクスはパッケージ変数で、どの層からも直接数える（logger.Logger と同じ）。
+// デフォルトの prometheus.DefaultRegisterer は使わず、Registry に登録したものだけを公開する。
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ログインの結果（LoginAttempts の result）
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests はリクエストの数。route はパスのテンプレート（例: /todos/:id）で、どのルートにも当たらなければ unmatched
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DBQueryDuration は GORM のクエリの時間。operation は create / query / update / delete / row / raw
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "GORM query latency by operation and table.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8), // 0.1ms 〜 1.6s
+	}, []string{"operation", "table"})
+
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Number of login attempts by result.",
+	}, []string{"result"})
+
+	TodosCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "todos_created_total",
+		Help: "Number of todos created (including next occurrences of recurring todos).",
+	})
+
+	TodosCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "todos_completed_total",
+		Help: "Number of todos marked as done (including auto-completed parents).",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		DBQueryDuration,
+		LoginAttempts,
+		TodosCreated,
+		TodosCompleted,
+	)
+	// 0 のままでも系列が出るように、値が決まっているラベルは先に作っておく
+	LoginAttempts.WithLabelValues(LoginSuccess)
+	LoginAttempts.WithLabelValues(LoginFailure)
+}
+
+// Handler は Registry のメトリクスを Prometheus のテキスト形式で返す
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Todos は service の TodoCounter で、作成・完了した todo を数える。
+// イベント（Publish）は数えない。todo.created には復元も含まれるので
+type Todos struct{}
+
+func (Todos) Publish(userID uint, typ string, data any) {}
+
+func (Todos) TodosCreated(n int) {
+	TodosCreated.Add(float64(n))
+}
+
+func (Todos) TodosCompleted(n int) {
+	TodosCompleted.Add(float64(n))
+}
@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/metrics"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// --- GORMPlugin ---
+func TestGORMPlugin(t *testing.T) {
+
+	db.Init(filepath.Join(t.TempDir(), "metrics.db"))
+	t.Cleanup(func() { db.Close() })
+	if err := db.DB.Use(metrics.GORMPlugin{}); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+
+	user := model.User{Email: "a@b.c", Password: "x"}
+	db.DB.Create(&user)
+	var found []model.User
+	db.DB.Where("email = ?", "a@b.c").Find(&found)
+
+	for _, labels := range [][]string{{"create", "users"}, {"query", "users"}} {
+		var m dto.Metric
+		histogram := metrics.DBQueryDuration.WithLabelValues(labels...).(prometheus.Histogram)
+		if err := histogram.Write(&m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if m.GetHistogram().GetSampleCount() == 0 {
+			t.Errorf("%v: expected observations, got none", labels)
+		}
+	}
+}
+
+// --- Todos（service の TodoCounter） ---
+func TestTodos(t *testing.T) {
+
+	created := testutil.ToFloat64(metrics.TodosCreated)
+	completed := testutil.ToFloat64(metrics.TodosCompleted)
+
+	todos := metrics.Todos{}
+	todos.TodosCreated(2)
+	todos.TodosCompleted(1)
+	todos.Publish(1, "todo.created", nil) // イベントは数えない
+
+	if got := testutil.ToFloat64(metrics.TodosCreated) - created; got != 2 {
+		t.Errorf("expected 2 created, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.TodosCompleted) - completed; got != 1 {
+		t.Errorf("expected 1 completed, got %v", got)
+	}
+}
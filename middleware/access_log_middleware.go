@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogMiddleware はリクエストごとに 1 行出す（gin.Logger の代わり）。
+// クエリ文字列は ?access_token= を含むことがあるので出さない。RequestIDMiddleware の後に置く
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		ctx := c.Request.Context()
+		args := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start).String(),
+			"bytes", c.Writer.Size(),
+		}
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			logger.Error(ctx, "request completed", args...)
+		case status >= 400:
+			logger.Warn(ctx, "request completed", args...)
+		default:
+			logger.Info(ctx, "request completed", args...)
+		}
+	}
+}
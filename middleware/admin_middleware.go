@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware は管理用 API を Authorization: Bearer <token> で守る。
+// token が空なら管理用 API は無効で、常に 404 を返す
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			c.Abort()
+			return
+		}
+
+		if !checkBearerToken(c, token) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkBearerToken は Authorization: Bearer が token と一致するか確認する。違えばエラーを返して false
+func checkBearerToken(c *gin.Context, token string) bool {
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || given == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+		c.Abort()
+		return false
+	}
+	// 一致するまでの時間からトークンを推測されないように、比較の時間を一定にする
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid token"})
+		c.Abort()
+		return false
+	}
+	return true
+}
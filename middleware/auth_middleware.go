@@ -1,19 +1,39 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	myjwt "github.com/a5415091-collab/go-gin-todo-app/jwt"
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker は jti が失効済みかどうかを判定する（AuthService が実装）
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+func AuthMiddleware(revocations RevocationChecker) gin.HandlerFunc {
+	return authMiddleware(revocations, false)
+}
+
+// StreamAuthMiddleware は AuthMiddleware と同じ検証をする。
+// EventSource や WebSocket はヘッダーを付けられないので、Authorization ヘッダーがなければ ?access_token= を使う
+func StreamAuthMiddleware(revocations RevocationChecker) gin.HandlerFunc {
+	return authMiddleware(revocations, true)
+}
+
+func authMiddleware(revocations RevocationChecker, allowQuery bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		// Authorization ヘッダ
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && allowQuery && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
 			c.Abort()
@@ -46,12 +66,35 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// user_id を Float64 → uint
-		userIDFloat := claims["user_id"].(float64)
+		// user_id を Float64 → uint、jti は失効チェック用
+		userIDFloat, ok := claims["user_id"].(float64)
+		jti, hasJTI := claims["jti"].(string)
+		exp, err := claims.GetExpirationTime()
+		if !ok || !hasJTI || jti == "" || err != nil || exp == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
+			c.Abort()
+			return
+		}
 		userID := uint(userIDFloat)
 
+		// 失効リスト（logout 済み）の確認
+		revoked, err := revocations.IsRevoked(c.Request.Context(), jti)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			c.Abort()
+			return
+		}
+
 		// context に保存
 		c.Set("userID", userID)
+		c.Set("jti", jti)
+		c.Set("tokenExpiresAt", exp.Time)
+		c.Request = c.Request.WithContext(logger.With(c.Request.Context(), "user_id", userID))
 
 		// 次へ
 		c.Next()
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyStore は Idempotency-Key ごとのリクエストと応答を保存する（IdempotencyService が実装）
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID uint, key string, fingerprint string) (*model.IdempotencyKey, error)
+	Complete(ctx context.Context, record *model.IdempotencyKey, status int, contentType string, headers model.Headers, body []byte) error
+	Release(ctx context.Context, record *model.IdempotencyKey) error
+}
+
+// maxIdempotentBodySize は Idempotency-Key 付きのリクエストの body の上限（fingerprint のためにメモリに読み込む）
+const maxIdempotentBodySize = 1 << 20
+
+// replayedHeaders は応答と一緒に保存して、再送にも返すヘッダー
+var replayedHeaders = []string{"ETag", "Location", "Last-Modified"}
+
+// IdempotencyMiddleware は Idempotency-Key ヘッダー付きの POST の応答を保存して、
+// 同じキーの再送には処理せずに保存した応答（Idempotent-Replayed: true）を返す。
+// 同じキーで body などが違うリクエストは 422、最初のリクエストが処理中なら 409。
+// 5xx の応答は保存しない（再送で処理し直す）。body が maxIdempotentBodySize を超えたら 413。AuthMiddleware の後に使う
+func IdempotencyMiddleware(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := c.GetHeader("Idempotency-Key")
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		userID := c.GetUint("userID")
+
+		// body を読んだ後もハンドラーで読めるように戻す
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := store.Begin(c.Request.Context(), userID, key, fingerprint(c.Request.Method, c.Request.URL.RequestURI(), body))
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			logger.Warn(c.Request.Context(), "idempotency key reused", "key", key)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case err != nil:
+			logger.Error(c.Request.Context(), "idempotency key lookup failed", "reason", err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check idempotency key"})
+			c.Abort()
+			return
+		}
+
+		// 保存済みの応答を返す
+		if record.StatusCode != 0 {
+			logger.Info(c.Request.Context(), "idempotent request replayed", "key", key)
+			for name, value := range record.ResponseHeaders {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// ハンドラーが panic したときも、キーが処理中のまま残らないようにする
+		completed := false
+		defer func() {
+			if !completed {
+				if err := store.Release(c.Request.Context(), record); err != nil {
+					logger.Error(c.Request.Context(), "failed to release idempotency key", "reason", err.Error())
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		headers := model.Headers{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err := store.Complete(c.Request.Context(), record, status, recorder.Header().Get("Content-Type"), headers, recorder.body.Bytes()); err != nil {
+			logger.Error(c.Request.Context(), "failed to save idempotent response", "reason", err.Error())
+			return
+		}
+		completed = true
+	}
+}
+
+// fingerprint はキーの使い回しを見分けるためのリクエストのハッシュ
+func fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder はクライアントに書き込みながら body を記録する
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,97 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/middleware"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 応答の保存と再送は DB を通して確認する（ヘッダーの保存・読み込みも含めて）
+func newIdempotencyRouter(t *testing.T, handle gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db.Init(filepath.Join(t.TempDir(), "idempotency.db"))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB.DB()
+		sqlDB.Close()
+	})
+	store := service.NewIdempotencyService(repository.NewIdempotencyRepository(), time.Hour)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) })
+	r.Use(middleware.IdempotencyMiddleware(store))
+	r.POST("/todos", handle)
+	return r
+}
+
+func postWithKey(r *gin.Engine, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// --- IdempotencyMiddleware（応答ヘッダーの再送） ---
+func TestIdempotencyMiddleware_ReplaysHeaders(t *testing.T) {
+
+	calls := 0
+	r := newIdempotencyRouter(t, func(c *gin.Context) {
+		calls++
+		c.Header("ETag", `"1-1"`)
+		c.Header("Location", "/todos/1")
+		c.Header("X-Debug", "not replayed")
+		c.JSON(http.StatusCreated, gin.H{"ID": 1})
+	})
+
+	first := postWithKey(r, "k-1", `{"title":"a"}`)
+	replayed := postWithKey(r, "k-1", `{"title":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, got %d", calls)
+	}
+	if replayed.Code != http.StatusCreated || replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replayed 201, got %d %v", replayed.Code, replayed.Header())
+	}
+	if replayed.Body.String() != first.Body.String() || replayed.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("expected same response, got %q (%s)", replayed.Body.String(), replayed.Header().Get("Content-Type"))
+	}
+	for _, name := range []string{"ETag", "Location"} {
+		if got := replayed.Header().Get(name); got != first.Header().Get(name) {
+			t.Errorf("expected %s %q, got %q", name, first.Header().Get(name), got)
+		}
+	}
+	if got := replayed.Header().Get("X-Debug"); got != "" {
+		t.Errorf("expected X-Debug not to be replayed, got %q", got)
+	}
+}
+
+// --- IdempotencyMiddleware（body の上限） ---
+func TestIdempotencyMiddleware_BodyTooLarge(t *testing.T) {
+
+	calls := 0
+	r := newIdempotencyRouter(t, func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	if w := postWithKey(r, "k-1", strings.Repeat("a", 1<<20+1)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", w.Code)
+	}
+	if w := postWithKey(r, "k-2", strings.Repeat("a", 1<<20)); w.Code != http.StatusCreated {
+		t.Errorf("expected body at the limit to be accepted, got %d", w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to run once, got %d", calls)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware はリクエストの数と時間を数える。
+// route はパスのテンプレート（/todos/:id）にして、id ごとに系列が増えないようにする
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsAuthMiddleware は /metrics を Authorization: Bearer <token> で守る。token が空なら誰でも見られる
+func MetricsAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" && !checkBearerToken(c, token) {
+			return
+		}
+		c.Next()
+	}
+}
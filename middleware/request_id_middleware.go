@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength を超える X-Request-ID は使わずに作り直す
+	maxRequestIDLength = 128
+)
+
+// RequestIDMiddleware はリクエスト ID を決めて X-Request-ID で返し、
+// request_id・route・client_ip を付けたロガーを c.Request の context に入れる（logger.FromContext で取り出す）。
+// X-Request-ID が送られてくればそれを使う（ロードバランサーなどと ID を揃える）
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := c.Request.Context()
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request.id", id))
+		ctx = logger.With(ctx, "request_id", id, "route", c.Request.Method+" "+route, "client_ip", c.ClientIP())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// validRequestID はログを崩さない ID か（空白や制御文字を含まない ASCII）
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,109 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// newRequestID で作った ID の形
+var generatedID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// --- RequestIDMiddleware ---
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		header      string // 送る X-Request-ID（空なら送らない）
+		path        string
+		expectID    string // 空なら作り直されていること
+		expectRoute string
+	}{
+		{name: "inbound id is used", header: "lb-1234.abc", path: "/items/7", expectID: "lb-1234.abc", expectRoute: "GET /items/:id"},
+		{name: "missing id is generated", path: "/items/7", expectRoute: "GET /items/:id"},
+		{name: "id with spaces is replaced", header: "bad id", path: "/items/7", expectRoute: "GET /items/:id"},
+		{name: "id with control characters is replaced", header: "abc\x01", path: "/items/7", expectRoute: "GET /items/:id"},
+		{name: "too long id is replaced", header: strings.Repeat("a", 129), path: "/items/7", expectRoute: "GET /items/:id"},
+		{name: "unmatched route", header: "abc", path: "/nothing", expectID: "abc", expectRoute: "GET unmatched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger.Logger = slog.New(slog.NewJSONHandler(&out, nil))
+
+			var handlerID string
+			r := gin.New()
+			r.Use(middleware.RequestIDMiddleware())
+			handle := func(c *gin.Context) {
+				handlerID = c.GetString("requestID")
+				logger.Info(c.Request.Context(), "handled")
+				c.Status(http.StatusNoContent)
+			}
+			r.GET("/items/:id", handle)
+			r.NoRoute(handle)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(middleware.RequestIDHeader)
+			if tt.expectID != "" {
+				if id != tt.expectID {
+					t.Errorf("expected id %q, got %q", tt.expectID, id)
+				}
+			} else if !generatedID.MatchString(id) {
+				t.Errorf("expected a generated id, got %q", id)
+			}
+			if handlerID != id {
+				t.Errorf("expected requestID %q in gin context, got %q", id, handlerID)
+			}
+
+			var line map[string]any
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("expected one JSON line, got %q: %v", out.String(), err)
+			}
+			if line["request_id"] != id {
+				t.Errorf("expected request_id %q in log, got %v", id, line["request_id"])
+			}
+			if line["route"] != tt.expectRoute {
+				t.Errorf("expected route %q in log, got %v", tt.expectRoute, line["route"])
+			}
+			if _, ok := line["client_ip"]; !ok {
+				t.Errorf("expected client_ip in log: %v", line)
+			}
+		})
+	}
+}
+
+// ID を送らないリクエストにはそれぞれ別の ID を作る
+func TestRequestIDMiddleware_Unique(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestIDMiddleware())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		id := w.Header().Get(middleware.RequestIDHeader)
+		if seen[id] {
+			t.Fatalf("duplicate request id %q", id)
+		}
+		seen[id] = true
+	}
+}
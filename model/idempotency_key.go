@@ -0,0 +1,52 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKey は Idempotency-Key ヘッダー付きのリクエストと、その応答。
+// Fingerprint はメソッド・パス・body のハッシュで、StatusCode が 0 なら処理中
+type IdempotencyKey struct {
+	ID              uint `gorm:"primarykey"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uint
+	Key             string
+	Fingerprint     string
+	StatusCode      int
+	ContentType     string
+	ResponseHeaders Headers // 再送にも返す応答ヘッダー（ETag・Location など）
+	ResponseBody    []byte
+}
+
+// Headers は JSON のオブジェクトとして保存する応答ヘッダー（空なら空文字）
+type Headers map[string]string
+
+func (h Headers) Value() (driver.Value, error) {
+	if len(h) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(h)
+	return string(b), err
+}
+
+func (h *Headers) Scan(value any) error {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	case nil:
+	default:
+		return fmt.Errorf("cannot scan %T into Headers", value)
+	}
+	*h = nil
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, h)
+}
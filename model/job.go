@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Job の状態
+const (
+	JobPending   = "pending"   // 実行待ち（RunAt 以降に実行する）
+	JobRunning   = "running"   // ワーカーが実行中（LeasedUntil を過ぎたら別のワーカーがやり直す）
+	JobSucceeded = "succeeded" // 成功した
+	JobDead      = "dead"      // 再試行の上限まで失敗した（dead-letter）
+)
+
+// Job はバックグラウンドで実行する処理 1 件。
+// LeaseToken は実行中のワーカーの印で、期限切れの後に別のワーカーが取ったジョブを前のワーカーが上書きしないようにする
+type Job struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Type        string
+	Payload     RawJSON
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LeasedUntil *time.Time
+	LeaseToken  string `json:"-"`
+	LastError   string
+	FinishedAt  *time.Time
+	UniqueKey   *string // 同じキーの未完了のジョブは 1 つだけ
+}
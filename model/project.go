@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Project は Todo をまとめるリスト。Position はユーザーごとの並び順（0 始まりで詰める）
+type Project struct {
+	gorm.Model
+	UserID     uint
+	Name       string
+	Color      string // "#RRGGBB"、空なら指定なし
+	Position   int
+	ArchivedAt *time.Time
+}
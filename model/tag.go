@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Tag はユーザーごとのラベル。名前は大文字小文字を区別せず一意
+type Tag struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint
+	Name      string
+}
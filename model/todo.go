@@ -1,10 +1,43 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Todo struct {
 	gorm.Model
-	UserID uint
-	Title  string
-	Done   bool
+	UserID    uint
+	ProjectID *uint // nil なら未分類（Inbox）
+	Title     string
+	Done      bool
+
+	// 楽観的排他制御用。保存のたびに 1 増え、ETag に使う
+	Version uint `gorm:"not null;default:1"`
+
+	// 差分同期用の変更番号（作成時と最後に変更したとき）。DB のトリガーで振るので書き込まない
+	CreatedSeq int64 `gorm:"->" json:"-"`
+	ChangeSeq  int64 `gorm:"->" json:"-"`
+
+	// サブタスク。AutoComplete なら子が全て完了したときに自分も完了する。
+	// Progress は子孫の完了率（0〜100、子がなければ nil）で、保存せず読み込み時に計算する
+	ParentID     *uint
+	AutoComplete bool
+	Progress     *int `gorm:"-"`
+
+	// 期日。時刻指定は UTC で保存し、終日（DueAllDay）は日付を UTC 0 時で保存して
+	// 利用者のタイムゾーンでの「その日」として扱う
+	DueAt       *time.Time
+	DueAllDay   bool
+	StartAt     *time.Time
+	CompletedAt *time.Time
+
+	// 繰り返し（RFC 5545 の RRULE）。RecurrenceStart は系列の DTSTART（最初の回の期日）で、
+	// 時刻指定の期日は RecurrenceTZ の壁時計の時刻を保って展開する
+	RRule           string `gorm:"column:rrule"`
+	RecurrenceTZ    string `gorm:"column:recurrence_tz"`
+	RecurrenceStart *time.Time
+
+	Tags []Tag `gorm:"many2many:todo_tags"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// RefreshToken はリフレッシュトークン（平文ではなくハッシュを保存）
+// 同じログインから回転して発行されたものは同じ FamilyID を持つ
+type RefreshToken struct {
+	ID              uint `gorm:"primarykey"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	UserID          uint
+	FamilyID        string
+	TokenHash       string `gorm:"unique"`
+	AccessJTI       string `gorm:"column:access_jti"` // 同時に発行したアクセストークンの jti
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	RevokedAt       *time.Time
+	ReplacedByID    *uint
+}
+
+// RevokedToken は失効させたアクセストークン（jti で引く）
+type RevokedToken struct {
+	JTI       string `gorm:"column:jti;primaryKey"`
+	UserID    uint
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook は todo のイベントを POST する先。
+// Events は送るイベントの種類、FailureCount は連続して失敗した送信の回数
+type Webhook struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uint
+	URL          string `gorm:"column:url"`
+	Events       StringList
+	Secret       string `json:"-"` // 署名用。作成時にだけ返す
+	Active       bool
+	FailureCount int
+	DisabledAt   *time.Time // 止めた（連続して失敗して自動で止まった）日時
+}
+
+// WebhookDelivery の状態
+const (
+	DeliveryPending   = "pending"   // 送信待ち（NextAttemptAt に送る）
+	DeliverySucceeded = "succeeded" // 2xx が返った
+	DeliveryFailed    = "failed"    // 再送の上限まで失敗した
+)
+
+// WebhookDelivery は 1 件のイベントの送信とその結果
+type WebhookDelivery struct {
+	ID             uint `gorm:"primarykey"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	WebhookID      uint
+	UserID         uint
+	Event          string
+	Payload        RawJSON
+	Status         string
+	Attempts       int
+	NextAttemptAt  *time.Time
+	ResponseStatus int    // 最後の送信の HTTP ステータス（届かなかったら 0）
+	Error          string // 最後の送信が失敗した理由
+	DeliveredAt    *time.Time
+}
+
+// StringList はカンマ区切りの文字列として保存する []string
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+func (l *StringList) Scan(value any) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+	*l = nil
+	if s != "" {
+		*l = strings.Split(s, ",")
+	}
+	return nil
+}
+
+// RawJSON は JSON のまま保存・出力する []byte（json.RawMessage と同じ）
+type RawJSON []byte
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j RawJSON) Value() (driver.Value, error) {
+	return []byte(j), nil
+}
+
+func (j *RawJSON) Scan(value any) error {
+	switch v := value.(type) {
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = RawJSON(v)
+	case nil:
+		*j = nil
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", value)
+	}
+	return nil
+}
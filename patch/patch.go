@@ -0,0 +1,298 @@
+// Package patch は JSON ドキュメントの部分更新を扱う。
+//
+//   - Merge: RFC 7396 JSON Merge Patch
+//   - Apply: RFC 6902 JSON Patch（add / remove / replace / move / copy / test）
+//
+// ドキュメントは encoding/json で any にデコードした値（map[string]any、[]any、
+// float64、string、bool、nil）で表し、引数は書き換えずに新しい値を返す。
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalid は操作の形式やパスが正しくない
+	ErrInvalid = errors.New("invalid patch")
+	// ErrTestFailed は test 操作の値が一致しなかった
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Merge は target に RFC 7396 のマージパッチを適用した結果を返す。
+// patch のオブジェクトの null はキーの削除、それ以外の値は置き換え（オブジェクトは再帰的にマージ）
+func Merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	result := map[string]any{}
+	if t, ok := target.(map[string]any); ok {
+		for k, v := range t {
+			result[k] = v
+		}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		result[k] = Merge(result[k], v)
+	}
+	return result
+}
+
+// Operation は JSON Patch の操作 1 つ
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"` // 省略と null を区別するため RawMessage
+}
+
+// Apply は doc に ops を順に適用した結果を返す。途中で失敗したら何も適用しない
+func Apply(doc any, ops []Operation) (any, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = applyOp(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOp(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalid)
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrTestFailed
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return remove(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if hasPrefix(path, from) && len(path) > len(from) {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalid)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalid, op.Op)
+	}
+}
+
+// parsePointer は RFC 6901 の JSON Pointer（"/a/b~1c"）をトークンに分ける
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path must start with '/': %q", ErrInvalid, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for i, token := range tokens {
+		tokens[i] = unescape.Replace(token)
+	}
+	return tokens, nil
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch v := doc.(type) {
+		case map[string]any:
+			value, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q not found", ErrInvalid, token)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(v)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("%w: %q not found", ErrInvalid, token)
+		}
+	}
+	return doc, nil
+}
+
+// add は path に value を置く（配列では index の位置に挿入、"-" は末尾）
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch v := doc.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v)+1)
+		for k, x := range v {
+			result[k] = x
+		}
+		if len(rest) == 0 {
+			result[token] = value
+			return result, nil
+		}
+		child, ok := v[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q not found", ErrInvalid, token)
+		}
+		added, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		result[token] = added
+		return result, nil
+
+	case []any:
+		if len(rest) == 0 {
+			i := len(v)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(v)); err != nil {
+					return nil, err
+				}
+			}
+			result := make([]any, 0, len(v)+1)
+			result = append(result, v[:i]...)
+			result = append(result, value)
+			return append(result, v[i:]...), nil
+		}
+		i, err := arrayIndex(token, len(v)-1)
+		if err != nil {
+			return nil, err
+		}
+		added, err := add(v[i], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		result := append([]any{}, v...)
+		result[i] = added
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q not found", ErrInvalid, token)
+	}
+}
+
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalid)
+	}
+	token, rest := path[0], path[1:]
+
+	switch v := doc.(type) {
+	case map[string]any:
+		child, ok := v[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q not found", ErrInvalid, token)
+		}
+		result := make(map[string]any, len(v))
+		for k, x := range v {
+			result[k] = x
+		}
+		if len(rest) == 0 {
+			delete(result, token)
+			return result, nil
+		}
+		removed, err := remove(child, rest)
+		if err != nil {
+			return nil, err
+		}
+		result[token] = removed
+		return result, nil
+
+	case []any:
+		i, err := arrayIndex(token, len(v)-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			result := make([]any, 0, len(v)-1)
+			result = append(result, v[:i]...)
+			return append(result, v[i+1:]...), nil
+		}
+		removed, err := remove(v[i], rest)
+		if err != nil {
+			return nil, err
+		}
+		result := append([]any{}, v...)
+		result[i] = removed
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q not found", ErrInvalid, token)
+	}
+}
+
+// arrayIndex は配列の添字を 0〜max の範囲で読む（先頭の 0 は不可）
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalid, token)
+	}
+	return i, nil
+}
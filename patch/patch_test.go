@@ -0,0 +1,215 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/patch"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid json %s: %v", s, err)
+	}
+	return v
+}
+
+// キーの順序に依存せずに比べるため、デコードし直した値をエンコードして比べる
+func assertJSON(t *testing.T, got any, expected string) {
+	t.Helper()
+
+	g, _ := json.Marshal(got)
+	e, _ := json.Marshal(decode(t, expected))
+	if string(g) != string(e) {
+		t.Errorf("expected %s, got %s", e, g)
+	}
+}
+
+// RFC 7396 Appendix A の例
+func TestMerge(t *testing.T) {
+
+	tests := []struct {
+		target string
+		patch  string
+		expect string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" + "+tt.patch, func(t *testing.T) {
+
+			target := decode(t, tt.target)
+			got := patch.Merge(target, decode(t, tt.patch))
+			assertJSON(t, got, tt.expect)
+
+			// 元のドキュメントは書き換えない
+			assertJSON(t, target, tt.target)
+		})
+	}
+}
+
+// RFC 6902 Appendix A の例を中心に
+func TestApply(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		doc       string
+		ops       string
+		expect    string
+		expectErr error
+	}{
+		{
+			name:   "add object member",
+			doc:    `{"foo":"bar"}`,
+			ops:    `[{"op":"add","path":"/baz","value":"qux"}]`,
+			expect: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:   "add array element",
+			doc:    `{"foo":["bar","baz"]}`,
+			ops:    `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			expect: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:   "add to end of array",
+			doc:    `{"foo":["bar"]}`,
+			ops:    `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			expect: `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:   "remove array element",
+			doc:    `{"foo":["bar","qux","baz"]}`,
+			ops:    `[{"op":"remove","path":"/foo/1"}]`,
+			expect: `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:   "replace",
+			doc:    `{"baz":"qux","foo":"bar"}`,
+			ops:    `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			expect: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:   "move",
+			doc:    `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			ops:    `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			expect: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:   "move array element",
+			doc:    `{"foo":["all","grass","cows","eat"]}`,
+			ops:    `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			expect: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:   "copy",
+			doc:    `{"a":{"b":1}}`,
+			ops:    `[{"op":"copy","from":"/a","path":"/c"}]`,
+			expect: `{"a":{"b":1},"c":{"b":1}}`,
+		},
+		{
+			name:   "test success then replace",
+			doc:    `{"baz":"qux","foo":["a",2,"c"]}`,
+			ops:    `[{"op":"test","path":"/foo","value":["a",2,"c"]},{"op":"replace","path":"/baz","value":null}]`,
+			expect: `{"baz":null,"foo":["a",2,"c"]}`,
+		},
+		{
+			name:   "escaped pointer",
+			doc:    `{"a/b":1,"m~n":2}`,
+			ops:    `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`,
+			expect: `{"m~n":3}`,
+		},
+		{
+			name:      "test failure",
+			doc:       `{"baz":"qux"}`,
+			ops:       `[{"op":"test","path":"/baz","value":"bar"}]`,
+			expectErr: patch.ErrTestFailed,
+		},
+		{
+			name:      "add to nonexistent target",
+			doc:       `{"foo":"bar"}`,
+			ops:       `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			expectErr: patch.ErrInvalid,
+		},
+		{
+			name:      "replace missing member",
+			doc:       `{"foo":"bar"}`,
+			ops:       `[{"op":"replace","path":"/baz","value":1}]`,
+			expectErr: patch.ErrInvalid,
+		},
+		{
+			name:      "missing value",
+			doc:       `{"foo":"bar"}`,
+			ops:       `[{"op":"add","path":"/baz"}]`,
+			expectErr: patch.ErrInvalid,
+		},
+		{
+			name:      "array index out of range",
+			doc:       `{"foo":["bar"]}`,
+			ops:       `[{"op":"add","path":"/foo/2","value":"x"}]`,
+			expectErr: patch.ErrInvalid,
+		},
+		{
+			name:      "leading zero index",
+			doc:       `{"foo":["bar","baz"]}`,
+			ops:       `[{"op":"remove","path":"/foo/01"}]`,
+			expectErr: patch.ErrInvalid,
+		},
+		{
+			name:      "move into own child",
+			doc:       `{"a":{"b":1}}`,
+			ops:       `[{"op":"move","from":"/a","path":"/a/c"}]`,
+			expectErr: patch.ErrInvalid,
+		},
+		{
+			name:      "unknown op",
+			doc:       `{}`,
+			ops:       `[{"op":"merge","path":"/a","value":1}]`,
+			expectErr: patch.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var ops []patch.Operation
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatalf("invalid ops: %v", err)
+			}
+			doc := decode(t, tt.doc)
+
+			got, err := patch.Apply(doc, ops)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSON(t, got, tt.expect)
+
+			// 元のドキュメントは書き換えない
+			assertJSON(t, doc, tt.doc)
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RFC 5545 の RRULE のうち、このアプリで使う範囲だけを扱う:
+//
+//	FREQ=DAILY|WEEKLY|MONTHLY|YEARLY, INTERVAL, COUNT, UNTIL, BYDAY, WKST
+//
+// 発生日時は DTSTART の壁時計の時刻（そのタイムゾーン）を保つので、
+// DST の切り替えをまたいでも「毎朝 9:00」は 9:00 のまま。
+// 存在しない日付（2/30 や 4/31）は RFC どおり飛ばす。
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// 何も発生しないルールで無限ループしないための上限（期間の数）
+const maxPeriods = 100000
+
+var ErrInvalidRule = errors.New("invalid rrule")
+
+// WeekdayNum は BYDAY の 1 要素（N が 0 以外なら「第 N」、負なら末尾から）
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type Rule struct {
+	Freq      Frequency
+	Interval  int
+	Count     int
+	Until     *time.Time // UNTIL（この日時を含む）
+	UntilDate bool       // UNTIL が日付だけ（YYYYMMDD）
+	ByDay     []WeekdayNum
+	WeekStart time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse は "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10" 形式（先頭の "RRULE:" は省略可）を解釈する
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidRule)
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly && r.Freq != Yearly {
+				err = fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(key, value)
+		case "COUNT":
+			r.Count, err = parsePositive(key, value)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			err = r.parseByDay(value)
+		case "WKST":
+			wd, ok := weekdayCodes[value]
+			if !ok {
+				err = fmt.Errorf("%w: invalid WKST %s", ErrInvalidRule, value)
+			}
+			r.WeekStart = wd
+		default:
+			err = fmt.Errorf("%w: unsupported %s", ErrInvalidRule, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	}
+	if r.Freq == Daily || r.Freq == Weekly {
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				return nil, fmt.Errorf("%w: BYDAY ordinals are only allowed with MONTHLY or YEARLY", ErrInvalidRule)
+			}
+		}
+	}
+	return r, nil
+}
+
+func parsePositive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidRule, key)
+	}
+	return n, nil
+}
+
+func (r *Rule) parseUntil(value string) error {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		r.Until = &t
+		return nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		r.Until = &t
+		r.UntilDate = true
+		return nil
+	}
+	return fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ", ErrInvalidRule)
+}
+
+func (r *Rule) parseByDay(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRule, item)
+		}
+		wd, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRule, item)
+		}
+
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			v, err := strconv.Atoi(prefix)
+			if err != nil || v == 0 || v < -53 || v > 53 {
+				return fmt.Errorf("%w: invalid BYDAY %s", ErrInvalidRule, item)
+			}
+			n = v
+		}
+		r.ByDay = append(r.ByDay, WeekdayNum{Weekday: wd, N: n})
+	}
+	return nil
+}
+
+// String は正規化した RRULE 文字列を返す
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			code := weekdayCode(d.Weekday)
+			if d.N != 0 {
+				code = strconv.Itoa(d.N) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+func weekdayCode(wd time.Weekday) string {
+	for code, v := range weekdayCodes {
+		if v == wd {
+			return code
+		}
+	}
+	return ""
+}
+
+// Each は dtstart（タイムゾーン付き）から順に発生日時を yield に渡す。
+// yield が false を返すか、COUNT / UNTIL に達したら終わる。
+// dtstart 自体もルールに合っていれば最初の発生日時になる。
+func (r *Rule) Each(dtstart time.Time, yield func(time.Time) bool) {
+	loc := dtstart.Location()
+	hour, min, sec := dtstart.Clock()
+	startDate := dateOf(dtstart)
+
+	count := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, day := range r.candidates(startDate, period) {
+			if day.Before(startDate) {
+				continue
+			}
+			t := localTime(day, hour, min, sec, dtstart.Nanosecond(), loc)
+			if t.Before(dtstart) {
+				continue
+			}
+			if r.pastUntil(t, day) {
+				return
+			}
+
+			count++
+			if !yield(t) {
+				return
+			}
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// Next は after より後の最初の発生日時を返す
+func (r *Rule) Next(dtstart, after time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.Each(dtstart, func(t time.Time) bool {
+		if t.After(after) {
+			next, found = t, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// Take は from 以降の発生日時を最大 n 件返す
+func (r *Rule) Take(dtstart, from time.Time, n int) []time.Time {
+	var result []time.Time
+	if n <= 0 {
+		return result
+	}
+	r.Each(dtstart, func(t time.Time) bool {
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return len(result) < n
+	})
+	return result
+}
+
+func (r *Rule) pastUntil(t time.Time, day time.Time) bool {
+	if r.Until == nil {
+		return false
+	}
+	if r.UntilDate {
+		return day.After(*r.Until)
+	}
+	return t.After(*r.Until)
+}
+
+// candidates は period 番目の期間（日・週・月・年）に入る候補日を昇順で返す。
+// 日付は UTC 0 時で表す（DST の影響を受けない暦の計算用）
+func (r *Rule) candidates(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		day := start.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.hasWeekday(day.Weekday()) {
+			return nil
+		}
+		return []time.Time{day}
+
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := start.AddDate(0, 0, -offset+step*7)
+		var days []time.Time
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if (len(r.ByDay) == 0 && day.Weekday() == start.Weekday()) || r.hasWeekday(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+		return days
+
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		last := first.AddDate(0, 1, -1)
+		if len(r.ByDay) > 0 {
+			return r.expandByDay(first, last)
+		}
+		if start.Day() > last.Day() {
+			return nil // 31 日がない月などは飛ばす
+		}
+		return []time.Time{first.AddDate(0, 0, start.Day()-1)}
+
+	case Yearly:
+		year := start.Year() + step
+		if len(r.ByDay) > 0 {
+			first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			return r.expandByDay(first, time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC))
+		}
+		day := time.Date(year, start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Month() != start.Month() {
+			return nil // 2/29 はうるう年だけ
+		}
+		return []time.Time{day}
+	}
+	return nil
+}
+
+func (r *Rule) hasWeekday(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// expandByDay は [first, last] の範囲で BYDAY に合う日を返す
+func (r *Rule) expandByDay(first, last time.Time) []time.Time {
+	seen := map[time.Time]bool{}
+	var days []time.Time
+	add := func(day time.Time) {
+		if !day.Before(first) && !day.After(last) && !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	for _, d := range r.ByDay {
+		firstMatch := first.AddDate(0, 0, (int(d.Weekday)-int(first.Weekday())+7)%7)
+		lastMatch := last.AddDate(0, 0, -((int(last.Weekday()) - int(d.Weekday) + 7) % 7))
+		switch {
+		case d.N > 0:
+			add(firstMatch.AddDate(0, 0, (d.N-1)*7))
+		case d.N < 0:
+			add(lastMatch.AddDate(0, 0, (d.N+1)*7))
+		default:
+			for day := firstMatch; !day.After(last); day = day.AddDate(0, 0, 7) {
+				add(day)
+			}
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// dateOf は t のタイムゾーンでの日付を UTC 0 時で返す
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// localTime は day の日付に loc での時刻を合わせる。
+// DST で存在しない時刻は切り替え前のオフセットで解釈し（例: 2:30 → 3:30）、
+// 2 回ある時刻は早い方を使う（RFC 5545 3.3.5）
+func localTime(day time.Time, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, nsec, loc)
+
+	// 存在しない時刻（time.Date の結果は未規定なので自前で解決する）
+	if t.Hour() != hour || t.Minute() != min {
+		wall := time.Date(day.Year(), day.Month(), day.Day(), hour, min, sec, nsec, time.UTC)
+		_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+		return wall.Add(-time.Duration(before) * time.Second).In(loc)
+	}
+
+	// 1 時間前が同じ壁時計の時刻なら、それが 1 回目
+	if earlier := t.Add(-time.Hour); earlier.Hour() == hour && earlier.Minute() == min && earlier.Day() == day.Day() {
+		return earlier
+	}
+	return t
+}
@@ -0,0 +1,503 @@
+package recurrence_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/a5415091-collab/go-gin-todo-app/recurrence"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", name, err)
+	}
+	return loc
+}
+
+// 発生日時を "2006-01-02 15:04 MST" の形で並べる（壁時計とオフセットの両方を確認する）
+func occurrences(t *testing.T, rrule string, dtstart time.Time, n int) []string {
+	t.Helper()
+
+	rule, err := recurrence.Parse(rrule)
+	if err != nil {
+		t.Fatalf("parse %q: %v", rrule, err)
+	}
+
+	var result []string
+	for _, o := range rule.Take(dtstart, dtstart, n) {
+		result = append(result, o.Format("2006-01-02 15:04 -0700"))
+	}
+	return result
+}
+
+func assertOccurrences(t *testing.T, got, expected []string) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d occurrences, got %d:\n got:  %v\n want: %v", len(expected), len(got), got, expected)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("occurrence %d mismatch: expected %s, got %s", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		rrule     string
+		expectErr bool
+		expectStr string
+	}{
+		{name: "daily", rrule: "FREQ=DAILY", expectStr: "FREQ=DAILY"},
+		{name: "rrule prefix and lower case", rrule: "RRULE:freq=weekly;byday=mo,we", expectStr: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{name: "interval and count", rrule: "FREQ=MONTHLY;INTERVAL=2;COUNT=5", expectStr: "FREQ=MONTHLY;INTERVAL=2;COUNT=5"},
+		{name: "ordinal byday", rrule: "FREQ=MONTHLY;BYDAY=-1FR,+2MO", expectStr: "FREQ=MONTHLY;BYDAY=-1FR,2MO"},
+		{name: "until datetime", rrule: "FREQ=DAILY;UNTIL=20261231T235959Z", expectStr: "FREQ=DAILY;UNTIL=20261231T235959Z"},
+		{name: "until date", rrule: "FREQ=YEARLY;UNTIL=20301231", expectStr: "FREQ=YEARLY;UNTIL=20301231"},
+		{name: "wkst", rrule: "FREQ=WEEKLY;WKST=SU;INTERVAL=2", expectStr: "FREQ=WEEKLY;INTERVAL=2;WKST=SU"},
+		{name: "empty", rrule: "", expectErr: true},
+		{name: "missing freq", rrule: "COUNT=3", expectErr: true},
+		{name: "unsupported freq", rrule: "FREQ=HOURLY", expectErr: true},
+		{name: "unsupported part", rrule: "FREQ=MONTHLY;BYMONTHDAY=15", expectErr: true},
+		{name: "count and until", rrule: "FREQ=DAILY;COUNT=3;UNTIL=20261231", expectErr: true},
+		{name: "zero interval", rrule: "FREQ=DAILY;INTERVAL=0", expectErr: true},
+		{name: "ordinal with weekly", rrule: "FREQ=WEEKLY;BYDAY=1MO", expectErr: true},
+		{name: "invalid weekday", rrule: "FREQ=WEEKLY;BYDAY=XX", expectErr: true},
+		{name: "invalid until", rrule: "FREQ=DAILY;UNTIL=tomorrow", expectErr: true},
+		{name: "duplicate key", rrule: "FREQ=DAILY;FREQ=WEEKLY", expectErr: true},
+		{name: "malformed part", rrule: "FREQ=DAILY;COUNT", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			rule, err := recurrence.Parse(tt.rrule)
+
+			if tt.expectErr {
+				if !errors.Is(err, recurrence.ErrInvalidRule) {
+					t.Errorf("expected ErrInvalidRule, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rule.String() != tt.expectStr {
+				t.Errorf("string mismatch: expected %s, got %s", tt.expectStr, rule.String())
+			}
+		})
+	}
+}
+
+func TestRule_Frequencies(t *testing.T) {
+
+	utc := time.UTC
+
+	tests := []struct {
+		name     string
+		rrule    string
+		dtstart  time.Time
+		n        int
+		expected []string
+	}{
+		{
+			name:    "daily with interval",
+			rrule:   "FREQ=DAILY;INTERVAL=3",
+			dtstart: time.Date(2026, 12, 30, 8, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2026-12-30 08:00 +0000",
+				"2027-01-02 08:00 +0000",
+				"2027-01-05 08:00 +0000",
+			},
+		},
+		{
+			name:    "daily limited to weekdays",
+			rrule:   "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart: time.Date(2026, 10, 16, 9, 0, 0, 0, utc), // 金曜
+			n:       3,
+			expected: []string{
+				"2026-10-16 09:00 +0000",
+				"2026-10-19 09:00 +0000",
+				"2026-10-20 09:00 +0000",
+			},
+		},
+		{
+			name:    "weekly defaults to dtstart weekday",
+			rrule:   "FREQ=WEEKLY;COUNT=3",
+			dtstart: time.Date(2026, 10, 14, 18, 30, 0, 0, utc), // 水曜
+			n:       10,
+			expected: []string{
+				"2026-10-14 18:30 +0000",
+				"2026-10-21 18:30 +0000",
+				"2026-10-28 18:30 +0000",
+			},
+		},
+		{
+			name:    "weekly byday skips days before dtstart",
+			rrule:   "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			dtstart: time.Date(2026, 10, 14, 7, 0, 0, 0, utc), // 水曜
+			n:       4,
+			expected: []string{
+				"2026-10-14 07:00 +0000",
+				"2026-10-16 07:00 +0000",
+				"2026-10-19 07:00 +0000",
+				"2026-10-21 07:00 +0000",
+			},
+		},
+		{
+			name:    "biweekly with sunday week start",
+			rrule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,SA;WKST=SU",
+			dtstart: time.Date(2026, 10, 18, 10, 0, 0, 0, utc), // 日曜
+			n:       4,
+			expected: []string{
+				"2026-10-18 10:00 +0000",
+				"2026-10-24 10:00 +0000",
+				"2026-11-01 10:00 +0000",
+				"2026-11-07 10:00 +0000",
+			},
+		},
+		{
+			name:    "monthly first monday",
+			rrule:   "FREQ=MONTHLY;BYDAY=1MO",
+			dtstart: time.Date(2026, 10, 5, 9, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2026-10-05 09:00 +0000",
+				"2026-11-02 09:00 +0000",
+				"2026-12-07 09:00 +0000",
+			},
+		},
+		{
+			name:    "monthly every tuesday",
+			rrule:   "FREQ=MONTHLY;BYDAY=TU;COUNT=6",
+			dtstart: time.Date(2026, 10, 20, 9, 0, 0, 0, utc),
+			n:       10,
+			expected: []string{
+				"2026-10-20 09:00 +0000",
+				"2026-10-27 09:00 +0000",
+				"2026-11-03 09:00 +0000",
+				"2026-11-10 09:00 +0000",
+				"2026-11-17 09:00 +0000",
+				"2026-11-24 09:00 +0000",
+			},
+		},
+		{
+			name:    "yearly",
+			rrule:   "FREQ=YEARLY;INTERVAL=2",
+			dtstart: time.Date(2026, 4, 1, 12, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2026-04-01 12:00 +0000",
+				"2028-04-01 12:00 +0000",
+				"2030-04-01 12:00 +0000",
+			},
+		},
+		{
+			name:    "yearly last sunday of the year",
+			rrule:   "FREQ=YEARLY;BYDAY=-1SU",
+			dtstart: time.Date(2026, 12, 27, 0, 0, 0, 0, utc),
+			n:       2,
+			expected: []string{
+				"2026-12-27 00:00 +0000",
+				"2027-12-26 00:00 +0000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertOccurrences(t, occurrences(t, tt.rrule, tt.dtstart, tt.n), tt.expected)
+		})
+	}
+}
+
+// 月末・うるう年: 存在しない日付は繰り上げずに飛ばす（RFC 5545）
+func TestRule_MonthEnd(t *testing.T) {
+
+	utc := time.UTC
+
+	tests := []struct {
+		name     string
+		rrule    string
+		dtstart  time.Time
+		n        int
+		expected []string
+	}{
+		{
+			name:    "monthly on the 31st skips short months",
+			rrule:   "FREQ=MONTHLY",
+			dtstart: time.Date(2026, 1, 31, 9, 0, 0, 0, utc),
+			n:       5,
+			expected: []string{
+				"2026-01-31 09:00 +0000",
+				"2026-03-31 09:00 +0000",
+				"2026-05-31 09:00 +0000",
+				"2026-07-31 09:00 +0000",
+				"2026-08-31 09:00 +0000",
+			},
+		},
+		{
+			name:    "monthly on the 30th skips february",
+			rrule:   "FREQ=MONTHLY;COUNT=3",
+			dtstart: time.Date(2027, 1, 30, 9, 0, 0, 0, utc),
+			n:       10,
+			expected: []string{
+				"2027-01-30 09:00 +0000",
+				"2027-03-30 09:00 +0000",
+				"2027-04-30 09:00 +0000",
+			},
+		},
+		{
+			name:    "monthly on the 29th includes leap february",
+			rrule:   "FREQ=MONTHLY",
+			dtstart: time.Date(2028, 1, 29, 9, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2028-01-29 09:00 +0000",
+				"2028-02-29 09:00 +0000",
+				"2028-03-29 09:00 +0000",
+			},
+		},
+		{
+			name:    "last friday of month",
+			rrule:   "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: time.Date(2026, 1, 30, 17, 0, 0, 0, utc),
+			n:       4,
+			expected: []string{
+				"2026-01-30 17:00 +0000",
+				"2026-02-27 17:00 +0000",
+				"2026-03-27 17:00 +0000",
+				"2026-04-24 17:00 +0000",
+			},
+		},
+		{
+			name:    "fifth monday only in months that have one",
+			rrule:   "FREQ=MONTHLY;BYDAY=5MO",
+			dtstart: time.Date(2026, 3, 30, 9, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2026-03-30 09:00 +0000",
+				"2026-06-29 09:00 +0000",
+				"2026-08-31 09:00 +0000",
+			},
+		},
+		{
+			name:    "yearly on feb 29 only in leap years",
+			rrule:   "FREQ=YEARLY",
+			dtstart: time.Date(2028, 2, 29, 0, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2028-02-29 00:00 +0000",
+				"2032-02-29 00:00 +0000",
+				"2036-02-29 00:00 +0000",
+			},
+		},
+		{
+			name:    "year boundary",
+			rrule:   "FREQ=MONTHLY;INTERVAL=5",
+			dtstart: time.Date(2026, 10, 15, 9, 0, 0, 0, utc),
+			n:       3,
+			expected: []string{
+				"2026-10-15 09:00 +0000",
+				"2027-03-15 09:00 +0000",
+				"2027-08-15 09:00 +0000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertOccurrences(t, occurrences(t, tt.rrule, tt.dtstart, tt.n), tt.expected)
+		})
+	}
+}
+
+// DST: 壁時計の時刻を保ち、存在しない時刻はずらし、2 回ある時刻は 1 回目を使う
+func TestRule_DST(t *testing.T) {
+
+	newYork := mustLoad(t, "America/New_York")
+	london := mustLoad(t, "Europe/London")
+	sydney := mustLoad(t, "Australia/Sydney")
+
+	tests := []struct {
+		name     string
+		rrule    string
+		dtstart  time.Time
+		n        int
+		expected []string
+	}{
+		{
+			name:    "daily 9am across spring forward",
+			rrule:   "FREQ=DAILY",
+			dtstart: time.Date(2026, 3, 7, 9, 0, 0, 0, newYork),
+			n:       3,
+			expected: []string{
+				"2026-03-07 09:00 -0500",
+				"2026-03-08 09:00 -0400",
+				"2026-03-09 09:00 -0400",
+			},
+		},
+		{
+			name:    "daily 9am across fall back",
+			rrule:   "FREQ=DAILY",
+			dtstart: time.Date(2026, 10, 31, 9, 0, 0, 0, newYork),
+			n:       3,
+			expected: []string{
+				"2026-10-31 09:00 -0400",
+				"2026-11-01 09:00 -0500",
+				"2026-11-02 09:00 -0500",
+			},
+		},
+		{
+			name:    "nonexistent 2:30 is shifted forward",
+			rrule:   "FREQ=DAILY",
+			dtstart: time.Date(2026, 3, 7, 2, 30, 0, 0, newYork),
+			n:       3,
+			expected: []string{
+				"2026-03-07 02:30 -0500",
+				"2026-03-08 03:30 -0400",
+				"2026-03-09 02:30 -0400",
+			},
+		},
+		{
+			name:    "ambiguous 1:30 uses the first occurrence",
+			rrule:   "FREQ=DAILY",
+			dtstart: time.Date(2026, 10, 31, 1, 30, 0, 0, newYork),
+			n:       3,
+			expected: []string{
+				"2026-10-31 01:30 -0400",
+				"2026-11-01 01:30 -0400",
+				"2026-11-02 01:30 -0500",
+			},
+		},
+		{
+			name:    "weekly across european dst change",
+			rrule:   "FREQ=WEEKLY;COUNT=3",
+			dtstart: time.Date(2026, 3, 22, 8, 0, 0, 0, london),
+			n:       10,
+			expected: []string{
+				"2026-03-22 08:00 +0000",
+				"2026-03-29 08:00 +0100",
+				"2026-04-05 08:00 +0100",
+			},
+		},
+		{
+			name:    "monthly across southern hemisphere dst end",
+			rrule:   "FREQ=MONTHLY",
+			dtstart: time.Date(2026, 3, 5, 9, 0, 0, 0, sydney),
+			n:       2,
+			expected: []string{
+				"2026-03-05 09:00 +1100",
+				"2026-04-05 09:00 +1000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertOccurrences(t, occurrences(t, tt.rrule, tt.dtstart, tt.n), tt.expected)
+		})
+	}
+}
+
+func TestRule_Until(t *testing.T) {
+
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name     string
+		rrule    string
+		dtstart  time.Time
+		expected []string
+	}{
+		{
+			name:    "until datetime is inclusive",
+			rrule:   "FREQ=DAILY;UNTIL=20261012T130000Z",
+			dtstart: time.Date(2026, 10, 10, 9, 0, 0, 0, newYork), // 13:00Z
+			expected: []string{
+				"2026-10-10 09:00 -0400",
+				"2026-10-11 09:00 -0400",
+				"2026-10-12 09:00 -0400",
+			},
+		},
+		{
+			name:    "until date includes the whole day",
+			rrule:   "FREQ=WEEKLY;UNTIL=20261024",
+			dtstart: time.Date(2026, 10, 10, 23, 0, 0, 0, newYork),
+			expected: []string{
+				"2026-10-10 23:00 -0400",
+				"2026-10-17 23:00 -0400",
+				"2026-10-24 23:00 -0400",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertOccurrences(t, occurrences(t, tt.rrule, tt.dtstart, 100), tt.expected)
+		})
+	}
+}
+
+func TestRule_Next(t *testing.T) {
+
+	rule, _ := recurrence.Parse("FREQ=WEEKLY;BYDAY=TU,TH;COUNT=4")
+	dtstart := time.Date(2026, 10, 13, 9, 0, 0, 0, time.UTC) // 火曜
+
+	tests := []struct {
+		name        string
+		after       time.Time
+		expectFound bool
+		expectNext  time.Time
+	}{
+		{
+			name:        "next after dtstart",
+			after:       dtstart,
+			expectFound: true,
+			expectNext:  time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "between occurrences",
+			after:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			expectFound: true,
+			expectNext:  time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "count exhausted",
+			after:       time.Date(2026, 10, 22, 9, 0, 0, 0, time.UTC),
+			expectFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			next, found := rule.Next(dtstart, tt.after)
+
+			if found != tt.expectFound {
+				t.Fatalf("expected found=%v, got %v", tt.expectFound, found)
+			}
+			if found && !next.Equal(tt.expectNext) {
+				t.Errorf("expected %s, got %s", tt.expectNext, next)
+			}
+		})
+	}
+}
+
+// ルールに合わない DTSTART は発生日時に含めない
+func TestRule_UnsyncedDTStart(t *testing.T) {
+	got := occurrences(t, "FREQ=WEEKLY;BYDAY=MO", time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), 2)
+	assertOccurrences(t, got, []string{
+		"2026-10-19 09:00 +0000",
+		"2026-10-26 09:00 +0000",
+	})
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrIdempotencyKeyExists は同じユーザーの同じキーが既に保存されている
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
+type IdempotencyRepository interface {
+	Create(record *model.IdempotencyKey) error
+	Find(userID uint, key string) (*model.IdempotencyKey, error)
+	Complete(record *model.IdempotencyKey) error
+	Delete(id uint) error
+	DeleteBefore(before time.Time) (int64, error)
+	WithContext(ctx context.Context) IdempotencyRepository
+}
+
+// idempotencyRepository は tx が nil なら db.DB を使う（WithContext の後は ctx 付きの DB）
+type idempotencyRepository struct {
+	tx *gorm.DB
+}
+
+func NewIdempotencyRepository() IdempotencyRepository {
+	return &idempotencyRepository{}
+}
+
+func (r *idempotencyRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す
+func (r *idempotencyRepository) WithContext(ctx context.Context) IdempotencyRepository {
+	return &idempotencyRepository{tx: r.conn().WithContext(ctx)}
+}
+
+// Create はキーを処理中として保存する。既にあれば ErrIdempotencyKeyExists を返す
+// （同時に届いた再送のうち 1 つだけが保存できる）
+func (r *idempotencyRepository) Create(record *model.IdempotencyKey) error {
+	result := r.conn().Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotencyKeyExists
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Find(userID uint, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	err := r.conn().Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete は処理が終わったリクエストの応答を保存する
+func (r *idempotencyRepository) Complete(record *model.IdempotencyKey) error {
+	return r.conn().Model(record).Select("status_code", "content_type", "response_headers", "response_body", "updated_at").Updates(record).Error
+}
+
+func (r *idempotencyRepository) Delete(id uint) error {
+	return r.conn().Delete(&model.IdempotencyKey{}, id).Error
+}
+
+// DeleteBefore は before より前に保存したキーを全ユーザー分削除する
+func (r *idempotencyRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.conn().Where("created_at < ?", formatTime(before)).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
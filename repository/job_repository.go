@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrDuplicateJob は同じ unique_key の未完了のジョブが既にある
+	ErrDuplicateJob = errors.New("job with the same unique key is already queued")
+	// ErrLeaseLost はリースの期限が切れて、ジョブを別のワーカーが取った
+	ErrLeaseLost = errors.New("job lease was lost")
+)
+
+type JobRepository interface {
+	Create(job *model.Job) error
+	Lease(now time.Time, until time.Time, token string) (*model.Job, error)
+	Extend(id uint, token string, until time.Time) error
+	Complete(id uint, token string, at time.Time) error
+	Retry(id uint, token string, runAt time.Time, lastError string) error
+	Dead(id uint, token string, at time.Time, lastError string) error
+	List(status string, typ string, limit int) ([]model.Job, error)
+	CountByStatus() (map[string]int64, error)
+	OldestDue(now time.Time) (*model.Job, error)
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+type jobRepository struct{}
+
+func NewJobRepository() JobRepository {
+	return &jobRepository{}
+}
+
+// Create はジョブを追加する。UniqueKey が同じ未完了のジョブがあれば ErrDuplicateJob を返す
+func (r *jobRepository) Create(job *model.Job) error {
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicateJob
+	}
+	return nil
+}
+
+// leaseExpiredError は、リースの期限が切れたまま実行する回数の上限を使い切ったジョブの last_error
+const leaseExpiredError = "lease expired too many times"
+
+// Lease は実行できるジョブを 1 つ実行中にして返す（なければ nil）。
+// 実行待ちで RunAt を過ぎたものか、実行中でリースの期限が切れたもの（ワーカーが落ちた）を古い順に取る。
+// リースの期限が切れたジョブのうち、もう MaxAttempts 回実行したものはやり直さずに先に dead-letter にする。
+// 1 つの UPDATE で取るので、複数のワーカーが同じジョブを取ることはない
+func (r *jobRepository) Lease(now time.Time, until time.Time, token string) (*model.Job, error) {
+	var job model.Job
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE jobs SET status = ?, leased_until = NULL, last_error = ?, finished_at = ?, updated_at = ?
+			WHERE status = ? AND leased_until <= ? AND attempts >= max_attempts`,
+			model.JobDead, leaseExpiredError, formatTime(now), formatTime(now),
+			model.JobRunning, formatTime(now),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Raw(`
+			UPDATE jobs SET status = ?, attempts = attempts + 1, leased_until = ?, lease_token = ?, updated_at = ?
+			WHERE id = (
+				SELECT id FROM jobs
+				WHERE (status = ? AND run_at <= ?) OR (status = ? AND leased_until <= ? AND attempts < max_attempts)
+				ORDER BY run_at, id LIMIT 1
+			)
+			RETURNING *`,
+			model.JobRunning, formatTime(until), token, formatTime(now),
+			model.JobPending, formatTime(now), model.JobRunning, formatTime(now),
+		).Scan(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if job.ID == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// Extend は実行中のジョブのリースを延ばす
+func (r *jobRepository) Extend(id uint, token string, until time.Time) error {
+	return r.finish(id, token, map[string]any{"leased_until": until})
+}
+
+func (r *jobRepository) Complete(id uint, token string, at time.Time) error {
+	return r.finish(id, token, map[string]any{
+		"status": model.JobSucceeded, "leased_until": nil, "last_error": "", "finished_at": at,
+	})
+}
+
+// Retry は失敗したジョブを runAt に実行し直す
+func (r *jobRepository) Retry(id uint, token string, runAt time.Time, lastError string) error {
+	return r.finish(id, token, map[string]any{
+		"status": model.JobPending, "run_at": runAt, "leased_until": nil, "last_error": lastError,
+	})
+}
+
+// Dead はジョブを再試行せずに dead-letter にする
+func (r *jobRepository) Dead(id uint, token string, at time.Time, lastError string) error {
+	return r.finish(id, token, map[string]any{
+		"status": model.JobDead, "leased_until": nil, "last_error": lastError, "finished_at": at,
+	})
+}
+
+// finish は token のワーカーが実行中のジョブだけを更新する（リースを失っていたら ErrLeaseLost）
+func (r *jobRepository) finish(id uint, token string, values map[string]any) error {
+	result := db.DB.Model(&model.Job{}).
+		Where("id = ? AND status = ? AND lease_token = ?", id, model.JobRunning, token).
+		Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// List は新しい順に返す。status / typ が空なら絞らない
+func (r *jobRepository) List(status string, typ string, limit int) ([]model.Job, error) {
+	query := db.DB.Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if typ != "" {
+		query = query.Where("type = ?", typ)
+	}
+	var jobs []model.Job
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *jobRepository) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := db.DB.Model(&model.Job{}).Select("status, count(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// OldestDue は RunAt を過ぎても実行されていない、一番古いジョブを返す（なければ nil）
+func (r *jobRepository) OldestDue(now time.Time) (*model.Job, error) {
+	var jobs []model.Job
+	err := db.DB.Where("status = ? AND run_at <= ?", model.JobPending, formatTime(now)).
+		Order("run_at").Limit(1).Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// DeleteFinishedBefore は before より前に終わった（成功・dead-letter）ジョブを削除する
+func (r *jobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := db.DB.Where("status IN ? AND finished_at < ?", []string{model.JobSucceeded, model.JobDead}, formatTime(before)).
+		Delete(&model.Job{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+)
+
+// プロジェクト削除時の Todo の扱い
+const (
+	ProjectTodosUnassign = "unassign" // 未分類に戻す
+	ProjectTodosDelete   = "delete"   // 一緒に削除する
+)
+
+type ProjectRepository interface {
+	FindAll(userID uint, includeArchived bool) ([]model.Project, error)
+	FindByID(userID uint, id uint) (*model.Project, error)
+	Create(project *model.Project) (*model.Project, error)
+	Update(project *model.Project) (*model.Project, error)
+	Move(project *model.Project, position int) error
+	Archive(project *model.Project, completeTodos bool) error
+	Unarchive(project *model.Project) error
+	Delete(project *model.Project, todos string) error
+	WithContext(ctx context.Context) ProjectRepository
+}
+
+// projectRepository は tx が nil なら db.DB を使う（WithContext の後は ctx 付きの DB）
+type projectRepository struct {
+	tx *gorm.DB
+}
+
+func NewProjectRepository() ProjectRepository {
+	return &projectRepository{}
+}
+
+func (r *projectRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す
+func (r *projectRepository) WithContext(ctx context.Context) ProjectRepository {
+	return &projectRepository{tx: r.conn().WithContext(ctx)}
+}
+
+func (r *projectRepository) FindAll(userID uint, includeArchived bool) ([]model.Project, error) {
+	tx := r.conn().Where("user_id = ?", userID)
+	if !includeArchived {
+		tx = tx.Where("archived_at IS NULL")
+	}
+
+	var projects []model.Project
+	err := tx.Order("position, id").Find(&projects).Error
+	return projects, err
+}
+
+func (r *projectRepository) FindByID(userID uint, id uint) (*model.Project, error) {
+	var project model.Project
+	err := r.conn().Where("id = ? AND user_id = ?", id, userID).First(&project).Error
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// Create は末尾の位置に追加する
+func (r *projectRepository) Create(project *model.Project) (*model.Project, error) {
+	err := r.conn().Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Project{}).Where("user_id = ?", project.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		project.Position = int(count)
+		return tx.Create(project).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Update は名前と色だけを保存する（位置は Move、アーカイブは Archive で変える）
+func (r *projectRepository) Update(project *model.Project) (*model.Project, error) {
+	result := r.conn().Model(project).
+		Where("user_id = ?", project.UserID).
+		Select("name", "color").
+		Updates(project)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return project, nil
+}
+
+// Move は project を position に移し、間のプロジェクトを 1 つずつずらす
+func (r *projectRepository) Move(project *model.Project, position int) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Project{}).Where("user_id = ?", project.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		position = max(0, min(position, int(count)-1))
+
+		from := project.Position
+		others := tx.Model(&model.Project{}).Where("user_id = ? AND id <> ?", project.UserID, project.ID)
+		var err error
+		switch {
+		case position < from:
+			err = others.Where("position >= ? AND position < ?", position, from).
+				Update("position", gorm.Expr("position + 1")).Error
+		case position > from:
+			err = others.Where("position > ? AND position <= ?", from, position).
+				Update("position", gorm.Expr("position - 1")).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		project.Position = position
+		return tx.Model(project).Update("position", position).Error
+	})
+}
+
+// Archive はプロジェクトをアーカイブする。
+// completeTodos なら未完了の Todo を完了にする（繰り返しもそこで終える）
+func (r *projectRepository) Archive(project *model.Project, completeTodos bool) error {
+	now := time.Now()
+
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		if completeTodos {
+			err := tx.Model(&model.Todo{}).
+				Where("user_id = ? AND project_id = ? AND done = ?", project.UserID, project.ID, false).
+				Updates(map[string]any{
+					"done":             true,
+					"completed_at":     now,
+					"rrule":            "",
+					"recurrence_tz":    "",
+					"recurrence_start": nil,
+					"version":          gorm.Expr("version + 1"),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		project.ArchivedAt = &now
+		return tx.Model(project).Update("archived_at", now).Error
+	})
+}
+
+func (r *projectRepository) Unarchive(project *model.Project) error {
+	project.ArchivedAt = nil
+	return r.conn().Model(project).Update("archived_at", nil).Error
+}
+
+// Delete はプロジェクトを削除し、todos（ProjectTodos*）に従って Todo を未分類に戻すか削除する。
+// 後ろのプロジェクトの位置は 1 つずつ詰める
+func (r *projectRepository) Delete(project *model.Project, todos string) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		projectTodos := tx.Where("user_id = ? AND project_id = ?", project.UserID, project.ID)
+
+		var err error
+		if todos == ProjectTodosDelete {
+			err = projectTodos.Delete(&model.Todo{}).Error
+		} else {
+			err = projectTodos.Model(&model.Todo{}).
+				Updates(map[string]any{"project_id": nil, "version": gorm.Expr("version + 1")}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(project).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Project{}).
+			Where("user_id = ? AND position > ?", project.UserID, project.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+	})
+}
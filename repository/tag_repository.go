@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository interface {
+	FindAll(userID uint) ([]model.Tag, error)
+	FindByID(userID uint, id uint) (*model.Tag, error)
+	FindByName(userID uint, name string) (*model.Tag, error)
+	Create(tag *model.Tag) (*model.Tag, error)
+	Update(tag *model.Tag) (*model.Tag, error)
+	Merge(from *model.Tag, into *model.Tag) error
+	Delete(tag *model.Tag) error
+	Attach(todoID uint, tagID uint) error
+	Detach(todoID uint, tagID uint) error
+	WithContext(ctx context.Context) TagRepository
+}
+
+// tagRepository は tx が nil なら db.DB を使う（WithContext の後は ctx 付きの DB）
+type tagRepository struct {
+	tx *gorm.DB
+}
+
+func NewTagRepository() TagRepository {
+	return &tagRepository{}
+}
+
+func (r *tagRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す
+func (r *tagRepository) WithContext(ctx context.Context) TagRepository {
+	return &tagRepository{tx: r.conn().WithContext(ctx)}
+}
+
+func (r *tagRepository) FindAll(userID uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	err := r.conn().Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *tagRepository) FindByID(userID uint, id uint) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.conn().Where("id = ? AND user_id = ?", id, userID).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindByName は大文字小文字を区別せずに探す（tags.name は COLLATE NOCASE）
+func (r *tagRepository) FindByName(userID uint, name string) (*model.Tag, error) {
+	var tag model.Tag
+	err := r.conn().Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *tagRepository) Create(tag *model.Tag) (*model.Tag, error) {
+	if err := r.conn().Create(tag).Error; err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (r *tagRepository) Update(tag *model.Tag) (*model.Tag, error) {
+	err := r.conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(tag).Where("user_id = ?", tag.UserID).Update("name", tag.Name).Error; err != nil {
+			return err
+		}
+		return touchTodos(tx, todosWithTag(tx, tag.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Merge は from の付いた todo に into を付け替えて from を削除する（両方付いていれば 1 つにまとめる）
+func (r *tagRepository) Merge(from *model.Tag, into *model.Tag) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"INSERT OR IGNORE INTO todo_tags (todo_id, tag_id) SELECT todo_id, ? FROM todo_tags WHERE tag_id = ?",
+			into.ID, from.ID,
+		).Error
+		if err != nil {
+			return err
+		}
+		return deleteTag(tx, from)
+	})
+}
+
+// Delete はタグと、todo との関連を削除する（todo 自体は残る）
+func (r *tagRepository) Delete(tag *model.Tag) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		return deleteTag(tx, tag)
+	})
+}
+
+func deleteTag(tx *gorm.DB, tag *model.Tag) error {
+	if err := touchTodos(tx, todosWithTag(tx, tag.ID)); err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM todo_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(tag).Error
+}
+
+// Attach は既に付いていても成功する
+func (r *tagRepository) Attach(todoID uint, tagID uint) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		result := tx.Table("todo_tags").
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(map[string]any{"todo_id": todoID, "tag_id": tagID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return touchTodos(tx, []uint{todoID})
+	})
+}
+
+func (r *tagRepository) Detach(todoID uint, tagID uint) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec("DELETE FROM todo_tags WHERE todo_id = ? AND tag_id = ?", todoID, tagID)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return touchTodos(tx, []uint{todoID})
+	})
+}
+
+func todosWithTag(tx *gorm.DB, tagID uint) *gorm.DB {
+	return tx.Table("todo_tags").Select("todo_id").Where("tag_id = ?", tagID)
+}
+
+// touchTodos は Tags が変わった todo の Version を増やす（ETag を変えるため）
+func touchTodos(tx *gorm.DB, todoIDs any) error {
+	return tx.Model(&model.Todo{}).
+		Where("id IN (?)", todoIDs).
+		Update("version", gorm.Expr("version + 1")).Error
+}
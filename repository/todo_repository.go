@@ -1,33 +1,399 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/a5415091-collab/go-gin-todo-app/db"
 	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTodoModified は読み込んでから保存するまでの間に他のリクエストで更新された
+	ErrTodoModified = errors.New("todo was modified by another request")
+	// ErrTodoNotFound は対象の todo がない（他のユーザーの todo・削除済みを含む）
+	ErrTodoNotFound = errors.New("todo not found")
 )
 
+// 一覧の並び替えに使える列（SQL に埋め込むのでホワイトリスト）
+const (
+	TodoSortCreatedAt = "created_at"
+	TodoSortUpdatedAt = "updated_at"
+	TodoSortTitle     = "title"
+)
+
+// TodoQuery は一覧取得の条件（keyset ページング・絞り込み・並び替え）
+type TodoQuery struct {
+	ProjectID   *uint
+	Done        *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Title       string   // 部分一致
+	TagsAny     []string // いずれかのタグが付いている（タグ名、大文字小文字は区別しない）
+	TagsAll     []string // 全てのタグが付いている
+	TagsNone    []string // どのタグも付いていない
+
+	SortBy string // TodoSort*
+	Desc   bool
+	Limit  int
+	After  *TodoCursor // この行の次から取得する
+}
+
+// TodoCursor は keyset ページングの位置（並び替え列の値 + id）
+type TodoCursor struct {
+	Value string
+	ID    uint
+}
+
+// NewTodoCursor は todo の位置を表すカーソルを返す
+func NewTodoCursor(todo *model.Todo, sortBy string) TodoCursor {
+	var value string
+	switch sortBy {
+	case TodoSortUpdatedAt:
+		value = todo.UpdatedAt.Format(db.TimeFormat)
+	case TodoSortTitle:
+		value = todo.Title
+	default:
+		value = todo.CreatedAt.Format(db.TimeFormat)
+	}
+	return TodoCursor{Value: value, ID: todo.ID}
+}
+
+// DueWindow は期日での絞り込み範囲（いずれも [From, To)、nil は制限なし）。
+// 時刻指定の期日と終日の期日（UTC 0 時）は別々の範囲で比較する
+type DueWindow struct {
+	TimedFrom *time.Time
+	TimedTo   *time.Time
+	DateFrom  *time.Time
+	DateTo    *time.Time
+}
+
+// 検索結果のハイライト位置を示すマーカー（service で HTML エスケープ後に置き換える）
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchTerm は検索語 1 つ分。フレーズ以外は前方一致で検索する
+type SearchTerm struct {
+	Text   string
+	Phrase bool
+}
+
+// TodoSearchHit は検索結果 1 件（Score は大きいほど関連度が高い）
+type TodoSearchHit struct {
+	model.Todo
+	Snippet string
+	Score   float64
+}
+
 type TodoRepository interface {
-	FindAll(userID uint) ([]model.Todo, error)
+	FindAll(userID uint, query TodoQuery) ([]model.Todo, error)
+	Search(userID uint, terms []SearchTerm, limit int) ([]TodoSearchHit, error)
+	FindDue(userID uint, window DueWindow) ([]model.Todo, error)
 	FindByID(userID uint, id uint) (*model.Todo, error)
 	Create(todo *model.Todo) (*model.Todo, error)
 	Update(todo *model.Todo) (*model.Todo, error)
-	Delete(userID uint, id uint) error
+	UpdateBatch(updated []*model.Todo, created []*model.Todo) error
+	FindChildren(userID uint, parentID uint) ([]model.Todo, error)
+	FindAncestors(userID uint, id uint) ([]model.Todo, error)
+	FindDescendants(userID uint, rootIDs []uint) ([]TodoNode, error)
+	Delete(userID uint, id uint, version uint) error
+	FindTrash(userID uint) ([]model.Todo, error)
+	FindDeleted(userID uint, id uint) (*model.Todo, error)
+	FindDeletedDescendants(userID uint, todo *model.Todo) ([]TodoNode, error)
+	Restore(todo *model.Todo, ids []uint) error
+	Purge(userID uint, ids []uint) error
+	EmptyTrash(userID uint) (int64, error)
+	PurgeDeletedBefore(before time.Time) (int64, error)
+	FindChanges(userID uint, since int64, limit int) ([]model.Todo, error)
+	SyncSequence(userID uint) (current int64, purged int64, err error)
+	Transaction(fn func(repo TodoRepository) error) error
+	WithContext(ctx context.Context) TodoRepository
+}
+
+// TodoNode は子孫の進捗の計算に使う最小限の情報
+type TodoNode struct {
+	ID       uint
+	ParentID uint
+	Done     bool
+	Depth    int // 根からの深さ（子が 1）
 }
 
-type todoRepository struct{}
+// 親子関係の再帰をたどる上限（循環はサービス層で防ぐが、壊れたデータでも止まるように）
+const maxTreeWalk = 64
+
+// todoRepository は tx が nil なら db.DB を使う（Transaction の中では tx、WithContext の後は ctx 付きの DB）
+type todoRepository struct {
+	tx *gorm.DB
+}
 
 func NewTodoRepository() TodoRepository {
 	return &todoRepository{}
 }
 
-func (r *todoRepository) FindAll(userID uint) ([]model.Todo, error) {
+func (r *todoRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// Transaction は fn の中のリポジトリ操作を 1 つのトランザクションで行う。
+// fn がエラーを返したら全て取り消す（Transaction の中で呼ぶとセーブポイントになる）
+func (r *todoRepository) Transaction(fn func(repo TodoRepository) error) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		return fn(&todoRepository{tx: tx})
+	})
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す（クエリの span をリクエストの trace に入れる）
+func (r *todoRepository) WithContext(ctx context.Context) TodoRepository {
+	return &todoRepository{tx: r.conn().WithContext(ctx)}
+}
+
+// FindAll は (user_id, 並び替え列, id) のインデックスを使って 1 ページ分取得する
+func (r *todoRepository) FindAll(userID uint, query TodoQuery) ([]model.Todo, error) {
+	tx := r.conn().Where("user_id = ?", userID)
+	if query.ProjectID != nil {
+		tx = tx.Where("project_id = ?", *query.ProjectID)
+	}
+
+	if query.Done != nil {
+		tx = tx.Where("done = ?", *query.Done)
+	}
+	if query.CreatedFrom != nil {
+		tx = tx.Where("created_at >= ?", formatTime(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		tx = tx.Where("created_at < ?", formatTime(*query.CreatedTo))
+	}
+	if query.UpdatedFrom != nil {
+		tx = tx.Where("updated_at >= ?", formatTime(*query.UpdatedFrom))
+	}
+	if query.UpdatedTo != nil {
+		tx = tx.Where("updated_at < ?", formatTime(*query.UpdatedTo))
+	}
+	if query.Title != "" {
+		tx = tx.Where(`title LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Title)+"%")
+	}
+	if len(query.TagsAny) > 0 {
+		tx = tx.Where("id IN ("+taggedTodoIDs+")", userID, query.TagsAny)
+	}
+	if len(query.TagsAll) > 0 {
+		tx = tx.Where("id IN ("+taggedTodoIDs+" GROUP BY tt.todo_id HAVING COUNT(*) = ?)", userID, query.TagsAll, len(query.TagsAll))
+	}
+	if len(query.TagsNone) > 0 {
+		tx = tx.Where("id NOT IN ("+taggedTodoIDs+")", userID, query.TagsNone)
+	}
+
+	column := TodoSortCreatedAt
+	switch query.SortBy {
+	case TodoSortUpdatedAt, TodoSortTitle:
+		column = query.SortBy
+	}
+	op, dir := ">", "ASC"
+	if query.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if query.After != nil {
+		tx = tx.Where("("+column+", id) "+op+" (?, ?)", query.After.Value, query.After.ID)
+	}
+	if query.Limit > 0 {
+		tx = tx.Limit(query.Limit)
+	}
+
+	var todos []model.Todo
+	err := tx.Scopes(withTags).Order(column + " " + dir).Order("id " + dir).Find(&todos).Error
+	return todos, err
+}
+
+// 指定した名前のタグが付いた todo の id（tags.name は COLLATE NOCASE）
+const taggedTodoIDs = `SELECT tt.todo_id FROM todo_tags tt
+	JOIN tags t ON t.id = tt.tag_id
+	WHERE t.user_id = ? AND t.name IN ?`
+
+// withTags はタグを名前順で読み込む
+func withTags(tx *gorm.DB) *gorm.DB {
+	return tx.Preload("Tags", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("tags.name")
+	})
+}
+
+// FindDue は未完了で期日が window に入る todo を期日順に返す
+func (r *todoRepository) FindDue(userID uint, window DueWindow) ([]model.Todo, error) {
+	timed, timedArgs := rangeCond("due_at", window.TimedFrom, window.TimedTo)
+	date, dateArgs := rangeCond("due_at", window.DateFrom, window.DateTo)
+
 	var todos []model.Todo
-	err := db.DB.Where("user_id = ?", userID).Find(&todos).Error
+	err := r.conn().
+		Where("user_id = ? AND done = ? AND due_at IS NOT NULL", userID, false).
+		Where("(due_all_day = 0 AND "+timed+") OR (due_all_day = 1 AND "+date+")", append(timedArgs, dateArgs...)...).
+		Order("due_at ASC").Order("id ASC").
+		Scopes(withTags).
+		Find(&todos).Error
 	return todos, err
 }
 
+// 期日は UTC で保存しているので UTC の文字列で比較する
+func rangeCond(column string, from, to *time.Time) (string, []any) {
+	conds := []string{"1 = 1"}
+	var args []any
+	if from != nil {
+		conds = append(conds, column+" >= ?")
+		args = append(args, from.UTC().Format(db.TimeFormat))
+	}
+	if to != nil {
+		conds = append(conds, column+" < ?")
+		args = append(args, to.UTC().Format(db.TimeFormat))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// Search は FTS5（todos_fts）があれば関連度順、なければ LIKE で検索する
+func (r *todoRepository) Search(userID uint, terms []SearchTerm, limit int) ([]TodoSearchHit, error) {
+	search := r.searchLike
+	if r.hasFTS() {
+		search = r.searchFTS
+	}
+
+	hits, err := search(userID, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := make([]*model.Todo, len(hits))
+	for i := range hits {
+		todos[i] = &hits[i].Todo
+	}
+	return hits, r.loadTags(todos)
+}
+
+// loadTags は Raw で読み込んだ todo にタグを付ける（Preload が使えない場合用）
+func (r *todoRepository) loadTags(todos []*model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	var rows []struct {
+		TodoID uint
+		model.Tag
+	}
+	err := r.conn().Table("tags").
+		Select("todo_tags.todo_id, tags.*").
+		Joins("JOIN todo_tags ON todo_tags.tag_id = tags.id").
+		Where("todo_tags.todo_id IN ?", ids).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	// Preload と同じく、タグがなければ空のスライスにする
+	tags := make(map[uint][]model.Tag)
+	for _, row := range rows {
+		tags[row.TodoID] = append(tags[row.TodoID], row.Tag)
+	}
+	for _, todo := range todos {
+		todo.Tags = append([]model.Tag{}, tags[todo.ID]...)
+	}
+	return nil
+}
+
+func (r *todoRepository) hasFTS() bool {
+	var count int64
+	r.conn().Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'todos_fts'").Scan(&count)
+	return count > 0
+}
+
+func (r *todoRepository) searchFTS(userID uint, terms []SearchTerm, limit int) ([]TodoSearchHit, error) {
+	var hits []TodoSearchHit
+	err := r.conn().Raw(`
+		SELECT todos.*,
+			snippet(todos_fts, 0, ?, ?, '…', 16) AS snippet,
+			-bm25(todos_fts) AS score
+		FROM todos_fts
+		JOIN todos ON todos.id = todos_fts.rowid
+		WHERE todos_fts MATCH ?
+			AND todos.user_id = ?
+			AND todos.deleted_at IS NULL
+		ORDER BY bm25(todos_fts), todos.id
+		LIMIT ?`,
+		HighlightStart, HighlightEnd, ftsMatchExpr(terms), userID, limit,
+	).Scan(&hits).Error
+	return hits, err
+}
+
+// ftsMatchExpr は検索語を FTS5 の MATCH 式にする。
+// 全ての語をクォートするので、入力に FTS5 の演算子が含まれていても構文として解釈されない。
+func ftsMatchExpr(terms []SearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted := `"` + strings.ReplaceAll(t.Text, `"`, `""`) + `"`
+		if !t.Phrase {
+			quoted += "*"
+		}
+		parts = append(parts, quoted)
+	}
+	return strings.Join(parts, " ")
+}
+
+// searchLike は FTS が使えない DB 向けのフォールバック（関連度は付かない）
+func (r *todoRepository) searchLike(userID uint, terms []SearchTerm, limit int) ([]TodoSearchHit, error) {
+	tx := r.conn().Where("user_id = ?", userID)
+	for _, t := range terms {
+		tx = tx.Where(`title LIKE ? ESCAPE '\'`, "%"+escapeLike(t.Text)+"%")
+	}
+
+	var todos []model.Todo
+	if err := tx.Order("updated_at DESC").Order("id DESC").Limit(limit).Find(&todos).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]TodoSearchHit, 0, len(todos))
+	for _, todo := range todos {
+		hits = append(hits, TodoSearchHit{Todo: todo, Snippet: highlightTerms(todo.Title, terms)})
+	}
+	return hits, nil
+}
+
+func highlightTerms(text string, terms []SearchTerm) string {
+	patterns := make([]string, 0, len(terms))
+	for _, t := range terms {
+		patterns = append(patterns, regexp.QuoteMeta(t.Text))
+	}
+	re, err := regexp.Compile("(?i)" + strings.Join(patterns, "|"))
+	if err != nil {
+		return text
+	}
+	return re.ReplaceAllString(text, HighlightStart+"$0"+HighlightEnd)
+}
+
+// datetime 列と文字列で比較するため、保存時と同じ形式（ローカル時刻）に揃える
+func formatTime(t time.Time) string {
+	return t.In(time.Local).Format(db.TimeFormat)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (r *todoRepository) FindByID(userID uint, id uint) (*model.Todo, error) {
 	var todo model.Todo
-	err := db.DB.Where("user_id = ? AND id = ?", userID, id).First(&todo).Error
+	err := r.conn().Scopes(withTags).Where("user_id = ? AND id = ?", userID, id).First(&todo).Error
 	if err != nil {
 		return nil, err
 	}
@@ -35,28 +401,286 @@ func (r *todoRepository) FindByID(userID uint, id uint) (*model.Todo, error) {
 }
 
 func (r *todoRepository) Create(todo *model.Todo) (*model.Todo, error) {
-	result := db.DB.Create(todo)
-	if result.Error != nil {
-		return nil, result.Error
+	if err := createTodo(r.conn(), todo); err != nil {
+		return nil, err
 	}
 	return todo, nil
 }
 
 func (r *todoRepository) Update(todo *model.Todo) (*model.Todo, error) {
-	result := db.DB.
-		Where("id = ? AND user_id = ?", todo.ID, todo.UserID).
+	if err := updateTodo(r.conn(), todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// UpdateBatch は複数の todo の更新と作成を 1 トランザクションで行う
+// （繰り返しの次の回の作成や、親の自動完了など）
+func (r *todoRepository) UpdateBatch(updated []*model.Todo, created []*model.Todo) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		for _, todo := range updated {
+			if err := updateTodo(tx, todo); err != nil {
+				return err
+			}
+		}
+		for _, todo := range created {
+			if err := createTodo(tx, todo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func createTodo(tx *gorm.DB, todo *model.Todo) error {
+	todo.Version = 1
+	return tx.Create(todo).Error
+}
+
+// updateTodo は保存済みの Version が todo.Version と同じときだけ保存して Version を 1 増やす。
+// 読み込んだ後に他で更新されていたら ErrTodoModified
+func updateTodo(tx *gorm.DB, todo *model.Todo) error {
+	version := todo.Version
+	todo.Version++
+
+	// Select("*") で false / nil への変更も保存する
+	result := tx.
+		Where("id = ? AND user_id = ? AND version = ?", todo.ID, todo.UserID, version).
+		Select("*").Omit("created_at", "deleted_at", clause.Associations).
 		Updates(todo)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrTodoModified
+	}
+	if result.Error != nil {
+		todo.Version = version
+	}
+	return result.Error
+}
+
+// FindChildren は直下の子を作成順に返す
+func (r *todoRepository) FindChildren(userID uint, parentID uint) ([]model.Todo, error) {
+	var todos []model.Todo
+	err := r.conn().Scopes(withTags).
+		Where("user_id = ? AND parent_id = ?", userID, parentID).
+		Order("created_at").Order("id").
+		Find(&todos).Error
+	return todos, err
+}
+
+// FindAncestors は親から順に根までの祖先を返す
+func (r *todoRepository) FindAncestors(userID uint, id uint) ([]model.Todo, error) {
+	var todos []model.Todo
+	err := r.conn().Raw(`
+		WITH RECURSIVE ancestors(id, depth) AS (
+			SELECT parent_id, 1 FROM todos
+			WHERE id = ? AND user_id = ? AND parent_id IS NOT NULL
+			UNION ALL
+			SELECT todos.parent_id, ancestors.depth + 1 FROM todos
+			JOIN ancestors ON todos.id = ancestors.id
+			WHERE todos.parent_id IS NOT NULL AND ancestors.depth < ?
+		)
+		SELECT todos.* FROM todos
+		JOIN ancestors ON todos.id = ancestors.id
+		WHERE todos.user_id = ? AND todos.deleted_at IS NULL
+		ORDER BY ancestors.depth`,
+		id, userID, maxTreeWalk, userID,
+	).Scan(&todos).Error
+	return todos, err
+}
+
+// FindDescendants は rootIDs の子孫（rootIDs 自身は含まない）を返す
+func (r *todoRepository) FindDescendants(userID uint, rootIDs []uint) ([]TodoNode, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	var nodes []TodoNode
+	err := r.conn().Raw(`
+		WITH RECURSIVE descendants(id, parent_id, done, depth) AS (
+			SELECT id, parent_id, done, 1 FROM todos
+			WHERE parent_id IN ? AND user_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT todos.id, todos.parent_id, todos.done, descendants.depth + 1 FROM todos
+			JOIN descendants ON todos.parent_id = descendants.id
+			WHERE todos.deleted_at IS NULL AND descendants.depth < ?
+		)
+		SELECT id, parent_id, done, depth FROM descendants`,
+		rootIDs, userID, maxTreeWalk,
+	).Scan(&nodes).Error
+	return nodes, err
+}
+
+// Delete は todo を子孫ごと削除する。
+// version が 0 でなければ、todo の保存済みの Version が同じときだけ削除する
+func (r *todoRepository) Delete(userID uint, id uint, version uint) error {
+	nodes, err := r.FindDescendants(userID, []uint{id})
+	if err != nil {
+		return err
+	}
+
+	ids := []uint{id}
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
 
+	// ゴミ箱でまとめて扱えるよう、子孫も 1 つの文で削除して deleted_at を揃える
+	query := r.conn().Where("id IN ? AND user_id = ?", ids, userID)
+	if version != 0 {
+		query = query.Where("(SELECT version FROM todos WHERE id = ?) = ?", id, version)
+	}
+
+	result := query.Delete(&model.Todo{})
 	if result.Error != nil {
-		return nil, result.Error
+		return result.Error
 	}
-	return todo, nil
+	if result.RowsAffected == 0 {
+		if version != 0 {
+			return ErrTodoModified
+		}
+		return ErrTodoNotFound
+	}
+	return nil
 }
 
-func (r *todoRepository) Delete(userID uint, id uint) error {
-	result := db.DB.
-		Where("id = ? AND user_id = ?", id, userID).
-		Delete(&model.Todo{})
+// --- ゴミ箱 ---
+// 子孫ごと削除した todo は同じ deleted_at を持つので、それを「一緒に削除された」目印にする
 
-	return result.Error
+// FindTrash は削除済みの todo を新しく削除した順に返す。
+// 親と一緒に削除されたサブタスクは親にまとめて、ここには含めない
+func (r *todoRepository) FindTrash(userID uint) ([]model.Todo, error) {
+	var todos []model.Todo
+	err := r.conn().Unscoped().Scopes(withTags).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM todos AS parents
+			WHERE parents.id = todos.parent_id AND parents.deleted_at = todos.deleted_at
+		)`).
+		Order("deleted_at DESC").Order("id DESC").
+		Find(&todos).Error
+	return todos, err
+}
+
+func (r *todoRepository) FindDeleted(userID uint, id uint) (*model.Todo, error) {
+	var todo model.Todo
+	err := r.conn().Unscoped().Scopes(withTags).
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		First(&todo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// FindDeletedDescendants は todo と一緒に削除された子孫を返す
+func (r *todoRepository) FindDeletedDescendants(userID uint, todo *model.Todo) ([]TodoNode, error) {
+	var nodes []TodoNode
+	err := r.conn().Raw(`
+		WITH RECURSIVE
+		root(deleted_at) AS (
+			SELECT deleted_at FROM todos WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL
+		),
+		descendants(id, parent_id, done, depth) AS (
+			SELECT todos.id, todos.parent_id, todos.done, 1 FROM todos
+			JOIN root ON todos.deleted_at = root.deleted_at
+			WHERE todos.parent_id = ?
+			UNION ALL
+			SELECT todos.id, todos.parent_id, todos.done, descendants.depth + 1 FROM todos
+			JOIN descendants ON todos.parent_id = descendants.id
+			JOIN root ON todos.deleted_at = root.deleted_at
+			WHERE descendants.depth < ?
+		)
+		SELECT id, parent_id, done, depth FROM descendants`,
+		todo.ID, userID, todo.ID, maxTreeWalk,
+	).Scan(&nodes).Error
+	return nodes, err
+}
+
+// Restore は ids（todo とその子孫）を元に戻し、todo を todo.ParentID の下に置く。
+// 削除されたプロジェクトに入っていた todo は未分類にする
+func (r *todoRepository) Restore(todo *model.Todo, ids []uint) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		restored := tx.Unscoped().Model(&model.Todo{}).Where("id IN ? AND user_id = ?", ids, todo.UserID)
+
+		err := restored.Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.Todo{}).
+			Where("id = ? AND user_id = ?", todo.ID, todo.UserID).
+			Update("parent_id", todo.ParentID).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Todo{}).
+			Where("id IN ? AND user_id = ?", ids, todo.UserID).
+			Where("project_id NOT IN (SELECT id FROM projects WHERE deleted_at IS NULL)").
+			Update("project_id", nil).Error
+	})
+}
+
+// Purge は削除済みの ids を完全に削除する
+func (r *todoRepository) Purge(userID uint, ids []uint) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		_, err := purgeTodos(tx, tx.Where("id IN ? AND user_id = ? AND deleted_at IS NOT NULL", ids, userID))
+		return err
+	})
+}
+
+// EmptyTrash は userID の削除済みの todo を全て完全に削除する
+func (r *todoRepository) EmptyTrash(userID uint) (int64, error) {
+	var purged int64
+	err := r.conn().Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = purgeTodos(tx, tx.Where("user_id = ? AND deleted_at IS NOT NULL", userID))
+		return err
+	})
+	return purged, err
+}
+
+// PurgeDeletedBefore は全ユーザーの before より前に削除された todo を完全に削除する
+func (r *todoRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	var purged int64
+	err := r.conn().Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = purgeTodos(tx, tx.Where("deleted_at < ?", formatTime(before)))
+		return err
+	})
+	return purged, err
+}
+
+// purgeTodos は cond に当てはまる todo をタグとの関連ごと物理削除する
+func purgeTodos(tx *gorm.DB, cond *gorm.DB) (int64, error) {
+	ids := tx.Unscoped().Model(&model.Todo{}).Select("id").Where(cond)
+
+	if err := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN (?)", ids).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Where(cond).Delete(&model.Todo{})
+	return result.RowsAffected, result.Error
+}
+
+// FindChanges は変更番号が since より後の todo（ゴミ箱の todo も含む）を変更番号順に limit 件返す
+func (r *todoRepository) FindChanges(userID uint, since int64, limit int) ([]model.Todo, error) {
+	var todos []model.Todo
+	err := r.conn().Unscoped().Scopes(withTags).
+		Where("user_id = ? AND change_seq > ?", userID, since).
+		Order("change_seq").
+		Limit(limit).
+		Find(&todos).Error
+	return todos, err
+}
+
+// SyncSequence は最後に振った変更番号と、ユーザーが完全に削除した todo の最後の変更番号
+// （完全に削除していなければ 0）を返す
+func (r *todoRepository) SyncSequence(userID uint) (int64, int64, error) {
+	var current, purged int64
+	if err := r.conn().Raw("SELECT seq FROM sync_sequence WHERE id = 1").Scan(&current).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := r.conn().Raw("SELECT coalesce(max(seq), 0) FROM sync_purges WHERE user_id = ?", userID).Scan(&purged).Error; err != nil {
+		return 0, 0, err
+	}
+	return current, purged, nil
 }
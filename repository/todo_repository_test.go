@@ -0,0 +1,466 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"gorm.io/gorm"
+)
+
+// SQL（keyset・FTS・再帰 CTE・トリガー）が肝なので、マイグレーションを適用した一時ディレクトリの SQLite で確認する
+func newTodoRepository(t *testing.T) repository.TodoRepository {
+	t.Helper()
+
+	db.Init(filepath.Join(t.TempDir(), "todos.db"))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB.DB()
+		sqlDB.Close()
+	})
+	return repository.NewTodoRepository()
+}
+
+func createTodo(t *testing.T, repo repository.TodoRepository, todo model.Todo) *model.Todo {
+	t.Helper()
+
+	if todo.UserID == 0 {
+		todo.UserID = 1
+	}
+	created, err := repo.Create(&todo)
+	if err != nil {
+		t.Fatalf("failed to create todo: %v", err)
+	}
+	return created
+}
+
+// gormModel は作成・更新日時を at にする（同じ値の並び替え列を作るため）
+func gormModel(at time.Time) gorm.Model {
+	return gorm.Model{CreatedAt: at, UpdatedAt: at}
+}
+
+func todoIDs(todos []model.Todo) []uint {
+	ids := make([]uint, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+// --- FindAll（keyset ページング） ---
+// 並び替え列の値が同じ行が続いても、id で順序が決まり、ページの境目で重複も抜けもないこと
+func TestTodoRepository_FindAllKeyset(t *testing.T) {
+	repo := newTodoRepository(t)
+
+	// 作成日時とタイトルが全て同じ 5 件と、前後に 1 件ずつ
+	same := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	first := createTodo(t, repo, model.Todo{Title: "a", Model: gormModel(same.Add(-time.Hour))})
+	var middle []uint
+	for i := 0; i < 5; i++ {
+		middle = append(middle, createTodo(t, repo, model.Todo{Title: "same", Model: gormModel(same)}).ID)
+	}
+	last := createTodo(t, repo, model.Todo{Title: "z", Model: gormModel(same.Add(time.Hour))})
+	createTodo(t, repo, model.Todo{UserID: 2, Title: "same", Model: gormModel(same)})
+
+	asc := append(append([]uint{first.ID}, middle...), last.ID)
+	desc := slices.Clone(asc)
+	slices.Reverse(desc)
+
+	tests := []struct {
+		sortBy string
+		desc   bool
+		expect []uint
+	}{
+		{sortBy: repository.TodoSortCreatedAt, expect: asc},
+		{sortBy: repository.TodoSortCreatedAt, desc: true, expect: desc},
+		{sortBy: repository.TodoSortTitle, expect: asc},
+		{sortBy: repository.TodoSortTitle, desc: true, expect: desc},
+	}
+
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 3} {
+			t.Run(fmt.Sprintf("%s desc=%v limit=%d", tt.sortBy, tt.desc, limit), func(t *testing.T) {
+				var got []uint
+				query := repository.TodoQuery{SortBy: tt.sortBy, Desc: tt.desc, Limit: limit}
+				for page := 0; page <= len(tt.expect); page++ {
+					todos, err := repo.FindAll(1, query)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					got = append(got, todoIDs(todos)...)
+					if len(todos) < limit {
+						break
+					}
+					cursor := repository.NewTodoCursor(&todos[len(todos)-1], tt.sortBy)
+					query.After = &cursor
+				}
+
+				if !slices.Equal(got, tt.expect) {
+					t.Errorf("expected %v, got %v", tt.expect, got)
+				}
+			})
+		}
+	}
+}
+
+// --- Search（FTS5） ---
+func TestTodoRepository_Search(t *testing.T) {
+	repo := newTodoRepository(t)
+
+	report := createTodo(t, repo, model.Todo{Title: "Write the weekly report"})
+	reporting := createTodo(t, repo, model.Todo{Title: "Reporting dashboard for the week"})
+	weekly := createTodo(t, repo, model.Todo{Title: "Weekly sync notes"})
+	cafe := createTodo(t, repo, model.Todo{Title: "Café meeting"})
+	createTodo(t, repo, model.Todo{Title: "Other user's weekly report", UserID: 2})
+	deleted := createTodo(t, repo, model.Todo{Title: "Deleted weekly report"})
+	if err := repo.Delete(1, deleted.ID, 0); err != nil {
+		t.Fatalf("failed to delete todo: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		terms  []repository.SearchTerm
+		expect []uint // 順序は問わない
+	}{
+		{name: "prefix", terms: []repository.SearchTerm{{Text: "rep"}}, expect: []uint{report.ID, reporting.ID}},
+		{name: "prefix is case insensitive", terms: []repository.SearchTerm{{Text: "WEEK"}}, expect: []uint{report.ID, reporting.ID, weekly.ID}},
+		{name: "all terms must match", terms: []repository.SearchTerm{{Text: "week"}, {Text: "report"}}, expect: []uint{report.ID, reporting.ID}},
+		{name: "no match for one term", terms: []repository.SearchTerm{{Text: "sync"}, {Text: "report"}}, expect: nil},
+		{name: "phrase", terms: []repository.SearchTerm{{Text: "weekly report", Phrase: true}}, expect: []uint{report.ID}},
+		{name: "phrase is not a prefix", terms: []repository.SearchTerm{{Text: "weekly rep", Phrase: true}}, expect: nil},
+		{name: "phrase words must be adjacent", terms: []repository.SearchTerm{{Text: "write report", Phrase: true}}, expect: nil},
+		{name: "diacritics are ignored", terms: []repository.SearchTerm{{Text: "cafe"}}, expect: []uint{cafe.ID}},
+		{name: "operators are literal", terms: []repository.SearchTerm{{Text: "AND"}, {Text: "weekly"}}, expect: nil},
+		{name: "quotes are not syntax", terms: []repository.SearchTerm{{Text: `"weekly`}}, expect: []uint{report.ID, weekly.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := repo.Search(1, tt.terms, 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []uint
+			for _, hit := range hits {
+				got = append(got, hit.ID)
+				if hit.Tags == nil {
+					t.Errorf("expected tags to be loaded for %d", hit.ID)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+
+	// タイトルを変えたら索引も変わり、スニペットに一致箇所の目印が付く
+	weekly.Title = "Monthly sync notes"
+	if _, err := repo.Update(weekly); err != nil {
+		t.Fatalf("failed to update todo: %v", err)
+	}
+	hits, _ := repo.Search(1, []repository.SearchTerm{{Text: "month"}}, 10)
+	if len(hits) != 1 || hits[0].ID != weekly.ID {
+		t.Fatalf("expected renamed todo, got %+v", hits)
+	}
+	if expect := repository.HighlightStart + "Monthly" + repository.HighlightEnd + " sync notes"; hits[0].Snippet != expect {
+		t.Errorf("expected snippet %q, got %q", expect, hits[0].Snippet)
+	}
+	if hits, _ := repo.Search(1, []repository.SearchTerm{{Text: "weekly", Phrase: true}}, 10); len(hits) != 1 || hits[0].ID != report.ID {
+		t.Errorf("expected old title to be removed from the index, got %+v", hits)
+	}
+}
+
+// --- FindAll（タグでの絞り込み） ---
+func TestTodoRepository_FindAllTags(t *testing.T) {
+	repo := newTodoRepository(t)
+	tagRepo := repository.NewTagRepository()
+
+	tag := func(userID uint, name string) *model.Tag {
+		t.Helper()
+		created, err := tagRepo.Create(&model.Tag{UserID: userID, Name: name})
+		if err != nil {
+			t.Fatalf("failed to create tag: %v", err)
+		}
+		return created
+	}
+	attach := func(todo *model.Todo, tags ...*model.Tag) {
+		t.Helper()
+		for _, tg := range tags {
+			if err := tagRepo.Attach(todo.ID, tg.ID); err != nil {
+				t.Fatalf("failed to attach tag: %v", err)
+			}
+		}
+	}
+
+	work, home, urgent := tag(1, "Work"), tag(1, "home"), tag(1, "urgent")
+	otherWork := tag(2, "work")
+
+	workUrgent := createTodo(t, repo, model.Todo{Title: "work urgent"})
+	attach(workUrgent, work, urgent)
+	workOnly := createTodo(t, repo, model.Todo{Title: "work"})
+	attach(workOnly, work)
+	homeUrgent := createTodo(t, repo, model.Todo{Title: "home urgent"})
+	attach(homeUrgent, home, urgent)
+	untagged := createTodo(t, repo, model.Todo{Title: "untagged"})
+	// 他のユーザーの同じ名前のタグは数えない
+	otherTodo := createTodo(t, repo, model.Todo{Title: "other", UserID: 2})
+	attach(otherTodo, otherWork)
+	attach(untagged, otherWork)
+
+	tests := []struct {
+		name   string
+		query  repository.TodoQuery
+		expect []uint
+	}{
+		{name: "any", query: repository.TodoQuery{TagsAny: []string{"work", "home"}}, expect: []uint{workUrgent.ID, workOnly.ID, homeUrgent.ID}},
+		{name: "any is case insensitive", query: repository.TodoQuery{TagsAny: []string{"WORK"}}, expect: []uint{workUrgent.ID, workOnly.ID}},
+		{name: "any with unknown tag", query: repository.TodoQuery{TagsAny: []string{"nothing"}}, expect: nil},
+		{name: "all", query: repository.TodoQuery{TagsAll: []string{"work", "urgent"}}, expect: []uint{workUrgent.ID}},
+		{name: "all with one tag", query: repository.TodoQuery{TagsAll: []string{"urgent"}}, expect: []uint{workUrgent.ID, homeUrgent.ID}},
+		{name: "all with unknown tag", query: repository.TodoQuery{TagsAll: []string{"work", "nothing"}}, expect: nil},
+		{name: "none", query: repository.TodoQuery{TagsNone: []string{"urgent"}}, expect: []uint{workOnly.ID, untagged.ID}},
+		{name: "any and none", query: repository.TodoQuery{TagsAny: []string{"urgent"}, TagsNone: []string{"home"}}, expect: []uint{workUrgent.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := repo.FindAll(1, tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := todoIDs(todos); !slices.Equal(got, tt.expect) {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+
+	// タグは名前順で読み込む
+	todos, _ := repo.FindAll(1, repository.TodoQuery{TagsAll: []string{"work", "urgent"}})
+	if len(todos) != 1 || len(todos[0].Tags) != 2 || todos[0].Tags[0].Name != "urgent" || todos[0].Tags[1].Name != "Work" {
+		t.Errorf("unexpected tags: %+v", todos)
+	}
+}
+
+// todoTree は root → child → grandchild と root → sibling の木を作る
+func todoTree(t *testing.T, repo repository.TodoRepository) (root, child, grandchild, sibling *model.Todo) {
+	t.Helper()
+
+	root = createTodo(t, repo, model.Todo{Title: "root"})
+	child = createTodo(t, repo, model.Todo{Title: "child", ParentID: &root.ID})
+	grandchild = createTodo(t, repo, model.Todo{Title: "grandchild", ParentID: &child.ID, Done: true})
+	sibling = createTodo(t, repo, model.Todo{Title: "sibling", ParentID: &root.ID})
+	return root, child, grandchild, sibling
+}
+
+// --- サブタスク（再帰 CTE） ---
+func TestTodoRepository_Subtasks(t *testing.T) {
+	repo := newTodoRepository(t)
+
+	root, child, grandchild, sibling := todoTree(t, repo)
+	other := createTodo(t, repo, model.Todo{Title: "other"})
+
+	ancestors, err := repo.FindAncestors(1, grandchild.ID)
+	if err != nil || !slices.Equal(todoIDs(ancestors), []uint{child.ID, root.ID}) {
+		t.Errorf("expected ancestors [child root], got %v, %v", todoIDs(ancestors), err)
+	}
+	if ancestors, _ := repo.FindAncestors(2, grandchild.ID); len(ancestors) != 0 {
+		t.Errorf("expected no ancestors for other user, got %v", todoIDs(ancestors))
+	}
+
+	nodes, err := repo.FindDescendants(1, []uint{root.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	depths := map[uint]int{}
+	for _, node := range nodes {
+		depths[node.ID] = node.Depth
+	}
+	if len(depths) != 3 || depths[child.ID] != 1 || depths[sibling.ID] != 1 || depths[grandchild.ID] != 2 {
+		t.Errorf("unexpected descendants: %+v", nodes)
+	}
+
+	children, _ := repo.FindChildren(1, root.ID)
+	if !slices.Equal(todoIDs(children), []uint{child.ID, sibling.ID}) {
+		t.Errorf("expected children [child sibling], got %v", todoIDs(children))
+	}
+
+	// 古い Version では削除しない
+	if err := repo.Delete(1, child.ID, child.Version+1); !errors.Is(err, repository.ErrTodoModified) {
+		t.Errorf("expected ErrTodoModified, got %v", err)
+	}
+	if err := repo.Delete(2, child.ID, 0); !errors.Is(err, repository.ErrTodoNotFound) {
+		t.Errorf("expected ErrTodoNotFound for other user, got %v", err)
+	}
+
+	// 子孫ごと削除し、他の todo は残す
+	if err := repo.Delete(1, child.ID, child.Version); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	todos, _ := repo.FindAll(1, repository.TodoQuery{})
+	if !slices.Equal(todoIDs(todos), []uint{root.ID, sibling.ID, other.ID}) {
+		t.Errorf("expected child subtree to be deleted, got %v", todoIDs(todos))
+	}
+	if nodes, _ := repo.FindDescendants(1, []uint{root.ID}); len(nodes) != 1 || nodes[0].ID != sibling.ID {
+		t.Errorf("expected only sibling to remain under root, got %+v", nodes)
+	}
+	if err := repo.Delete(1, child.ID, 0); !errors.Is(err, repository.ErrTodoNotFound) {
+		t.Errorf("expected ErrTodoNotFound for deleted todo, got %v", err)
+	}
+}
+
+// --- ゴミ箱（deleted_at でのまとめ・復元・完全な削除） ---
+func TestTodoRepository_Trash(t *testing.T) {
+	repo := newTodoRepository(t)
+
+	root, child, grandchild, sibling := todoTree(t, repo)
+	other := createTodo(t, repo, model.Todo{Title: "other"})
+
+	// child を先に消してから root を消すと、ゴミ箱では別々の項目になる
+	if err := repo.Delete(1, child.ID, 0); err != nil {
+		t.Fatalf("failed to delete child: %v", err)
+	}
+	if err := repo.Delete(1, root.ID, 0); err != nil {
+		t.Fatalf("failed to delete root: %v", err)
+	}
+
+	trash, err := repo.FindTrash(1)
+	if err != nil || !slices.Equal(todoIDs(trash), []uint{root.ID, child.ID}) {
+		t.Fatalf("expected trash [root child], got %v, %v", todoIDs(trash), err)
+	}
+	if trash[0].DeletedAt.Time.Before(trash[1].DeletedAt.Time) {
+		t.Errorf("expected newest deletion first: %+v", trash)
+	}
+	if trash, _ := repo.FindTrash(2); len(trash) != 0 {
+		t.Errorf("expected empty trash for other user, got %v", todoIDs(trash))
+	}
+
+	// 一緒に削除された子孫だけをたどる（先に消した child は root と一緒ではない）
+	nodes, err := repo.FindDeletedDescendants(1, &trash[0])
+	if err != nil || len(nodes) != 1 || nodes[0].ID != sibling.ID {
+		t.Errorf("expected [sibling] deleted with root, got %+v, %v", nodes, err)
+	}
+	nodes, _ = repo.FindDeletedDescendants(1, &trash[1])
+	if len(nodes) != 1 || nodes[0].ID != grandchild.ID || nodes[0].Depth != 1 {
+		t.Errorf("expected [grandchild] deleted with child, got %+v", nodes)
+	}
+
+	// 親が削除済みの child はルートに戻す
+	deletedChild, err := repo.FindDeleted(1, child.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deletedChild.ParentID = nil
+	if err := repo.Restore(deletedChild, []uint{child.ID, grandchild.ID}); err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	restored, err := repo.FindByID(1, child.ID)
+	if err != nil || restored.ParentID != nil || restored.Version != child.Version+1 {
+		t.Errorf("expected child restored at root with new version, got %+v, %v", restored, err)
+	}
+	if restored, err := repo.FindByID(1, grandchild.ID); err != nil || *restored.ParentID != child.ID {
+		t.Errorf("expected grandchild restored under child, got %+v, %v", restored, err)
+	}
+	if trash, _ := repo.FindTrash(1); !slices.Equal(todoIDs(trash), []uint{root.ID}) {
+		t.Errorf("expected trash [root], got %v", todoIDs(trash))
+	}
+
+	// 削除済みでない todo は Purge しない
+	if err := repo.Purge(1, []uint{other.ID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.FindByID(1, other.ID); err != nil {
+		t.Errorf("expected active todo to remain, got %v", err)
+	}
+
+	// 保存期間を過ぎた root と sibling を完全に削除する
+	if purged, err := repo.PurgeDeletedBefore(time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Errorf("expected nothing purged before retention, got %d, %v", purged, err)
+	}
+	if purged, err := repo.PurgeDeletedBefore(time.Now().Add(time.Second)); err != nil || purged != 2 {
+		t.Errorf("expected 2 purged, got %d, %v", purged, err)
+	}
+	if trash, _ := repo.FindTrash(1); len(trash) != 0 {
+		t.Errorf("expected empty trash, got %v", todoIDs(trash))
+	}
+	if _, err := repo.FindDeleted(1, root.ID); err == nil {
+		t.Errorf("expected root to be purged")
+	}
+
+	// ゴミ箱を空にするのは自分の todo だけ
+	otherUser := createTodo(t, repo, model.Todo{Title: "other user", UserID: 2})
+	repo.Delete(2, otherUser.ID, 0)
+	repo.Delete(1, other.ID, 0)
+	if emptied, err := repo.EmptyTrash(1); err != nil || emptied != 1 {
+		t.Errorf("expected 1 emptied, got %d, %v", emptied, err)
+	}
+	if trash, _ := repo.FindTrash(2); len(trash) != 1 {
+		t.Errorf("expected other user's trash to remain, got %v", todoIDs(trash))
+	}
+}
+
+// --- 差分同期（トリガーで振る変更番号） ---
+func TestTodoRepository_Changes(t *testing.T) {
+	repo := newTodoRepository(t)
+
+	a := createTodo(t, repo, model.Todo{Title: "a"})
+	b := createTodo(t, repo, model.Todo{Title: "b"})
+	createTodo(t, repo, model.Todo{Title: "other", UserID: 2})
+
+	current, purged, err := repo.SyncSequence(1)
+	if err != nil || current != 3 || purged != 0 {
+		t.Fatalf("expected sequence 3 and nothing purged, got %d %d, %v", current, purged, err)
+	}
+
+	// 更新と削除（ゴミ箱に入れる）のたびに全体で単調増加する番号を振り直す
+	a.Title = "a2"
+	if _, err := repo.Update(a); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := repo.Delete(1, b.ID, 0); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	changes, err := repo.FindChanges(1, 0, 10)
+	if err != nil || !slices.Equal(todoIDs(changes), []uint{a.ID, b.ID}) {
+		t.Fatalf("expected changes [a b], got %v, %v", todoIDs(changes), err)
+	}
+	if changes[0].CreatedSeq != 1 || changes[0].ChangeSeq != 4 || changes[1].CreatedSeq != 2 || changes[1].ChangeSeq != 5 {
+		t.Errorf("unexpected sequences: a %d/%d, b %d/%d", changes[0].CreatedSeq, changes[0].ChangeSeq, changes[1].CreatedSeq, changes[1].ChangeSeq)
+	}
+	if !changes[1].DeletedAt.Valid {
+		t.Errorf("expected deleted todo in changes: %+v", changes[1])
+	}
+
+	// since より後だけを、limit 件まで
+	if changes, _ := repo.FindChanges(1, 4, 10); !slices.Equal(todoIDs(changes), []uint{b.ID}) {
+		t.Errorf("expected changes after 4 [b], got %v", todoIDs(changes))
+	}
+	if changes, _ := repo.FindChanges(1, 0, 1); !slices.Equal(todoIDs(changes), []uint{a.ID}) {
+		t.Errorf("expected first page [a], got %v", todoIDs(changes))
+	}
+	if changes, _ := repo.FindChanges(1, 5, 10); len(changes) != 0 {
+		t.Errorf("expected no changes after 5, got %v", todoIDs(changes))
+	}
+
+	// 完全に削除したら、その最後の変更番号を覚えておく（ユーザーごと）
+	if _, err := repo.EmptyTrash(1); err != nil {
+		t.Fatalf("failed to empty trash: %v", err)
+	}
+	current, purged, _ = repo.SyncSequence(1)
+	if current != 5 || purged != 5 {
+		t.Errorf("expected sequence 5 and purged 5, got %d %d", current, purged)
+	}
+	if _, purged, _ := repo.SyncSequence(2); purged != 0 {
+		t.Errorf("expected nothing purged for other user, got %d", purged)
+	}
+	if changes, _ := repo.FindChanges(1, 0, 10); !slices.Equal(todoIDs(changes), []uint{a.ID}) {
+		t.Errorf("expected purged todo to be gone from changes, got %v", todoIDs(changes))
+	}
+}
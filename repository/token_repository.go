@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 回転済み（他のリクエストが先に使った）リフレッシュトークンを回転しようとした
+var ErrRefreshTokenRotated = errors.New("refresh token already rotated")
+
+type TokenRepository interface {
+	CreateRefreshToken(token *model.RefreshToken) error
+	FindRefreshTokenByHash(hash string) (*model.RefreshToken, error)
+	FindRefreshTokenByAccessJTI(jti string) (*model.RefreshToken, error)
+	RotateRefreshToken(oldID uint, next *model.RefreshToken) error
+	RevokeFamily(familyID string) error
+	RevokeAllForUser(userID uint) error
+	RevokeAccessToken(token *model.RevokedToken) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	DeleteExpired(now time.Time) (int64, error)
+	WithContext(ctx context.Context) TokenRepository
+}
+
+// tokenRepository は tx が nil なら db.DB を使う（WithContext の後は ctx 付きの DB）
+type tokenRepository struct {
+	tx *gorm.DB
+}
+
+func NewTokenRepository() TokenRepository {
+	return &tokenRepository{}
+}
+
+func (r *tokenRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す
+func (r *tokenRepository) WithContext(ctx context.Context) TokenRepository {
+	return &tokenRepository{tx: r.conn().WithContext(ctx)}
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return r.conn().Create(token).Error
+}
+
+func (r *tokenRepository) FindRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.conn().Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) FindRefreshTokenByAccessJTI(jti string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.conn().Where("access_jti = ?", jti).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken は古いトークンを失効させて新しいトークンを保存する。
+// 古いトークンが既に失効済みなら ErrRefreshTokenRotated を返す。
+func (r *tokenRepository) RotateRefreshToken(oldID uint, next *model.RefreshToken) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldID).
+			Updates(map[string]any{"revoked_at": time.Now(), "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenRotated
+		}
+		return nil
+	})
+}
+
+// RevokeFamily は系列内のリフレッシュトークンと、それと一緒に発行したアクセストークンを全て失効させる
+func (r *tokenRepository) RevokeFamily(familyID string) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		return revokeRefreshTokens(tx, "family_id = ?", familyID)
+	})
+}
+
+// RevokeAllForUser はユーザーの全セッションを失効させる
+func (r *tokenRepository) RevokeAllForUser(userID uint) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		return revokeRefreshTokens(tx, "user_id = ?", userID)
+	})
+}
+
+func (r *tokenRepository) RevokeAccessToken(token *model.RevokedToken) error {
+	return r.conn().Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.conn().Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteExpired は期限切れのリフレッシュトークンと、失効させたアクセストークンのうち期限切れのものを全ユーザー分削除する。
+// 期限切れのトークンはどちらにしても使えないので、行を残しておく必要はない
+func (r *tokenRepository) DeleteExpired(now time.Time) (int64, error) {
+	var deleted int64
+	err := r.conn().Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at < ?", formatTime(now)).Delete(&model.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+
+		result = tx.Where("expires_at < ?", formatTime(now)).Delete(&model.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
+
+func revokeRefreshTokens(tx *gorm.DB, query string, args ...any) error {
+	var tokens []model.RefreshToken
+	if err := tx.Where(query, args...).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var revoked []model.RevokedToken
+	for _, t := range tokens {
+		if t.AccessExpiresAt.After(now) {
+			revoked = append(revoked, model.RevokedToken{
+				JTI:       t.AccessJTI,
+				UserID:    t.UserID,
+				ExpiresAt: t.AccessExpiresAt,
+			})
+		}
+	}
+	if len(revoked) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&model.RefreshToken{}).
+		Where(query, args...).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+}
@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+func newTokenRepository(t *testing.T) repository.TokenRepository {
+	t.Helper()
+
+	db.Init(filepath.Join(t.TempDir(), "tokens.db"))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB.DB()
+		sqlDB.Close()
+	})
+	return repository.NewTokenRepository()
+}
+
+// --- DeleteExpired ---
+// 期限切れのリフレッシュトークン（失効済みかどうかによらない）と、期限切れの失効させたアクセストークンだけを削除する
+func TestTokenRepository_DeleteExpired(t *testing.T) {
+	repo := newTokenRepository(t)
+
+	now := time.Now()
+	revokedAt := now.Add(-2 * time.Hour)
+	refreshTokens := []*model.RefreshToken{
+		{UserID: 1, FamilyID: "a", TokenHash: "expired", ExpiresAt: now.Add(-time.Hour)},
+		{UserID: 1, FamilyID: "a", TokenHash: "expired-revoked", ExpiresAt: now.Add(-time.Hour), RevokedAt: &revokedAt},
+		{UserID: 2, FamilyID: "b", TokenHash: "active", ExpiresAt: now.Add(time.Hour)},
+		{UserID: 2, FamilyID: "b", TokenHash: "active-revoked", ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt},
+	}
+	for _, token := range refreshTokens {
+		if err := repo.CreateRefreshToken(token); err != nil {
+			t.Fatalf("failed to create refresh token: %v", err)
+		}
+	}
+	for jti, expiresAt := range map[string]time.Time{"old": now.Add(-time.Minute), "live": now.Add(time.Minute)} {
+		if err := repo.RevokeAccessToken(&model.RevokedToken{JTI: jti, UserID: 1, ExpiresAt: expiresAt}); err != nil {
+			t.Fatalf("failed to revoke access token: %v", err)
+		}
+	}
+
+	deleted, err := repo.DeleteExpired(now)
+	if err != nil || deleted != 3 {
+		t.Fatalf("expected 3 deleted, got %d, %v", deleted, err)
+	}
+
+	for hash, expectFound := range map[string]bool{"expired": false, "expired-revoked": false, "active": true, "active-revoked": true} {
+		if _, err := repo.FindRefreshTokenByHash(hash); (err == nil) != expectFound {
+			t.Errorf("%s: expected found=%v, got %v", hash, expectFound, err)
+		}
+	}
+	for jti, expectRevoked := range map[string]bool{"old": false, "live": true} {
+		if revoked, err := repo.IsAccessTokenRevoked(jti); err != nil || revoked != expectRevoked {
+			t.Errorf("%s: expected revoked=%v, got %v, %v", jti, expectRevoked, revoked, err)
+		}
+	}
+
+	if deleted, err := repo.DeleteExpired(now); err != nil || deleted != 0 {
+		t.Errorf("expected nothing left to delete, got %d, %v", deleted, err)
+	}
+}
@@ -1,24 +1,42 @@
 package repository
 
 import (
+	"context"
 	"github.com/a5415091-collab/go-gin-todo-app/db"
 	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
 )
 
 type UserRepository interface {
 	FindByEmail(email string) (*model.User, error)
 	Create(user *model.User) error
+	WithContext(ctx context.Context) UserRepository
 }
 
-type userRepository struct{}
+// userRepository は tx が nil なら db.DB を使う（WithContext の後は ctx 付きの DB）
+type userRepository struct {
+	tx *gorm.DB
+}
 
 func NewUserRepository() UserRepository {
 	return &userRepository{}
 }
 
+func (r *userRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す
+func (r *userRepository) WithContext(ctx context.Context) UserRepository {
+	return &userRepository{tx: r.conn().WithContext(ctx)}
+}
+
 func (r *userRepository) FindByEmail(email string) (*model.User, error) {
 	var user model.User
-	result := db.DB.Where("email = ?", email).First(&user)
+	result := r.conn().Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -26,6 +44,6 @@ func (r *userRepository) FindByEmail(email string) (*model.User, error) {
 }
 
 func (r *userRepository) Create(user *model.User) error {
-	result := db.DB.Create(user)
+	result := r.conn().Create(user)
 	return result.Error
 }
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	FindAll(userID uint) ([]model.Webhook, error)
+	FindByID(userID uint, id uint) (*model.Webhook, error)
+	FindActive(userID uint) ([]model.Webhook, error)
+	Count(userID uint) (int64, error)
+	Create(webhook *model.Webhook) error
+	Update(webhook *model.Webhook) error
+	Delete(webhook *model.Webhook) error
+
+	// 連続して失敗した送信の回数
+	ResetFailures(id uint) error
+	IncrementFailures(id uint) (int, error)
+	Disable(id uint, at time.Time) error
+
+	FindDeliveries(userID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	FindDelivery(userID uint, webhookID uint, id uint) (*model.WebhookDelivery, error)
+	FindDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	DeleteDeliveriesBefore(before time.Time) (int64, error)
+	WithContext(ctx context.Context) WebhookRepository
+}
+
+// webhookRepository は tx が nil なら db.DB を使う（WithContext の後は ctx 付きの DB）
+type webhookRepository struct {
+	tx *gorm.DB
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &webhookRepository{}
+}
+
+func (r *webhookRepository) conn() *gorm.DB {
+	if r.tx != nil {
+		return r.tx
+	}
+	return db.DB
+}
+
+// WithContext は ctx を付けてクエリを実行するリポジトリを返す
+func (r *webhookRepository) WithContext(ctx context.Context) WebhookRepository {
+	return &webhookRepository{tx: r.conn().WithContext(ctx)}
+}
+
+func (r *webhookRepository) FindAll(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := r.conn().Where("user_id = ?", userID).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) FindByID(userID uint, id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.conn().Where("user_id = ?", userID).First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// FindActive は止めていない webhook を返す（イベントの種類では絞らない）
+func (r *webhookRepository) FindActive(userID uint) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	err := r.conn().Where("user_id = ? AND active = ?", userID, true).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) Count(userID uint) (int64, error) {
+	var count int64
+	err := r.conn().Model(&model.Webhook{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *webhookRepository) Create(webhook *model.Webhook) error {
+	return r.conn().Create(webhook).Error
+}
+
+func (r *webhookRepository) Update(webhook *model.Webhook) error {
+	return r.conn().Save(webhook).Error
+}
+
+// Delete は webhook を送信の記録ごと削除する
+func (r *webhookRepository) Delete(webhook *model.Webhook) error {
+	return r.conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+}
+
+func (r *webhookRepository) ResetFailures(id uint) error {
+	return r.conn().Model(&model.Webhook{}).Where("id = ? AND failure_count <> 0", id).
+		Update("failure_count", 0).Error
+}
+
+// IncrementFailures は連続して失敗した回数を 1 増やして、増やした後の回数を返す
+func (r *webhookRepository) IncrementFailures(id uint) (int, error) {
+	var count int
+	err := r.conn().Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Webhook{}).Where("id = ?", id).
+			Update("failure_count", gorm.Expr("failure_count + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.Webhook{}).Where("id = ?", id).Select("failure_count").Scan(&count).Error
+	})
+	return count, err
+}
+
+func (r *webhookRepository) Disable(id uint, at time.Time) error {
+	return r.conn().Model(&model.Webhook{}).Where("id = ?", id).
+		Updates(map[string]any{"active": false, "disabled_at": at}).Error
+}
+
+// FindDeliveries は新しい順に返す
+func (r *webhookRepository) FindDeliveries(userID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.conn().Where("user_id = ? AND webhook_id = ?", userID, webhookID).
+		Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) FindDelivery(userID uint, webhookID uint, id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.conn().Where("user_id = ? AND webhook_id = ?", userID, webhookID).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDueDeliveries は now までに送る予定の送信待ちを全ユーザー分、予定の早い順に返す
+func (r *webhookRepository) FindDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.conn().Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, formatTime(now)).
+		Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.conn().Create(delivery).Error
+}
+
+// UpdateDelivery は送信の結果を保存する（送信中に webhook ごと削除されていたら何もしない）
+func (r *webhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return r.conn().Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "error", "delivered_at", "updated_at").
+		Updates(delivery).Error
+}
+
+// DeleteDeliveriesBefore は before より前の送信の記録を全ユーザー分削除する（送信待ちは残す）
+func (r *webhookRepository) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	result := r.conn().Where("created_at < ? AND status <> ?", formatTime(before), model.DeliveryPending).
+		Delete(&model.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
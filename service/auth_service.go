@@ -1,28 +1,51 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	"github.com/a5415091-collab/go-gin-todo-app/jwt"
 	"github.com/a5415091-collab/go-gin-todo-app/model"
 	"github.com/a5415091-collab/go-gin-todo-app/repository"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
 )
 
 type AuthService interface {
-	Signup(email, password string) error
-	Login(email, password string) (*model.User, error)
+	Signup(ctx context.Context, email, password string) error
+	Login(ctx context.Context, email, password string) (*model.User, error)
+	IssueTokens(ctx context.Context, userID uint) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time) error
+	LogoutAll(ctx context.Context, userID uint) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+// TokenPair は login / refresh で返すトークンの組
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
 }
 
 type authService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
 }
 
-func NewAuthService(userRepo repository.UserRepository) AuthService {
-	return &authService{userRepo}
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository) AuthService {
+	return &tracedAuthService{&authService{userRepo, tokenRepo}}
 }
 
 // Signup
-func (s *authService) Signup(email, password string) error {
+func (s *authService) Signup(ctx context.Context, email, password string) error {
 	existing, _ := s.userRepo.FindByEmail(email)
 	if existing != nil {
 		return errors.New("email already exists")
@@ -43,7 +66,7 @@ func (s *authService) Signup(email, password string) error {
 }
 
 // Login
-func (s *authService) Login(email, password string) (*model.User, error) {
+func (s *authService) Login(ctx context.Context, email, password string) (*model.User, error) {
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil || user == nil {
 		return nil, errors.New("invalid email or password")
@@ -56,3 +79,122 @@ func (s *authService) Login(email, password string) (*model.User, error) {
 
 	return user, nil
 }
+
+// IssueTokens はログイン成功時に新しいトークン系列を発行する
+func (s *authService) IssueTokens(ctx context.Context, userID uint) (*TokenPair, error) {
+	familyID, err := jwt.NewFamilyID()
+	if err != nil {
+		return nil, err
+	}
+
+	pair, row, err := s.newTokenPair(userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.CreateRefreshToken(row); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh はリフレッシュトークンを回転させて新しいトークンを発行する。
+// 回転済みのトークンが再利用された場合は系列全体を失効させる。
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	current, err := s.tokenRepo.FindRefreshTokenByHash(jwt.HashToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		// DB の障害はトークンの誤りではないので、そのまま返す（500）
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, next, err := s.newTokenPair(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokenRepo.RotateRefreshToken(current.ID, next)
+	if errors.Is(err, repository.ErrRefreshTokenRotated) {
+		// 同じトークンが同時に使われた
+		if err := s.tokenRepo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout は現在のアクセストークンとその系列のリフレッシュトークンを失効させる
+func (s *authService) Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time) error {
+	session, err := s.tokenRepo.FindRefreshTokenByAccessJTI(jti)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		// 系列を失効できないままログアウトを成功にしない
+		return err
+	}
+	if err == nil && session != nil && session.UserID == userID {
+		if err := s.tokenRepo.RevokeFamily(session.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	return s.tokenRepo.RevokeAccessToken(&model.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// LogoutAll はユーザーの全セッションを失効させる
+func (s *authService) LogoutAll(ctx context.Context, userID uint) error {
+	return s.tokenRepo.RevokeAllForUser(userID)
+}
+
+// IsRevoked はアクセストークンが失効済みかどうか（AuthMiddleware で使う）
+func (s *authService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.tokenRepo.IsAccessTokenRevoked(jti)
+}
+
+// PurgeExpired は期限切れのリフレッシュトークンと失効させたアクセストークンを削除する（定期実行用）
+func (s *authService) PurgeExpired(now time.Time) (int64, error) {
+	return s.tokenRepo.DeleteExpired(now)
+}
+
+func (s *authService) newTokenPair(userID uint, familyID string) (*TokenPair, *model.RefreshToken, error) {
+	access, err := jwt.CreateToken(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	refresh, refreshExpiresAt, err := jwt.NewRefreshToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	row := &model.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		TokenHash:       jwt.HashToken(refresh),
+		AccessJTI:       access.JTI,
+		AccessExpiresAt: access.ExpiresAt,
+		ExpiresAt:       refreshExpiresAt,
+	}
+	pair := &TokenPair{
+		AccessToken:  access.Token,
+		RefreshToken: refresh,
+		ExpiresAt:    access.ExpiresAt,
+	}
+	return pair, row, nil
+}
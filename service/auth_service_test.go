@@ -1,12 +1,17 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/a5415091-collab/go-gin-todo-app/jwt"
 	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
 	"github.com/a5415091-collab/go-gin-todo-app/service"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // --- Mock Repository ---
@@ -24,6 +29,62 @@ func (m *MockUserRepository) Create(user *model.User) error {
 	return m.CreateFunc(user)
 }
 
+func (m *MockUserRepository) WithContext(ctx context.Context) repository.UserRepository {
+	return m
+}
+
+type MockTokenRepository struct {
+	CreateRefreshTokenFunc          func(token *model.RefreshToken) error
+	FindRefreshTokenByHashFunc      func(hash string) (*model.RefreshToken, error)
+	FindRefreshTokenByAccessJTIFunc func(jti string) (*model.RefreshToken, error)
+	RotateRefreshTokenFunc          func(oldID uint, next *model.RefreshToken) error
+	RevokeFamilyFunc                func(familyID string) error
+	RevokeAllForUserFunc            func(userID uint) error
+	RevokeAccessTokenFunc           func(token *model.RevokedToken) error
+	IsAccessTokenRevokedFunc        func(jti string) (bool, error)
+	DeleteExpiredFunc               func(now time.Time) (int64, error)
+}
+
+func (m *MockTokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return m.CreateRefreshTokenFunc(token)
+}
+
+func (m *MockTokenRepository) FindRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	return m.FindRefreshTokenByHashFunc(hash)
+}
+
+func (m *MockTokenRepository) FindRefreshTokenByAccessJTI(jti string) (*model.RefreshToken, error) {
+	return m.FindRefreshTokenByAccessJTIFunc(jti)
+}
+
+func (m *MockTokenRepository) RotateRefreshToken(oldID uint, next *model.RefreshToken) error {
+	return m.RotateRefreshTokenFunc(oldID, next)
+}
+
+func (m *MockTokenRepository) RevokeFamily(familyID string) error {
+	return m.RevokeFamilyFunc(familyID)
+}
+
+func (m *MockTokenRepository) RevokeAllForUser(userID uint) error {
+	return m.RevokeAllForUserFunc(userID)
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(token *model.RevokedToken) error {
+	return m.RevokeAccessTokenFunc(token)
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	return m.IsAccessTokenRevokedFunc(jti)
+}
+
+func (m *MockTokenRepository) DeleteExpired(now time.Time) (int64, error) {
+	return m.DeleteExpiredFunc(now)
+}
+
+func (m *MockTokenRepository) WithContext(ctx context.Context) repository.TokenRepository {
+	return m
+}
+
 // =====================
 //
 //	Signup Test
@@ -87,9 +148,9 @@ func TestAuthService_Signup(t *testing.T) {
 				CreateFunc:      tt.mockCreate,
 			}
 
-			svc := service.NewAuthService(mockRepo)
+			svc := service.NewAuthService(mockRepo, &MockTokenRepository{})
 
-			err := svc.Signup(tt.email, tt.password)
+			err := svc.Signup(ctx, tt.email, tt.password)
 
 			if tt.expectErr && err == nil {
 				t.Errorf("expected error but got none")
@@ -159,9 +220,9 @@ func TestAuthService_Login(t *testing.T) {
 				FindByEmailFunc: tt.mockFind,
 			}
 
-			svc := service.NewAuthService(mockRepo)
+			svc := service.NewAuthService(mockRepo, &MockTokenRepository{})
 
-			_, err := svc.Login(tt.email, tt.password)
+			_, err := svc.Login(ctx, tt.email, tt.password)
 
 			if tt.expectErr && err == nil {
 				t.Errorf("expected error but got none")
@@ -173,3 +234,230 @@ func TestAuthService_Login(t *testing.T) {
 		})
 	}
 }
+
+// =====================
+//
+//	Refresh Test
+//
+// =====================
+func TestAuthService_Refresh(t *testing.T) {
+
+	jwt.Init("test-secret", time.Minute, time.Hour)
+
+	revoked := time.Now().Add(-time.Minute)
+	errDB := errors.New("database is locked")
+
+	tests := []struct {
+		name         string
+		mockFind     func(hash string) (*model.RefreshToken, error)
+		mockRotate   func(oldID uint, next *model.RefreshToken) error
+		expectErr    error
+		expectRevoke bool
+	}{
+		{
+			name: "success refresh",
+			mockFind: func(hash string) (*model.RefreshToken, error) {
+				return &model.RefreshToken{ID: 1, UserID: 1, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			mockRotate: func(oldID uint, next *model.RefreshToken) error {
+				if oldID != 1 || next.FamilyID != "fam" || next.UserID != 1 {
+					return errors.New("unexpected rotate args")
+				}
+				return nil
+			},
+			expectErr:    nil,
+			expectRevoke: false,
+		},
+		{
+			name: "unknown token",
+			mockFind: func(hash string) (*model.RefreshToken, error) {
+				return nil, gorm.ErrRecordNotFound
+			},
+			expectErr:    service.ErrInvalidRefreshToken,
+			expectRevoke: false,
+		},
+		{
+			// DB の障害はトークンの誤り（401）にしない
+			name: "db error",
+			mockFind: func(hash string) (*model.RefreshToken, error) {
+				return nil, errDB
+			},
+			expectErr:    errDB,
+			expectRevoke: false,
+		},
+		{
+			name: "expired token",
+			mockFind: func(hash string) (*model.RefreshToken, error) {
+				return &model.RefreshToken{ID: 1, UserID: 1, FamilyID: "fam", ExpiresAt: time.Now().Add(-time.Hour)}, nil
+			},
+			expectErr:    service.ErrInvalidRefreshToken,
+			expectRevoke: false,
+		},
+		{
+			name: "reused rotated token revokes family",
+			mockFind: func(hash string) (*model.RefreshToken, error) {
+				return &model.RefreshToken{ID: 1, UserID: 1, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revoked}, nil
+			},
+			expectErr:    service.ErrRefreshTokenReused,
+			expectRevoke: true,
+		},
+		{
+			name: "concurrent rotation revokes family",
+			mockFind: func(hash string) (*model.RefreshToken, error) {
+				return &model.RefreshToken{ID: 1, UserID: 1, FamilyID: "fam", ExpiresAt: time.Now().Add(time.Hour)}, nil
+			},
+			mockRotate: func(oldID uint, next *model.RefreshToken) error {
+				return repository.ErrRefreshTokenRotated
+			},
+			expectErr:    service.ErrRefreshTokenReused,
+			expectRevoke: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			revokedFamily := ""
+			mockTokenRepo := &MockTokenRepository{
+				FindRefreshTokenByHashFunc: tt.mockFind,
+				RotateRefreshTokenFunc:     tt.mockRotate,
+				RevokeFamilyFunc: func(familyID string) error {
+					revokedFamily = familyID
+					return nil
+				},
+			}
+
+			svc := service.NewAuthService(&MockUserRepository{}, mockTokenRepo)
+
+			pair, err := svc.Refresh(ctx, "refresh-token")
+
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr == nil && (pair == nil || pair.AccessToken == "" || pair.RefreshToken == "") {
+				t.Errorf("expected new token pair, got %+v", pair)
+			}
+			if tt.expectRevoke && revokedFamily != "fam" {
+				t.Errorf("expected family to be revoked")
+			}
+			if !tt.expectRevoke && revokedFamily != "" {
+				t.Errorf("family should not be revoked")
+			}
+		})
+	}
+}
+
+// =====================
+//
+//	Logout Test
+//
+// =====================
+func TestAuthService_Logout(t *testing.T) {
+
+	errDB := errors.New("database is locked")
+
+	tests := []struct {
+		name         string
+		userID       uint
+		mockSession  func(jti string) (*model.RefreshToken, error)
+		expectFamily string
+		expectErr    error
+	}{
+		{
+			name:   "revokes session family",
+			userID: 1,
+			mockSession: func(jti string) (*model.RefreshToken, error) {
+				return &model.RefreshToken{UserID: 1, FamilyID: "fam", AccessJTI: jti}, nil
+			},
+			expectFamily: "fam",
+		},
+		{
+			name:   "no session only revokes access token",
+			userID: 1,
+			mockSession: func(jti string) (*model.RefreshToken, error) {
+				return nil, gorm.ErrRecordNotFound
+			},
+			expectFamily: "",
+		},
+		{
+			name:   "db error fails logout",
+			userID: 1,
+			mockSession: func(jti string) (*model.RefreshToken, error) {
+				return nil, errDB
+			},
+			expectFamily: "",
+			expectErr:    errDB,
+		},
+		{
+			name:   "other user's session is not revoked",
+			userID: 2,
+			mockSession: func(jti string) (*model.RefreshToken, error) {
+				return &model.RefreshToken{UserID: 1, FamilyID: "fam", AccessJTI: jti}, nil
+			},
+			expectFamily: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			revokedFamily := ""
+			var revokedJTI string
+			mockTokenRepo := &MockTokenRepository{
+				FindRefreshTokenByAccessJTIFunc: tt.mockSession,
+				RevokeFamilyFunc: func(familyID string) error {
+					revokedFamily = familyID
+					return nil
+				},
+				RevokeAccessTokenFunc: func(token *model.RevokedToken) error {
+					revokedJTI = token.JTI
+					return nil
+				},
+			}
+
+			svc := service.NewAuthService(&MockUserRepository{}, mockTokenRepo)
+
+			err := svc.Logout(ctx, tt.userID, "jti-1", time.Now().Add(time.Minute))
+
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr != nil {
+				return
+			}
+			if revokedJTI != "jti-1" {
+				t.Errorf("access token should be revoked, got %q", revokedJTI)
+			}
+			if revokedFamily != tt.expectFamily {
+				t.Errorf("family mismatch: expected %q, got %q", tt.expectFamily, revokedFamily)
+			}
+		})
+	}
+}
+
+// =====================
+//
+//	PurgeExpired Test
+//
+// =====================
+func TestAuthService_PurgeExpired(t *testing.T) {
+
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	var at time.Time
+	mockTokenRepo := &MockTokenRepository{
+		DeleteExpiredFunc: func(n time.Time) (int64, error) {
+			at = n
+			return 4, nil
+		},
+	}
+
+	svc := service.NewAuthService(&MockUserRepository{}, mockTokenRepo)
+
+	purged, err := svc.PurgeExpired(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purged != 4 || !at.Equal(now) {
+		t.Errorf("expected 4 purged at %v, got %d at %v", now, purged, at)
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+// 一括操作 1 回あたりの操作数の上限
+const MaxBulkOperations = 100
+
+// 一括操作の種類
+const (
+	BulkCreate   = "create"
+	BulkUpdate   = "update"   // PUT と同じ（Done が nil なら完了状態は変えない）
+	BulkComplete = "complete" // 完了にする
+	BulkDelete   = "delete"   // 子孫も一緒に削除する
+)
+
+// 一括操作のモード
+const (
+	BulkAtomic  = "atomic"   // 1 つでも失敗したら全て取り消す
+	BulkPerItem = "per_item" // 失敗した操作だけ取り消して、残りは保存する
+)
+
+// BulkOperation は一括操作の 1 つ分
+type BulkOperation struct {
+	Op    string
+	ID    uint      // update / complete / delete
+	Input TodoInput // create / update
+	Done  *bool     // update
+	Pre   Precondition
+}
+
+// BulkResult は操作 1 つ分の結果（Err が nil なら成功）
+type BulkResult struct {
+	Op   string
+	ID   uint
+	Todo *model.Todo // create / update / complete の保存後の todo
+	Err  error
+}
+
+// BulkError は atomic モードで失敗した操作（Index は 0 始まり）
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// --- Bulk ---
+// ops を順に 1 つのトランザクションで実行する。
+// atomic では失敗した操作の *BulkError を返して全て取り消し、
+// per_item では操作ごとにセーブポイントを使い、失敗は BulkResult.Err で返す
+func (s *todoService) Bulk(ctx context.Context, userID uint, mode string, ops []BulkOperation) ([]BulkResult, error) {
+	if mode == "" {
+		mode = BulkAtomic
+	}
+	if mode != BulkAtomic && mode != BulkPerItem {
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrValidation, mode)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: operations are required", ErrValidation)
+	}
+	if len(ops) > MaxBulkOperations {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed", ErrValidation, MaxBulkOperations)
+	}
+
+	// イベントは保存が確定した操作の分だけ、コミットした後に通知する
+	results := make([]BulkResult, len(ops))
+	committed := &eventBuffer{}
+	err := s.todoRepo.Transaction(func(repo repository.TodoRepository) error {
+		for i, op := range ops {
+			results[i] = BulkResult{Op: op.Op, ID: op.ID}
+
+			events := &eventBuffer{}
+			err := repo.Transaction(func(repo repository.TodoRepository) error {
+				tx := &todoService{todoRepo: repo, projectRepo: s.projectRepo, events: events}
+				todo, err := tx.applyBulk(ctx, userID, op)
+				if err != nil {
+					return err
+				}
+				if todo != nil {
+					results[i].ID, results[i].Todo = todo.ID, todo
+				}
+				return nil
+			})
+			if err != nil {
+				if mode == BulkAtomic {
+					return &BulkError{Index: i, Err: err}
+				}
+				results[i].Err = err
+				continue
+			}
+			events.flush(committed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed.flush(s.events)
+	return results, nil
+}
+
+func (s *todoService) applyBulk(ctx context.Context, userID uint, op BulkOperation) (*model.Todo, error) {
+	switch op.Op {
+	case BulkCreate:
+		return s.create(userID, op.Input)
+	case BulkUpdate:
+		return s.Update(ctx, userID, op.ID, op.Input, op.Done, op.Pre)
+	case BulkComplete:
+		todo, err := s.todoRepo.FindByID(userID, op.ID)
+		if err != nil {
+			return nil, ErrTodoNotFound
+		}
+		if err := s.checkPrecondition(userID, todo, op.Pre); err != nil {
+			return nil, err
+		}
+		// 完了済みなら何もしない（Version を上げず、更新も通知しない）
+		if todo.Done {
+			return todo, nil
+		}
+		done := true
+		return s.saveTodo(userID, todo, &done)
+	case BulkDelete:
+		return nil, s.Delete(ctx, userID, op.ID, op.Pre)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrValidation, op.Op)
+	}
+}
@@ -0,0 +1,174 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+)
+
+// --- Bulk ---
+func TestTodoService_Bulk(t *testing.T) {
+
+	ops := []service.BulkOperation{
+		{Op: service.BulkCreate, Input: service.TodoInput{Title: "new"}},
+		{Op: service.BulkComplete, ID: 1},
+		{Op: service.BulkUpdate, ID: 99, Input: service.TodoInput{Title: "missing"}},
+		{Op: service.BulkDelete, ID: 2},
+	}
+
+	tests := []struct {
+		name        string
+		mode        string
+		ops         []service.BulkOperation
+		expectErr   error
+		expectIndex int    // atomic で失敗した操作
+		expectErrs  []bool // per_item で失敗した操作
+	}{
+		{name: "per item", mode: service.BulkPerItem, ops: ops, expectErrs: []bool{false, false, true, false}},
+		{name: "atomic stops at failure", mode: service.BulkAtomic, ops: ops, expectErr: service.ErrTodoNotFound, expectIndex: 2},
+		{name: "default is atomic", mode: "", ops: ops, expectErr: service.ErrTodoNotFound, expectIndex: 2},
+		{name: "atomic success", mode: service.BulkAtomic, ops: ops[:2], expectErrs: []bool{false, false}},
+		{name: "per item delete of missing todo", mode: service.BulkPerItem, ops: []service.BulkOperation{{Op: service.BulkDelete, ID: 98}}, expectErrs: []bool{true}},
+		{name: "atomic delete of missing todo", mode: service.BulkAtomic, ops: []service.BulkOperation{ops[0], {Op: service.BulkDelete, ID: 98}}, expectErr: service.ErrTodoNotFound, expectIndex: 1},
+		{name: "unknown op", mode: service.BulkPerItem, ops: []service.BulkOperation{{Op: "archive", ID: 1}}, expectErrs: []bool{true}},
+		{name: "unknown mode", mode: "best_effort", ops: ops, expectErr: service.ErrValidation},
+		{name: "no operations", mode: service.BulkAtomic, ops: nil, expectErr: service.ErrValidation},
+		{name: "too many operations", mode: service.BulkAtomic, ops: make([]service.BulkOperation, service.MaxBulkOperations+1), expectErr: service.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// 外側のトランザクションと、操作ごとのセーブポイント
+			transactions := 0
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					if id == 99 {
+						return nil, errors.New("record not found")
+					}
+					todo := &model.Todo{UserID: userID, Title: "old", Version: 1}
+					todo.ID = id
+					return todo, nil
+				},
+				CreateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					todo.ID = 10
+					return todo, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+				DeleteFunc: func(userID uint, id uint, version uint) error {
+					if id == 98 {
+						return repository.ErrTodoNotFound
+					}
+					return nil
+				},
+			}
+			mockRepo.TransactionFunc = func(fn func(repo repository.TodoRepository) error) error {
+				transactions++
+				return fn(mockRepo)
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			results, err := svc.Bulk(ctx, 1, tt.mode, tt.ops)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				var bulkErr *service.BulkError
+				if errors.As(err, &bulkErr) && bulkErr.Index != tt.expectIndex {
+					t.Errorf("expected index %d, got %d", tt.expectIndex, bulkErr.Index)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(results) != len(tt.expectErrs) {
+				t.Fatalf("expected %d results, got %d", len(tt.expectErrs), len(results))
+			}
+			for i, result := range results {
+				if (result.Err != nil) != tt.expectErrs[i] {
+					t.Errorf("result %d: expected error=%v, got %v", i, tt.expectErrs[i], result.Err)
+				}
+			}
+			if transactions != len(tt.ops)+1 {
+				t.Errorf("expected %d transactions, got %d", len(tt.ops)+1, transactions)
+			}
+		})
+	}
+}
+
+// complete は保存後の todo を返し、作成した todo の id を結果に入れる
+func TestTodoService_Bulk_Results(t *testing.T) {
+
+	mockRepo := &MockTodoRepository{
+		FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+			todo := &model.Todo{UserID: userID, Title: "old", Version: 1}
+			todo.ID = id
+			return todo, nil
+		},
+		CreateFunc: func(todo *model.Todo) (*model.Todo, error) {
+			todo.ID = 10
+			return todo, nil
+		},
+		UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+			todo.Version++
+			return todo, nil
+		},
+	}
+
+	svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+	results, err := svc.Bulk(ctx, 1, service.BulkAtomic, []service.BulkOperation{
+		{Op: service.BulkCreate, Input: service.TodoInput{Title: "new"}},
+		{Op: service.BulkComplete, ID: 3, Pre: service.Precondition{IfMatch: []string{`"1"`}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if results[0].ID != 10 || results[0].Todo.Title != "new" {
+		t.Errorf("unexpected create result: %+v", results[0])
+	}
+	if results[1].ID != 3 || !results[1].Todo.Done || results[1].Todo.CompletedAt == nil || service.TodoETag(results[1].Todo) != `"2"` {
+		t.Errorf("unexpected complete result: %+v", results[1].Todo)
+	}
+}
+
+// 完了済みの todo の complete は保存も通知もしない
+func TestTodoService_Bulk_CompleteDone(t *testing.T) {
+
+	completedAt := time.Now().Add(-time.Hour)
+	mockRepo := &MockTodoRepository{
+		FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+			todo := &model.Todo{UserID: userID, Title: "done", Done: true, CompletedAt: &completedAt, Version: 4}
+			todo.ID = id
+			return todo, nil
+		},
+		UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+			t.Errorf("unexpected update: %+v", todo)
+			return todo, nil
+		},
+	}
+	events := &MockEventPublisher{}
+	svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, events)
+
+	results, err := svc.Bulk(ctx, 1, service.BulkPerItem, []service.BulkOperation{{Op: service.BulkComplete, ID: 3}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Err != nil || results[0].Todo.Version != 4 || !results[0].Todo.CompletedAt.Equal(completedAt) {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+	if len(events.events) != 0 || events.completed != 0 {
+		t.Errorf("expected no events, got %v (completed %d)", events.types(), events.completed)
+	}
+}
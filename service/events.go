@@ -0,0 +1,123 @@
+package service
+
+import (
+	"github.com/a5415091-collab/go-gin-todo-app/event"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+)
+
+// EventPublisher は todo の変更を通知する（event.Bus が実装）
+type EventPublisher interface {
+	Publish(userID uint, typ string, data any)
+}
+
+// TodoCounter は作成・完了した todo の数を受け取る（metrics.Todos が実装）。
+// EventPublisher のうちこれも実装するものにだけ、イベントと同じく保存が確定してから送る
+type TodoCounter interface {
+	TodosCreated(n int)
+	TodosCompleted(n int)
+}
+
+// EventPublishers は同じイベントを全ての EventPublisher に通知する
+type EventPublishers []EventPublisher
+
+func (p EventPublishers) Publish(userID uint, typ string, data any) {
+	for _, publisher := range p {
+		publisher.Publish(userID, typ, data)
+	}
+}
+
+func (p EventPublishers) TodosCreated(n int) {
+	for _, publisher := range p {
+		if counter, ok := publisher.(TodoCounter); ok {
+			counter.TodosCreated(n)
+		}
+	}
+}
+
+func (p EventPublishers) TodosCompleted(n int) {
+	for _, publisher := range p {
+		if counter, ok := publisher.(TodoCounter); ok {
+			counter.TodosCompleted(n)
+		}
+	}
+}
+
+// DeletedTodo は todo.deleted イベントの data（サブタスクも一緒に削除されている）
+type DeletedTodo struct {
+	ID uint `json:"id"`
+}
+
+// publish は todos のイベントを通知する。
+// 送った後に書き換えられないように、todo はコピーを渡す
+func (s *todoService) publish(userID uint, typ string, todos ...*model.Todo) {
+	if s.events == nil {
+		return
+	}
+	for _, todo := range todos {
+		s.events.Publish(userID, typ, *todo)
+	}
+}
+
+// countCreated / countCompleted は作成・完了した todo を数える（events が TodoCounter なら）
+func (s *todoService) countCreated(n int) {
+	if counter, ok := s.events.(TodoCounter); ok && n > 0 {
+		counter.TodosCreated(n)
+	}
+}
+
+func (s *todoService) countCompleted(todos ...*model.Todo) {
+	n := 0
+	for _, todo := range todos {
+		if todo.Done {
+			n++
+		}
+	}
+	if counter, ok := s.events.(TodoCounter); ok && n > 0 {
+		counter.TodosCompleted(n)
+	}
+}
+
+func (s *todoService) publishDeleted(userID uint, id uint) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(userID, event.TodoDeleted, DeletedTodo{ID: id})
+}
+
+// eventBuffer はトランザクション内の todoService のイベントを、保存が確定するまで溜めておく
+type eventBuffer struct {
+	events    []bufferedEvent
+	created   int
+	completed int
+}
+
+type bufferedEvent struct {
+	userID uint
+	typ    string
+	data   any
+}
+
+func (b *eventBuffer) Publish(userID uint, typ string, data any) {
+	b.events = append(b.events, bufferedEvent{userID, typ, data})
+}
+
+func (b *eventBuffer) TodosCreated(n int)   { b.created += n }
+func (b *eventBuffer) TodosCompleted(n int) { b.completed += n }
+
+// flush は溜めたイベントを to に送る
+func (b *eventBuffer) flush(to EventPublisher) {
+	if to == nil {
+		return
+	}
+	for _, ev := range b.events {
+		to.Publish(ev.userID, ev.typ, ev.data)
+	}
+	if counter, ok := to.(TodoCounter); ok {
+		if b.created > 0 {
+			counter.TodosCreated(b.created)
+		}
+		if b.completed > 0 {
+			counter.TodosCompleted(b.completed)
+		}
+	}
+}
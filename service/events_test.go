@@ -0,0 +1,205 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/event"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+)
+
+// --- Mock ---
+type publishedEvent struct {
+	userID uint
+	typ    string
+	data   any
+}
+
+type MockEventPublisher struct {
+	events    []publishedEvent
+	created   int
+	completed int
+}
+
+func (m *MockEventPublisher) Publish(userID uint, typ string, data any) {
+	m.events = append(m.events, publishedEvent{userID, typ, data})
+}
+
+func (m *MockEventPublisher) TodosCreated(n int)   { m.created += n }
+func (m *MockEventPublisher) TodosCompleted(n int) { m.completed += n }
+
+func (m *MockEventPublisher) types() []string {
+	var types []string
+	for _, ev := range m.events {
+		types = append(types, ev.typ)
+	}
+	return types
+}
+
+// --- イベントの通知 ---
+func TestTodoService_Events(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		run         func(svc service.TodoService) error
+		expectTypes []string
+		// TodoCounter に送った作成・完了の数
+		expectCreated   int
+		expectCompleted int
+	}{
+		{
+			name: "create",
+			run: func(svc service.TodoService) error {
+				_, err := svc.Create(ctx, 1, service.TodoInput{Title: "new"})
+				return err
+			},
+			expectTypes:   []string{event.TodoCreated},
+			expectCreated: 1,
+		},
+		{
+			name: "update",
+			run: func(svc service.TodoService) error {
+				_, err := svc.Update(ctx, 1, 1, service.TodoInput{Title: "changed"}, nil, service.Precondition{})
+				return err
+			},
+			expectTypes: []string{event.TodoUpdated},
+		},
+		{
+			name: "complete",
+			run: func(svc service.TodoService) error {
+				done := true
+				_, err := svc.Update(ctx, 1, 1, service.TodoInput{Title: "old"}, &done, service.Precondition{})
+				return err
+			},
+			expectTypes:     []string{event.TodoUpdated},
+			expectCompleted: 1,
+		},
+		{
+			name: "delete",
+			run: func(svc service.TodoService) error {
+				return svc.Delete(ctx, 1, 1, service.Precondition{})
+			},
+			expectTypes: []string{event.TodoDeleted},
+		},
+		{
+			name: "delete of missing todo publishes nothing",
+			run: func(svc service.TodoService) error {
+				return svc.Delete(ctx, 1, 98, service.Precondition{})
+			},
+		},
+		{
+			name: "failed update publishes nothing",
+			run: func(svc service.TodoService) error {
+				_, err := svc.Update(ctx, 1, 99, service.TodoInput{Title: "changed"}, nil, service.Precondition{})
+				return err
+			},
+		},
+		{
+			name: "bulk publishes after commit",
+			run: func(svc service.TodoService) error {
+				_, err := svc.Bulk(ctx, 1, service.BulkAtomic, []service.BulkOperation{
+					{Op: service.BulkCreate, Input: service.TodoInput{Title: "new"}},
+					{Op: service.BulkDelete, ID: 2},
+				})
+				return err
+			},
+			expectTypes:   []string{event.TodoCreated, event.TodoDeleted},
+			expectCreated: 1,
+		},
+		{
+			name: "rolled back bulk publishes nothing",
+			run: func(svc service.TodoService) error {
+				_, err := svc.Bulk(ctx, 1, service.BulkAtomic, []service.BulkOperation{
+					{Op: service.BulkCreate, Input: service.TodoInput{Title: "new"}},
+					{Op: service.BulkDelete, ID: 99},
+				})
+				return err
+			},
+		},
+		{
+			name: "failed ancestor sync after create publishes nothing",
+			run: func(svc service.TodoService) error {
+				parentID := uint(3)
+				_, err := svc.Create(ctx, 1, service.TodoInput{Title: "new", ParentID: &parentID})
+				return err
+			},
+		},
+		{
+			name: "failed ancestor sync after move publishes nothing",
+			run: func(svc service.TodoService) error {
+				parentID := uint(3)
+				_, err := svc.Move(ctx, 1, 5, &parentID)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					if id == 99 {
+						return nil, errors.New("record not found")
+					}
+					todo := &model.Todo{UserID: userID, Title: "old", Version: 1}
+					todo.ID = id
+					return todo, nil
+				},
+				CreateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					todo.ID = 10
+					return todo, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+				DeleteFunc: func(userID uint, id uint, version uint) error {
+					if id == 99 || id == 98 {
+						return repository.ErrTodoNotFound
+					}
+					return nil
+				},
+				// 作成した todo（10）と移した todo（5）の祖先は自動完了の 3 で、子を読めずに失敗する
+				FindAncestorsFunc: func(userID uint, id uint) ([]model.Todo, error) {
+					if id != 10 && id != 5 {
+						return nil, nil
+					}
+					parent := model.Todo{UserID: userID, AutoComplete: true, Done: true}
+					parent.ID = 3
+					return []model.Todo{parent}, nil
+				},
+				FindChildrenFunc: func(userID uint, parentID uint) ([]model.Todo, error) {
+					return nil, errors.New("db error")
+				},
+			}
+			mockRepo.TransactionFunc = func(fn func(repo repository.TodoRepository) error) error {
+				return fn(mockRepo)
+			}
+
+			events := &MockEventPublisher{}
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, events)
+
+			_ = tt.run(svc)
+
+			if events.created != tt.expectCreated || events.completed != tt.expectCompleted {
+				t.Errorf("expected created=%d completed=%d, got %d / %d",
+					tt.expectCreated, tt.expectCompleted, events.created, events.completed)
+			}
+
+			types := events.types()
+			if len(types) != len(tt.expectTypes) {
+				t.Fatalf("expected %v, got %v", tt.expectTypes, types)
+			}
+			for i := range types {
+				if types[i] != tt.expectTypes[i] {
+					t.Errorf("expected %v, got %v", tt.expectTypes, types)
+				}
+				if events.events[i].userID != 1 {
+					t.Errorf("expected user 1, got %d", events.events[i].userID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"gorm.io/gorm"
+)
+
+// Idempotency-Key の長さの上限
+const MaxIdempotencyKeyLength = 255
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService は Idempotency-Key ごとに最初のリクエストの応答を保存して、再送に同じ応答を返す
+type IdempotencyService interface {
+	Begin(ctx context.Context, userID uint, key string, fingerprint string) (*model.IdempotencyKey, error)
+	Complete(ctx context.Context, record *model.IdempotencyKey, status int, contentType string, headers model.Headers, body []byte) error
+	Release(ctx context.Context, record *model.IdempotencyKey) error
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+type idempotencyService struct {
+	repo      repository.IdempotencyRepository
+	retention time.Duration
+}
+
+// retention は応答を保存しておく期間（過ぎたキーは新しいリクエストとして扱う）
+func NewIdempotencyService(repo repository.IdempotencyRepository, retention time.Duration) IdempotencyService {
+	return &idempotencyService{repo, retention}
+}
+
+func (s *idempotencyService) with(ctx context.Context) *idempotencyService {
+	return &idempotencyService{s.repo.WithContext(ctx), s.retention}
+}
+
+// --- Begin ---
+// キーを処理中として保存する。
+// 既に完了したリクエストのキーなら保存済みの応答（StatusCode が 0 以外）を返し、
+// 別のリクエストに使われていれば ErrIdempotencyKeyReused、処理中なら ErrIdempotencyKeyInProgress を返す
+func (s *idempotencyService) Begin(ctx context.Context, userID uint, key string, fingerprint string) (*model.IdempotencyKey, error) {
+	s = s.with(ctx)
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: idempotency key must be 1-%d characters", ErrValidation, MaxIdempotencyKeyLength)
+	}
+	for _, r := range key {
+		if r < 0x20 || r > 0x7e {
+			return nil, fmt.Errorf("%w: idempotency key must be printable ASCII", ErrValidation)
+		}
+	}
+
+	record := &model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint}
+	err := s.repo.Create(record)
+	if !errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		if err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+
+	existing, err := s.repo.Find(userID, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 見つけるまでの間に削除された
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		// DB の障害を処理中（409）と返すと、クライアントが壊れた DB に再送し続けるので、そのまま返す（500）
+		return nil, err
+	}
+
+	// 保存期間を過ぎたキーは削除して新しいリクエストとして扱う
+	if existing.CreatedAt.Before(time.Now().Add(-s.retention)) {
+		if err := s.repo.Delete(existing.ID); err != nil {
+			return nil, err
+		}
+		if err := s.repo.Create(record); err != nil {
+			if errors.Is(err, repository.ErrIdempotencyKeyExists) {
+				return nil, ErrIdempotencyKeyInProgress
+			}
+			return nil, err
+		}
+		return record, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return existing, nil
+}
+
+// --- Complete ---
+// Begin で保存したキーに応答（headers は再送にも返すヘッダー）を保存する
+func (s *idempotencyService) Complete(ctx context.Context, record *model.IdempotencyKey, status int, contentType string, headers model.Headers, body []byte) error {
+	s = s.with(ctx)
+	record.StatusCode = status
+	record.ContentType = contentType
+	record.ResponseHeaders = headers
+	record.ResponseBody = body
+	return s.repo.Complete(record)
+}
+
+// --- Release ---
+// 応答を保存せずにキーを削除する（サーバーエラーなど、再送で処理し直すべき場合）
+func (s *idempotencyService) Release(ctx context.Context, record *model.IdempotencyKey) error {
+	s = s.with(ctx)
+	return s.repo.Delete(record.ID)
+}
+
+// --- PurgeExpired ---
+// 全ユーザーの、保存期間を過ぎたキーを削除する（定期実行用）
+func (s *idempotencyService) PurgeExpired(now time.Time) (int64, error) {
+	return s.repo.DeleteBefore(now.Add(-s.retention))
+}
@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"gorm.io/gorm"
+)
+
+// --- Mock ---
+type MockIdempotencyRepository struct {
+	CreateFunc       func(record *model.IdempotencyKey) error
+	FindFunc         func(userID uint, key string) (*model.IdempotencyKey, error)
+	CompleteFunc     func(record *model.IdempotencyKey) error
+	DeleteFunc       func(id uint) error
+	DeleteBeforeFunc func(before time.Time) (int64, error)
+}
+
+func (m *MockIdempotencyRepository) Create(record *model.IdempotencyKey) error {
+	return m.CreateFunc(record)
+}
+
+func (m *MockIdempotencyRepository) Find(userID uint, key string) (*model.IdempotencyKey, error) {
+	return m.FindFunc(userID, key)
+}
+
+func (m *MockIdempotencyRepository) Complete(record *model.IdempotencyKey) error {
+	return m.CompleteFunc(record)
+}
+
+func (m *MockIdempotencyRepository) Delete(id uint) error {
+	return m.DeleteFunc(id)
+}
+
+func (m *MockIdempotencyRepository) DeleteBefore(before time.Time) (int64, error) {
+	return m.DeleteBeforeFunc(before)
+}
+
+func (m *MockIdempotencyRepository) WithContext(ctx context.Context) repository.IdempotencyRepository {
+	return m
+}
+
+// --- Begin ---
+func TestIdempotencyService_Begin(t *testing.T) {
+
+	now := time.Now()
+	errDB := errors.New("database is locked")
+
+	tests := []struct {
+		name          string
+		key           string
+		existing      *model.IdempotencyKey // nil ならキーは未使用
+		findErr       error
+		expectErr     error
+		expectReplay  bool
+		expectDeleted bool
+	}{
+		{name: "new key", key: "k1"},
+		{name: "completed request", key: "k1", existing: &model.IdempotencyKey{ID: 5, Fingerprint: "fp", StatusCode: 201, CreatedAt: now}, expectReplay: true},
+		{name: "different request", key: "k1", existing: &model.IdempotencyKey{ID: 5, Fingerprint: "other", StatusCode: 201, CreatedAt: now}, expectErr: service.ErrIdempotencyKeyReused},
+		{name: "in progress", key: "k1", existing: &model.IdempotencyKey{ID: 5, Fingerprint: "fp", CreatedAt: now}, expectErr: service.ErrIdempotencyKeyInProgress},
+		{name: "deleted before find", key: "k1", existing: &model.IdempotencyKey{ID: 5}, findErr: gorm.ErrRecordNotFound, expectErr: service.ErrIdempotencyKeyInProgress},
+		{name: "find fails", key: "k1", existing: &model.IdempotencyKey{ID: 5}, findErr: errDB, expectErr: errDB},
+		{name: "expired key is reused", key: "k1", existing: &model.IdempotencyKey{ID: 5, Fingerprint: "other", StatusCode: 201, CreatedAt: now.Add(-25 * time.Hour)}, expectDeleted: true},
+		{name: "empty key", key: "", expectErr: service.ErrValidation},
+		{name: "too long key", key: strings.Repeat("k", service.MaxIdempotencyKeyLength+1), expectErr: service.ErrValidation},
+		{name: "non ascii key", key: "キー", expectErr: service.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			existing := tt.existing
+			deleted := false
+			mockRepo := &MockIdempotencyRepository{
+				CreateFunc: func(record *model.IdempotencyKey) error {
+					if existing != nil {
+						return repository.ErrIdempotencyKeyExists
+					}
+					record.ID = 9
+					return nil
+				},
+				FindFunc: func(userID uint, key string) (*model.IdempotencyKey, error) {
+					if tt.findErr != nil {
+						return nil, tt.findErr
+					}
+					return existing, nil
+				},
+				DeleteFunc: func(id uint) error {
+					deleted, existing = true, nil
+					return nil
+				},
+			}
+
+			svc := service.NewIdempotencyService(mockRepo, 24*time.Hour)
+
+			record, err := svc.Begin(ctx, 1, tt.key, "fp")
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if replay := record.StatusCode != 0; replay != tt.expectReplay {
+				t.Errorf("expected replay=%v, got %+v", tt.expectReplay, record)
+			}
+			if !tt.expectReplay && (record.ID != 9 || record.UserID != 1 || record.Fingerprint != "fp") {
+				t.Errorf("expected new record, got %+v", record)
+			}
+			if deleted != tt.expectDeleted {
+				t.Errorf("expected deleted=%v, got %v", tt.expectDeleted, deleted)
+			}
+		})
+	}
+}
+
+// --- Complete / PurgeExpired ---
+func TestIdempotencyService_Complete(t *testing.T) {
+
+	var saved *model.IdempotencyKey
+	var purgedBefore time.Time
+	mockRepo := &MockIdempotencyRepository{
+		CompleteFunc: func(record *model.IdempotencyKey) error {
+			saved = record
+			return nil
+		},
+		DeleteBeforeFunc: func(before time.Time) (int64, error) {
+			purgedBefore = before
+			return 3, nil
+		},
+	}
+
+	svc := service.NewIdempotencyService(mockRepo, 24*time.Hour)
+
+	record := &model.IdempotencyKey{ID: 9}
+	if err := svc.Complete(ctx, record, 201, "application/json", model.Headers{"ETag": `"1-1"`}, []byte(`{"ID":1}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved != record || saved.StatusCode != 201 || saved.ContentType != "application/json" ||
+		saved.ResponseHeaders["ETag"] != `"1-1"` || string(saved.ResponseBody) != `{"ID":1}` {
+		t.Errorf("unexpected saved record: %+v", saved)
+	}
+
+	now := time.Now()
+	purged, err := svc.PurgeExpired(now)
+	if err != nil || purged != 3 {
+		t.Fatalf("unexpected purge result: %d, %v", purged, err)
+	}
+	if !purgedBefore.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("expected cutoff %s, got %s", now.Add(-24*time.Hour), purgedBefore)
+	}
+}
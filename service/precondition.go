@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+var (
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrTodoModified       = repository.ErrTodoModified // 読み込んだ後に他のリクエストで更新された
+)
+
+// Precondition は条件付きリクエスト（If-Match / If-None-Match）の ETag の一覧。
+// nil なら条件なしで、"*" は存在するどの表現とも一致する
+type Precondition struct {
+	IfMatch     []string
+	IfNoneMatch []string
+}
+
+// TodoETag は todo の表現の ETag。
+// 保存のたびに増える Version と、子孫から計算する Progress（FindByID などで設定済み）から作る
+func TodoETag(todo *model.Todo) string {
+	if todo.Progress != nil {
+		return fmt.Sprintf(`"%d-%d"`, todo.Version, *todo.Progress)
+	}
+	return fmt.Sprintf(`"%d"`, todo.Version)
+}
+
+// Check は etag の表現が条件を満たさなければ ErrPreconditionFailed を返す。
+// If-Match は強い比較（W/ の ETag とは一致しない）、If-None-Match は弱い比較
+func (p Precondition) Check(etag string) error {
+	if p.IfMatch != nil && !matchETag(p.IfMatch, etag, false) {
+		return ErrPreconditionFailed
+	}
+	if p.IfNoneMatch != nil && matchETag(p.IfNoneMatch, etag, true) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+func (p Precondition) empty() bool {
+	return p.IfMatch == nil && p.IfNoneMatch == nil
+}
+
+func matchETag(tags []string, etag string, weak bool) bool {
+	for _, tag := range tags {
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if tag == etag && !strings.HasPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+)
+
+// --- Check ---
+func TestPrecondition_Check(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		pre       service.Precondition
+		expectErr bool
+	}{
+		{name: "no condition", pre: service.Precondition{}},
+		{name: "if-match same", pre: service.Precondition{IfMatch: []string{`"2"`, `"3"`}}},
+		{name: "if-match other", pre: service.Precondition{IfMatch: []string{`"2"`}}, expectErr: true},
+		{name: "if-match any", pre: service.Precondition{IfMatch: []string{"*"}}},
+		{name: "if-match weak never matches", pre: service.Precondition{IfMatch: []string{`W/"3"`}}, expectErr: true},
+		{name: "if-none-match same", pre: service.Precondition{IfNoneMatch: []string{`"3"`}}, expectErr: true},
+		{name: "if-none-match weak compares", pre: service.Precondition{IfNoneMatch: []string{`W/"3"`}}, expectErr: true},
+		{name: "if-none-match other", pre: service.Precondition{IfNoneMatch: []string{`"2"`}}},
+		{name: "if-none-match any", pre: service.Precondition{IfNoneMatch: []string{"*"}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			err := tt.pre.Check(`"3"`)
+
+			if tt.expectErr != errors.Is(err, service.ErrPreconditionFailed) {
+				t.Errorf("expected error=%v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+// 子孫の完了率が変わると ETag も変わる
+func TestTodoETag(t *testing.T) {
+
+	todo := &model.Todo{Version: 3}
+	if etag := service.TodoETag(todo); etag != `"3"` {
+		t.Errorf("unexpected etag: %s", etag)
+	}
+
+	todo.Progress = ptrInt(50)
+	if etag := service.TodoETag(todo); etag != `"3-50"` {
+		t.Errorf("unexpected etag: %s", etag)
+	}
+}
+
+// --- Update (If-Match) ---
+func TestTodoService_Update_Precondition(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		ifMatch   []string
+		updateErr error
+		expectErr error
+	}{
+		{name: "matching etag", ifMatch: []string{`"3"`}},
+		{name: "stale etag", ifMatch: []string{`"2"`}, expectErr: service.ErrPreconditionFailed},
+		{name: "modified after check", ifMatch: []string{`"3"`}, updateErr: repository.ErrTodoModified, expectErr: service.ErrTodoModified},
+		{name: "unconditional", ifMatch: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			updated := false
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					todo := &model.Todo{UserID: userID, Title: "old", Version: 3}
+					todo.ID = id
+					return todo, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					if tt.updateErr != nil {
+						return nil, tt.updateErr
+					}
+					updated = true
+					todo.Version++
+					return todo, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Update(ctx, 1, 1, service.TodoInput{Title: "new"}, nil, service.Precondition{IfMatch: tt.ifMatch})
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !updated || service.TodoETag(todo) != `"4"` {
+				t.Errorf("expected new version, got %+v", todo)
+			}
+		})
+	}
+}
+
+// --- Delete (If-Match) ---
+// 条件付きなら確認した Version をリポジトリに渡す
+func TestTodoService_Delete_Precondition(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		pre           service.Precondition
+		expectErr     error
+		expectVersion uint
+		expectDeleted bool
+	}{
+		{name: "unconditional", pre: service.Precondition{}, expectVersion: 0, expectDeleted: true},
+		{name: "matching etag", pre: service.Precondition{IfMatch: []string{`"7"`}}, expectVersion: 7, expectDeleted: true},
+		{name: "stale etag", pre: service.Precondition{IfMatch: []string{`"6"`}}, expectErr: service.ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			deleted := false
+			var gotVersion uint
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					return &model.Todo{UserID: userID, Version: 7}, nil
+				},
+				DeleteFunc: func(userID uint, id uint, version uint) error {
+					deleted, gotVersion = true, version
+					return nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			err := svc.Delete(ctx, 1, 1, tt.pre)
+
+			if tt.expectErr != nil && !errors.Is(err, tt.expectErr) {
+				t.Errorf("expected %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if deleted != tt.expectDeleted || gotVersion != tt.expectVersion {
+				t.Errorf("expected deleted=%v version=%d, got deleted=%v version=%d", tt.expectDeleted, tt.expectVersion, deleted, gotVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+
+var projectColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ProjectInput は作成・更新時の入力
+type ProjectInput struct {
+	Name     string
+	Color    string // "#RRGGBB"、空なら指定なし
+	Position *int   // 更新時のみ。nil なら並び順を変えない
+}
+
+type ProjectService interface {
+	FindAll(ctx context.Context, userID uint, includeArchived bool) ([]model.Project, error)
+	FindByID(ctx context.Context, userID uint, id uint) (*model.Project, error)
+	Create(ctx context.Context, userID uint, input ProjectInput) (*model.Project, error)
+	Update(ctx context.Context, userID uint, id uint, input ProjectInput) (*model.Project, error)
+	Archive(ctx context.Context, userID uint, id uint, completeTodos bool) (*model.Project, error)
+	Unarchive(ctx context.Context, userID uint, id uint) (*model.Project, error)
+	Delete(ctx context.Context, userID uint, id uint, todos string) error
+}
+
+type projectService struct {
+	projectRepo repository.ProjectRepository
+}
+
+func NewProjectService(projectRepo repository.ProjectRepository) ProjectService {
+	return &projectService{projectRepo}
+}
+
+// with は ctx を付けたリポジトリを使う projectService を返す（クエリのログと span をリクエストに紐付ける）
+func (s *projectService) with(ctx context.Context) *projectService {
+	return &projectService{s.projectRepo.WithContext(ctx)}
+}
+
+// --- FindAll ---
+func (s *projectService) FindAll(ctx context.Context, userID uint, includeArchived bool) ([]model.Project, error) {
+	s = s.with(ctx)
+	projects, err := s.projectRepo.FindAll(userID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	if projects == nil {
+		projects = []model.Project{}
+	}
+	return projects, nil
+}
+
+// --- FindByID ---
+func (s *projectService) FindByID(ctx context.Context, userID uint, id uint) (*model.Project, error) {
+	s = s.with(ctx)
+	project, err := s.projectRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// --- Create ---
+func (s *projectService) Create(ctx context.Context, userID uint, input ProjectInput) (*model.Project, error) {
+	s = s.with(ctx)
+	if err := validateProjectInput(input); err != nil {
+		return nil, err
+	}
+
+	project := &model.Project{
+		UserID: userID,
+		Name:   input.Name,
+		Color:  strings.ToLower(input.Color),
+	}
+	return s.projectRepo.Create(project)
+}
+
+// --- Update ---
+func (s *projectService) Update(ctx context.Context, userID uint, id uint, input ProjectInput) (*model.Project, error) {
+	s = s.with(ctx)
+	if err := validateProjectInput(input); err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+
+	project.Name = input.Name
+	project.Color = strings.ToLower(input.Color)
+	if _, err := s.projectRepo.Update(project); err != nil {
+		return nil, err
+	}
+
+	if input.Position != nil && *input.Position != project.Position {
+		if err := s.projectRepo.Move(project, *input.Position); err != nil {
+			return nil, err
+		}
+	}
+	return project, nil
+}
+
+// --- Archive ---
+// アーカイブしたプロジェクトの Todo はそのまま残るが、新しく Todo を追加・移動できなくなる
+func (s *projectService) Archive(ctx context.Context, userID uint, id uint, completeTodos bool) (*model.Project, error) {
+	s = s.with(ctx)
+	project, err := s.projectRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.ArchivedAt != nil {
+		return project, nil
+	}
+
+	if err := s.projectRepo.Archive(project, completeTodos); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// --- Unarchive ---
+func (s *projectService) Unarchive(ctx context.Context, userID uint, id uint) (*model.Project, error) {
+	s = s.with(ctx)
+	project, err := s.projectRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.ArchivedAt == nil {
+		return project, nil
+	}
+
+	if err := s.projectRepo.Unarchive(project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// --- Delete ---
+// todos は repository.ProjectTodos*（空なら未分類に戻す）
+func (s *projectService) Delete(ctx context.Context, userID uint, id uint, todos string) error {
+	s = s.with(ctx)
+	if todos == "" {
+		todos = repository.ProjectTodosUnassign
+	}
+	if todos != repository.ProjectTodosUnassign && todos != repository.ProjectTodosDelete {
+		return fmt.Errorf("%w: todos must be unassign or delete", ErrValidation)
+	}
+
+	project, err := s.projectRepo.FindByID(userID, id)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	return s.projectRepo.Delete(project, todos)
+}
+
+func validateProjectInput(input ProjectInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if input.Color != "" && !projectColorPattern.MatchString(input.Color) {
+		return fmt.Errorf("%w: color must be #RRGGBB", ErrValidation)
+	}
+	if input.Position != nil && *input.Position < 0 {
+		return fmt.Errorf("%w: position must not be negative", ErrValidation)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+)
+
+// --- Mock Repository 定義 ---
+type MockProjectRepository struct {
+	FindAllFunc   func(userID uint, includeArchived bool) ([]model.Project, error)
+	FindByIDFunc  func(userID uint, id uint) (*model.Project, error)
+	CreateFunc    func(project *model.Project) (*model.Project, error)
+	UpdateFunc    func(project *model.Project) (*model.Project, error)
+	MoveFunc      func(project *model.Project, position int) error
+	ArchiveFunc   func(project *model.Project, completeTodos bool) error
+	UnarchiveFunc func(project *model.Project) error
+	DeleteFunc    func(project *model.Project, todos string) error
+}
+
+func (m *MockProjectRepository) FindAll(userID uint, includeArchived bool) ([]model.Project, error) {
+	return m.FindAllFunc(userID, includeArchived)
+}
+
+func (m *MockProjectRepository) FindByID(userID uint, id uint) (*model.Project, error) {
+	return m.FindByIDFunc(userID, id)
+}
+
+func (m *MockProjectRepository) Create(project *model.Project) (*model.Project, error) {
+	return m.CreateFunc(project)
+}
+
+func (m *MockProjectRepository) Update(project *model.Project) (*model.Project, error) {
+	return m.UpdateFunc(project)
+}
+
+func (m *MockProjectRepository) Move(project *model.Project, position int) error {
+	return m.MoveFunc(project, position)
+}
+
+func (m *MockProjectRepository) Archive(project *model.Project, completeTodos bool) error {
+	return m.ArchiveFunc(project, completeTodos)
+}
+
+func (m *MockProjectRepository) Unarchive(project *model.Project) error {
+	return m.UnarchiveFunc(project)
+}
+
+func (m *MockProjectRepository) Delete(project *model.Project, todos string) error {
+	return m.DeleteFunc(project, todos)
+}
+
+func (m *MockProjectRepository) WithContext(ctx context.Context) repository.ProjectRepository {
+	return m
+}
+
+// --- Create ---
+func TestProjectService_Create(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		input       service.ProjectInput
+		expectErr   bool
+		expectColor string
+	}{
+		{
+			name:        "success",
+			input:       service.ProjectInput{Name: "Work", Color: "#FF8800"},
+			expectColor: "#ff8800",
+		},
+		{
+			name:  "without color",
+			input: service.ProjectInput{Name: "Home"},
+		},
+		{
+			name:      "empty name",
+			input:     service.ProjectInput{Name: "  "},
+			expectErr: true,
+		},
+		{
+			name:      "invalid color",
+			input:     service.ProjectInput{Name: "Work", Color: "orange"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockProjectRepository{
+				CreateFunc: func(project *model.Project) (*model.Project, error) {
+					project.ID = 1
+					return project, nil
+				},
+			}
+
+			svc := service.NewProjectService(mockRepo)
+
+			project, err := svc.Create(ctx, 1, tt.input)
+
+			if tt.expectErr {
+				if !errors.Is(err, service.ErrValidation) {
+					t.Errorf("expected ErrValidation, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if project.UserID != 1 || project.Color != tt.expectColor {
+				t.Errorf("unexpected project: %+v", project)
+			}
+		})
+	}
+}
+
+// --- Update ---
+func TestProjectService_Update(t *testing.T) {
+
+	ptrInt := func(i int) *int { return &i }
+
+	tests := []struct {
+		name       string
+		input      service.ProjectInput
+		findErr    error
+		expectErr  error
+		expectMove *int
+	}{
+		{
+			name:  "rename only",
+			input: service.ProjectInput{Name: "Renamed"},
+		},
+		{
+			name:       "move to another position",
+			input:      service.ProjectInput{Name: "Work", Position: ptrInt(0)},
+			expectMove: ptrInt(0),
+		},
+		{
+			name:  "same position does not move",
+			input: service.ProjectInput{Name: "Work", Position: ptrInt(2)},
+		},
+		{
+			name:      "negative position",
+			input:     service.ProjectInput{Name: "Work", Position: ptrInt(-1)},
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "not found",
+			input:     service.ProjectInput{Name: "Work"},
+			findErr:   errors.New("record not found"),
+			expectErr: service.ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var moved *int
+			mockRepo := &MockProjectRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Project, error) {
+					if tt.findErr != nil {
+						return nil, tt.findErr
+					}
+					p := &model.Project{UserID: userID, Name: "Work", Position: 2}
+					p.ID = id
+					return p, nil
+				},
+				UpdateFunc: func(project *model.Project) (*model.Project, error) {
+					return project, nil
+				},
+				MoveFunc: func(project *model.Project, position int) error {
+					moved = &position
+					project.Position = position
+					return nil
+				},
+			}
+
+			svc := service.NewProjectService(mockRepo)
+
+			project, err := svc.Update(ctx, 1, 1, tt.input)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if project.Name != tt.input.Name {
+				t.Errorf("name mismatch: %s", project.Name)
+			}
+			if (moved == nil) != (tt.expectMove == nil) || (moved != nil && *moved != *tt.expectMove) {
+				t.Errorf("move mismatch: expected %v, got %v", tt.expectMove, moved)
+			}
+		})
+	}
+}
+
+// --- Archive ---
+func TestProjectService_Archive(t *testing.T) {
+
+	archivedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		current       model.Project
+		completeTodos bool
+		expectCalled  bool
+	}{
+		{
+			name:         "archive keeps todos",
+			current:      model.Project{Name: "Work"},
+			expectCalled: true,
+		},
+		{
+			name:          "archive completes todos",
+			current:       model.Project{Name: "Work"},
+			completeTodos: true,
+			expectCalled:  true,
+		},
+		{
+			name:          "already archived is a no-op",
+			current:       model.Project{Name: "Work", ArchivedAt: &archivedAt},
+			completeTodos: true,
+			expectCalled:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			called := false
+			mockRepo := &MockProjectRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Project, error) {
+					current := tt.current
+					return &current, nil
+				},
+				ArchiveFunc: func(project *model.Project, completeTodos bool) error {
+					called = true
+					if completeTodos != tt.completeTodos {
+						t.Errorf("completeTodos mismatch: %v", completeTodos)
+					}
+					now := time.Now()
+					project.ArchivedAt = &now
+					return nil
+				},
+			}
+
+			svc := service.NewProjectService(mockRepo)
+
+			project, err := svc.Archive(ctx, 1, 1, tt.completeTodos)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if called != tt.expectCalled {
+				t.Errorf("expected archive called=%v, got %v", tt.expectCalled, called)
+			}
+			if project.ArchivedAt == nil {
+				t.Errorf("project should be archived")
+			}
+		})
+	}
+}
+
+// --- Delete ---
+func TestProjectService_Delete(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		todos       string
+		findErr     error
+		expectErr   error
+		expectTodos string
+	}{
+		{
+			name:        "default unassigns todos",
+			todos:       "",
+			expectTodos: repository.ProjectTodosUnassign,
+		},
+		{
+			name:        "delete todos",
+			todos:       repository.ProjectTodosDelete,
+			expectTodos: repository.ProjectTodosDelete,
+		},
+		{
+			name:      "unknown mode",
+			todos:     "archive",
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "not found",
+			findErr:   errors.New("record not found"),
+			expectErr: service.ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var gotTodos string
+			mockRepo := &MockProjectRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Project, error) {
+					if tt.findErr != nil {
+						return nil, tt.findErr
+					}
+					return &model.Project{UserID: userID}, nil
+				},
+				DeleteFunc: func(project *model.Project, todos string) error {
+					gotTodos = todos
+					return nil
+				},
+			}
+
+			svc := service.NewProjectService(mockRepo)
+
+			err := svc.Delete(ctx, 1, 1, tt.todos)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotTodos != tt.expectTodos {
+				t.Errorf("todos mode mismatch: expected %s, got %s", tt.expectTodos, gotTodos)
+			}
+		})
+	}
+}
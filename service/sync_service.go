@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+const (
+	DefaultSyncLimit = 500
+	MaxSyncLimit     = 1000
+	MaxSyncChanges   = 100 // POST /sync 1 回あたりの変更数の上限
+)
+
+var (
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+	ErrSyncTokenExpired = errors.New("sync token has expired, a full sync is required")
+)
+
+// クライアントからの変更の種類
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+)
+
+// クライアントからの変更の結果
+const (
+	SyncApplied  = "applied"  // 保存した
+	SyncConflict = "conflict" // サーバーの変更を優先して保存しなかった（Todo はサーバーの現在の状態）
+	SyncRejected = "rejected" // 入力が正しくないので保存しなかった
+)
+
+// SyncPull は since の後の変更。
+// Created / Updated は現在の状態、Deleted はゴミ箱に移したか完全に削除した todo
+type SyncPull struct {
+	Created   []model.Todo    `json:"created"`
+	Updated   []model.Todo    `json:"updated"`
+	Deleted   []SyncTombstone `json:"deleted"`
+	NextToken string          `json:"next_token"`
+	HasMore   bool            `json:"has_more"` // true なら NextToken ですぐに続きを取得する
+}
+
+// SyncTombstone は削除した todo
+type SyncTombstone struct {
+	ID        uint      `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SyncChange はクライアントでオフライン中に行った変更 1 つ分
+type SyncChange struct {
+	Op          string
+	ClientID    string    // create: クライアント側の仮 id（結果の対応付け用）
+	ID          uint      // update / delete
+	BaseVersion uint      // update / delete: クライアントが最後に受け取った Version
+	Input       TodoInput // create
+	Done        bool      // create: 作成してすぐ完了にする
+	Patch       []byte    // update: 変更した項目だけの JSON Merge Patch
+}
+
+// SyncResult は変更 1 つ分の結果
+type SyncResult struct {
+	ClientID string
+	ID       uint
+	Status   string      // SyncApplied | SyncConflict | SyncRejected
+	Todo     *model.Todo // サーバーの現在の todo（削除済みなら nil）
+	Err      error       // SyncRejected の理由
+}
+
+type SyncService interface {
+	Pull(ctx context.Context, userID uint, since string, limit int) (*SyncPull, error)
+	Push(ctx context.Context, userID uint, changes []SyncChange) ([]SyncResult, error)
+}
+
+type syncService struct {
+	todoRepo    repository.TodoRepository
+	projectRepo repository.ProjectRepository
+	events      EventPublisher
+}
+
+// events には Push で保存した変更を通知する（nil なら通知しない）
+func NewSyncService(todoRepo repository.TodoRepository, projectRepo repository.ProjectRepository, events EventPublisher) SyncService {
+	return &syncService{todoRepo, projectRepo, events}
+}
+
+// 同期トークンの中身（最後に受け取った変更番号）
+type syncToken struct {
+	Seq int64 `json:"q"`
+}
+
+func encodeSyncToken(seq int64) string {
+	b, _ := json.Marshal(syncToken{Seq: seq})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSyncToken(s string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidSyncToken
+	}
+	var t syncToken
+	if err := json.Unmarshal(b, &t); err != nil || t.Seq < 0 {
+		return 0, ErrInvalidSyncToken
+	}
+	return t.Seq, nil
+}
+
+// --- Pull ---
+// since（前回の NextToken、空なら最初から）の後に作成・更新・削除した todo を変更順に返す。
+// since の後に完全に削除した todo があると削除を返せないので ErrSyncTokenExpired を返す
+func (s *syncService) Pull(ctx context.Context, userID uint, since string, limit int) (*SyncPull, error) {
+	if limit <= 0 {
+		limit = DefaultSyncLimit
+	}
+	if limit > MaxSyncLimit {
+		limit = MaxSyncLimit
+	}
+
+	var seq int64
+	if since != "" {
+		var err error
+		if seq, err = decodeSyncToken(since); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SyncPull{Created: []model.Todo{}, Updated: []model.Todo{}, Deleted: []SyncTombstone{}}
+
+	// 変更番号と todo を同じスナップショットから読む
+	err := s.todoRepo.WithContext(ctx).Transaction(func(repo repository.TodoRepository) error {
+		current, purged, err := repo.SyncSequence(userID)
+		if err != nil {
+			return err
+		}
+		if seq > current {
+			return ErrInvalidSyncToken
+		}
+		if seq > 0 && seq < purged {
+			return ErrSyncTokenExpired
+		}
+
+		todos, err := repo.FindChanges(userID, seq, limit+1)
+		if err != nil {
+			return err
+		}
+		if len(todos) > limit {
+			todos, result.HasMore = todos[:limit], true
+		}
+
+		for _, todo := range todos {
+			switch {
+			case todo.DeletedAt.Valid:
+				// クライアントが受け取る前に削除した todo は返さない
+				if todo.CreatedSeq <= seq {
+					result.Deleted = append(result.Deleted, SyncTombstone{ID: todo.ID, DeletedAt: todo.DeletedAt.Time})
+				}
+			case todo.CreatedSeq > seq:
+				result.Created = append(result.Created, todo)
+			default:
+				result.Updated = append(result.Updated, todo)
+			}
+		}
+
+		next := current
+		if result.HasMore {
+			next = todos[len(todos)-1].ChangeSeq
+		}
+		result.NextToken = encodeSyncToken(next)
+
+		todoService := &todoService{todoRepo: repo, projectRepo: s.projectRepo.WithContext(ctx)}
+		return todoService.fillProgress(userID, append(todoPointers(result.Created), todoPointers(result.Updated)...)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// --- Push ---
+// クライアントの変更を順に 1 つのトランザクションで保存する（失敗した変更だけ取り消す）。
+// 競合は次の規則で解決する:
+//   - create は常に保存する
+//   - update / delete は、BaseVersion がサーバーの Version と同じときだけ保存する
+//     （クライアントの時計は合っているとは限らないので、変更した日時では決めない）。
+//     update は Patch に含まれる項目だけを上書きする
+//   - それ以外はサーバーの変更を優先して SyncConflict を返す
+//   - 削除済みの todo への update は削除を優先して SyncConflict、delete は保存済みとして SyncApplied
+func (s *syncService) Push(ctx context.Context, userID uint, changes []SyncChange) ([]SyncResult, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: changes are required", ErrValidation)
+	}
+	if len(changes) > MaxSyncChanges {
+		return nil, fmt.Errorf("%w: at most %d changes are allowed", ErrValidation, MaxSyncChanges)
+	}
+
+	results := make([]SyncResult, len(changes))
+	committed := &eventBuffer{}
+	err := s.todoRepo.WithContext(ctx).Transaction(func(repo repository.TodoRepository) error {
+		for i, change := range changes {
+			results[i] = SyncResult{ClientID: change.ClientID, ID: change.ID}
+
+			events := &eventBuffer{}
+			err := repo.Transaction(func(repo repository.TodoRepository) error {
+				tx := &todoService{todoRepo: repo, projectRepo: s.projectRepo.WithContext(ctx), events: events}
+				return tx.applySync(ctx, userID, change, &results[i])
+			})
+			if err != nil {
+				if !errors.Is(err, ErrValidation) {
+					return err
+				}
+				results[i].Status, results[i].Todo, results[i].Err = SyncRejected, nil, err
+				continue
+			}
+			events.flush(committed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed.flush(s.events)
+	return results, nil
+}
+
+// applySync は変更 1 つを保存して result に結果を入れる（todoService のトランザクション内で呼ぶ）
+func (s *todoService) applySync(ctx context.Context, userID uint, change SyncChange, result *SyncResult) error {
+	switch change.Op {
+	case SyncCreate:
+		todo, err := s.create(userID, change.Input)
+		if err != nil {
+			return err
+		}
+		if change.Done {
+			done := true
+			if todo, err = s.saveTodo(userID, todo, &done); err != nil {
+				return err
+			}
+		}
+		result.ID, result.Status, result.Todo = todo.ID, SyncApplied, todo
+		return nil
+
+	case SyncUpdate, SyncDelete:
+		todo, err := s.todoRepo.FindByID(userID, change.ID)
+		if err != nil {
+			if change.Op == SyncDelete {
+				result.Status = SyncApplied
+			} else {
+				result.Status = SyncConflict
+			}
+			return nil
+		}
+
+		if todo.Version != change.BaseVersion {
+			if err := s.fillProgress(userID, todo); err != nil {
+				return err
+			}
+			result.Status, result.Todo = SyncConflict, todo
+			return nil
+		}
+
+		if change.Op == SyncDelete {
+			if err := s.Delete(ctx, userID, todo.ID, Precondition{}); err != nil {
+				return err
+			}
+			result.Status = SyncApplied
+			return nil
+		}
+
+		if todo, err = s.Patch(ctx, userID, todo.ID, PatchMerge, change.Patch, Precondition{}); err != nil {
+			return err
+		}
+		result.Status, result.Todo = SyncApplied, todo
+		return nil
+
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrValidation, change.Op)
+	}
+}
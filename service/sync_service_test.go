@@ -0,0 +1,296 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"gorm.io/gorm"
+)
+
+// syncTodo は変更番号付きの todo（deleted ならゴミ箱の todo）
+func syncTodo(id uint, createdSeq, changeSeq int64, deleted bool) model.Todo {
+	todo := model.Todo{Title: "t", CreatedSeq: createdSeq, ChangeSeq: changeSeq}
+	todo.ID = id
+	if deleted {
+		todo.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	}
+	return todo
+}
+
+// --- Pull ---
+func TestSyncService_Pull(t *testing.T) {
+
+	// 変更番号 5 まで振った後の変更（since は 2 = 1, 2 を受け取った後）
+	changes := []model.Todo{
+		syncTodo(1, 1, 3, false), // 更新
+		syncTodo(2, 2, 4, true),  // 削除
+		syncTodo(3, 5, 5, false), // 作成
+	}
+
+	tests := []struct {
+		name          string
+		since         int64 // -1 ならトークンなし（全件）
+		limit         int
+		purged        int64
+		changes       []model.Todo
+		expectErr     error
+		expectCreated []uint
+		expectUpdated []uint
+		expectDeleted []uint
+		expectNext    int64
+		expectMore    bool
+	}{
+		{
+			name: "changes since token", since: 2, changes: changes,
+			expectCreated: []uint{3}, expectUpdated: []uint{1}, expectDeleted: []uint{2}, expectNext: 5,
+		},
+		{
+			name: "full sync skips deleted", since: -1, changes: changes,
+			expectCreated: []uint{1, 3}, expectNext: 5,
+		},
+		{
+			name: "created and deleted after token", since: 2, changes: []model.Todo{syncTodo(4, 3, 4, true)},
+			expectNext: 5,
+		},
+		{
+			name: "paging", since: 2, limit: 2, changes: changes,
+			expectUpdated: []uint{1}, expectDeleted: []uint{2}, expectNext: 4, expectMore: true,
+		},
+		{name: "purged after token", since: 2, purged: 3, expectErr: service.ErrSyncTokenExpired},
+		{name: "purged before token", since: 3, purged: 3, expectNext: 5},
+		{name: "token from the future", since: 6, expectErr: service.ErrInvalidSyncToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var gotSince int64
+			mockRepo := &MockTodoRepository{
+				SyncSequenceFunc: func(userID uint) (int64, int64, error) {
+					return 5, tt.purged, nil
+				},
+				FindChangesFunc: func(userID uint, since int64, limit int) ([]model.Todo, error) {
+					gotSince = since
+					if limit < len(tt.changes) {
+						return append([]model.Todo{}, tt.changes[:limit]...), nil
+					}
+					return append([]model.Todo{}, tt.changes...), nil
+				},
+			}
+
+			svc := service.NewSyncService(mockRepo, &MockProjectRepository{}, nil)
+
+			// since = -1 はトークンなしの全件同期
+			token := ""
+			if tt.since >= 0 {
+				token = syncToken(t, tt.since)
+			}
+
+			result, err := svc.Pull(ctx, 1, token, tt.limit)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.since > 0 && gotSince != tt.since {
+				t.Errorf("expected since %d, got %d", tt.since, gotSince)
+			}
+			assertIDs(t, "created", todoIDs(result.Created), tt.expectCreated)
+			assertIDs(t, "updated", todoIDs(result.Updated), tt.expectUpdated)
+			var deleted []uint
+			for _, d := range result.Deleted {
+				deleted = append(deleted, d.ID)
+			}
+			assertIDs(t, "deleted", deleted, tt.expectDeleted)
+			if result.NextToken != syncToken(t, tt.expectNext) || result.HasMore != tt.expectMore {
+				t.Errorf("expected next=%s more=%v, got next=%s more=%v", syncToken(t, tt.expectNext), tt.expectMore, result.NextToken, result.HasMore)
+			}
+		})
+	}
+}
+
+func TestSyncService_Pull_InvalidToken(t *testing.T) {
+	svc := service.NewSyncService(&MockTodoRepository{}, &MockProjectRepository{}, nil)
+
+	if _, err := svc.Pull(ctx, 1, "not-a-token", 0); !errors.Is(err, service.ErrInvalidSyncToken) {
+		t.Errorf("expected ErrInvalidSyncToken, got %v", err)
+	}
+}
+
+// syncToken は変更番号 seq までを受け取ったことを示すトークンを、変更のない Pull から作る
+func syncToken(t *testing.T, seq int64) string {
+	t.Helper()
+
+	mockRepo := &MockTodoRepository{
+		SyncSequenceFunc: func(userID uint) (int64, int64, error) {
+			return seq, 0, nil
+		},
+		FindChangesFunc: func(userID uint, since int64, limit int) ([]model.Todo, error) {
+			return nil, nil
+		},
+	}
+	result, err := service.NewSyncService(mockRepo, &MockProjectRepository{}, nil).Pull(ctx, 1, "", 0)
+	if err != nil {
+		t.Fatalf("failed to make token: %v", err)
+	}
+	return result.NextToken
+}
+
+func todoIDs(todos []model.Todo) []uint {
+	var ids []uint
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, name string, got, expected []uint) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, got)
+		return
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("%s: expected %v, got %v", name, expected, got)
+			return
+		}
+	}
+}
+
+// --- Push ---
+func TestSyncService_Push(t *testing.T) {
+
+	updatedAt := time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		change        service.SyncChange
+		expectStatus  string
+		expectSaved   bool
+		expectDeleted bool
+	}{
+		{
+			name:         "create",
+			change:       service.SyncChange{Op: service.SyncCreate, ClientID: "tmp-1", Input: service.TodoInput{Title: "new"}},
+			expectStatus: service.SyncApplied, expectSaved: true,
+		},
+		{
+			name:         "update on same version",
+			change:       service.SyncChange{Op: service.SyncUpdate, ID: 1, BaseVersion: 3, Patch: []byte(`{"title":"new"}`)},
+			expectStatus: service.SyncApplied, expectSaved: true,
+		},
+		{
+			name:         "stale update loses",
+			change:       service.SyncChange{Op: service.SyncUpdate, ID: 1, BaseVersion: 2, Patch: []byte(`{"title":"new"}`)},
+			expectStatus: service.SyncConflict,
+		},
+		{
+			name:         "newer base version loses",
+			change:       service.SyncChange{Op: service.SyncUpdate, ID: 1, BaseVersion: 4, Patch: []byte(`{"title":"new"}`)},
+			expectStatus: service.SyncConflict,
+		},
+		{
+			name:         "update on deleted todo",
+			change:       service.SyncChange{Op: service.SyncUpdate, ID: 99, BaseVersion: 3, Patch: []byte(`{"title":"new"}`)},
+			expectStatus: service.SyncConflict,
+		},
+		{
+			name:         "invalid update",
+			change:       service.SyncChange{Op: service.SyncUpdate, ID: 1, BaseVersion: 3, Patch: []byte(`{"title":""}`)},
+			expectStatus: service.SyncRejected,
+		},
+		{
+			name:         "delete on same version",
+			change:       service.SyncChange{Op: service.SyncDelete, ID: 1, BaseVersion: 3},
+			expectStatus: service.SyncApplied, expectDeleted: true,
+		},
+		{
+			name:         "stale delete loses",
+			change:       service.SyncChange{Op: service.SyncDelete, ID: 1, BaseVersion: 2},
+			expectStatus: service.SyncConflict,
+		},
+		{
+			name:         "delete on deleted todo",
+			change:       service.SyncChange{Op: service.SyncDelete, ID: 99, BaseVersion: 3},
+			expectStatus: service.SyncApplied,
+		},
+		{
+			name:         "unknown op",
+			change:       service.SyncChange{Op: "archive", ID: 1},
+			expectStatus: service.SyncRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			saved, deleted := false, false
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					if id == 99 {
+						return nil, errors.New("record not found")
+					}
+					todo := &model.Todo{UserID: userID, Title: "old", Version: 3}
+					todo.ID, todo.UpdatedAt = id, updatedAt
+					return todo, nil
+				},
+				CreateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					saved = true
+					todo.ID = 10
+					return todo, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					saved = true
+					return todo, nil
+				},
+				DeleteFunc: func(userID uint, id uint, version uint) error {
+					deleted = true
+					return nil
+				},
+			}
+
+			svc := service.NewSyncService(mockRepo, &MockProjectRepository{}, nil)
+
+			results, err := svc.Push(ctx, 1, []service.SyncChange{tt.change})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result := results[0]
+			if result.Status != tt.expectStatus {
+				t.Errorf("expected %s, got %s (%v)", tt.expectStatus, result.Status, result.Err)
+			}
+			if saved != tt.expectSaved || deleted != tt.expectDeleted {
+				t.Errorf("expected saved=%v deleted=%v, got saved=%v deleted=%v", tt.expectSaved, tt.expectDeleted, saved, deleted)
+			}
+			if result.ClientID != tt.change.ClientID {
+				t.Errorf("expected client id %q, got %q", tt.change.ClientID, result.ClientID)
+			}
+			if result.Status == service.SyncConflict && tt.change.ID != 99 && (result.Todo == nil || result.Todo.Title != "old") {
+				t.Errorf("expected server todo on conflict, got %+v", result.Todo)
+			}
+		})
+	}
+}
+
+func TestSyncService_Push_Limits(t *testing.T) {
+	svc := service.NewSyncService(&MockTodoRepository{}, &MockProjectRepository{}, nil)
+
+	if _, err := svc.Push(ctx, 1, nil); !errors.Is(err, service.ErrValidation) {
+		t.Errorf("expected ErrValidation for no changes, got %v", err)
+	}
+	if _, err := svc.Push(ctx, 1, make([]service.SyncChange, service.MaxSyncChanges+1)); !errors.Is(err, service.ErrValidation) {
+		t.Errorf("expected ErrValidation for too many changes, got %v", err)
+	}
+}
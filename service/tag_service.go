@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+const maxTagNameLength = 50
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already exists")
+)
+
+type TagService interface {
+	FindAll(ctx context.Context, userID uint) ([]model.Tag, error)
+	Create(ctx context.Context, userID uint, name string) (*model.Tag, error)
+	Rename(ctx context.Context, userID uint, id uint, name string) (*model.Tag, error)
+	Merge(ctx context.Context, userID uint, id uint, intoID uint) (*model.Tag, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	Attach(ctx context.Context, userID uint, todoID uint, tagID uint) (*model.Todo, error)
+	Detach(ctx context.Context, userID uint, todoID uint, tagID uint) (*model.Todo, error)
+}
+
+type tagService struct {
+	tagRepo  repository.TagRepository
+	todoRepo repository.TodoRepository
+}
+
+func NewTagService(tagRepo repository.TagRepository, todoRepo repository.TodoRepository) TagService {
+	return &tagService{tagRepo, todoRepo}
+}
+
+func (s *tagService) with(ctx context.Context) *tagService {
+	return &tagService{s.tagRepo.WithContext(ctx), s.todoRepo.WithContext(ctx)}
+}
+
+// --- FindAll ---
+func (s *tagService) FindAll(ctx context.Context, userID uint) ([]model.Tag, error) {
+	s = s.with(ctx)
+	tags, err := s.tagRepo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []model.Tag{}
+	}
+	return tags, nil
+}
+
+// --- Create ---
+func (s *tagService) Create(ctx context.Context, userID uint, name string) (*model.Tag, error) {
+	s = s.with(ctx)
+	name, err := validateTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.tagRepo.FindByName(userID, name); err == nil {
+		return nil, ErrTagExists
+	}
+
+	return s.tagRepo.Create(&model.Tag{UserID: userID, Name: name})
+}
+
+// --- Rename ---
+// 大文字小文字だけの変更は同じタグとして扱う
+func (s *tagService) Rename(ctx context.Context, userID uint, id uint, name string) (*model.Tag, error) {
+	s = s.with(ctx)
+	name, err := validateTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tagRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrTagNotFound
+	}
+	if other, err := s.tagRepo.FindByName(userID, name); err == nil && other.ID != tag.ID {
+		return nil, ErrTagExists
+	}
+
+	tag.Name = name
+	return s.tagRepo.Update(tag)
+}
+
+// --- Merge ---
+// id のタグを intoID のタグにまとめ、id のタグは削除する
+func (s *tagService) Merge(ctx context.Context, userID uint, id uint, intoID uint) (*model.Tag, error) {
+	s = s.with(ctx)
+	if id == intoID {
+		return nil, fmt.Errorf("%w: cannot merge a tag into itself", ErrValidation)
+	}
+
+	from, err := s.tagRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrTagNotFound
+	}
+	into, err := s.tagRepo.FindByID(userID, intoID)
+	if err != nil {
+		return nil, ErrTagNotFound
+	}
+
+	if err := s.tagRepo.Merge(from, into); err != nil {
+		return nil, err
+	}
+	return into, nil
+}
+
+// --- Delete ---
+func (s *tagService) Delete(ctx context.Context, userID uint, id uint) error {
+	s = s.with(ctx)
+	tag, err := s.tagRepo.FindByID(userID, id)
+	if err != nil {
+		return ErrTagNotFound
+	}
+	return s.tagRepo.Delete(tag)
+}
+
+// --- Attach ---
+func (s *tagService) Attach(ctx context.Context, userID uint, todoID uint, tagID uint) (*model.Todo, error) {
+	s = s.with(ctx)
+	if err := s.checkOwner(userID, todoID, tagID); err != nil {
+		return nil, err
+	}
+	if err := s.tagRepo.Attach(todoID, tagID); err != nil {
+		return nil, err
+	}
+	return s.todoRepo.FindByID(userID, todoID)
+}
+
+// --- Detach ---
+func (s *tagService) Detach(ctx context.Context, userID uint, todoID uint, tagID uint) (*model.Todo, error) {
+	s = s.with(ctx)
+	if err := s.checkOwner(userID, todoID, tagID); err != nil {
+		return nil, err
+	}
+	if err := s.tagRepo.Detach(todoID, tagID); err != nil {
+		return nil, err
+	}
+	return s.todoRepo.FindByID(userID, todoID)
+}
+
+// checkOwner は todo とタグがどちらも userID のものか確認する
+func (s *tagService) checkOwner(userID uint, todoID uint, tagID uint) error {
+	if _, err := s.todoRepo.FindByID(userID, todoID); err != nil {
+		return ErrTodoNotFound
+	}
+	if _, err := s.tagRepo.FindByID(userID, tagID); err != nil {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// タグ名は GET /todos の tags_any などでカンマ区切りで渡すのでカンマを含められない
+func validateTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if utf8.RuneCountInString(name) > maxTagNameLength {
+		return "", fmt.Errorf("%w: name must be at most %d characters", ErrValidation, maxTagNameLength)
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("%w: name must not contain commas", ErrValidation)
+	}
+	return name, nil
+}
@@ -0,0 +1,311 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+)
+
+// --- Mock Repository 定義 ---
+type MockTagRepository struct {
+	FindAllFunc    func(userID uint) ([]model.Tag, error)
+	FindByIDFunc   func(userID uint, id uint) (*model.Tag, error)
+	FindByNameFunc func(userID uint, name string) (*model.Tag, error)
+	CreateFunc     func(tag *model.Tag) (*model.Tag, error)
+	UpdateFunc     func(tag *model.Tag) (*model.Tag, error)
+	MergeFunc      func(from *model.Tag, into *model.Tag) error
+	DeleteFunc     func(tag *model.Tag) error
+	AttachFunc     func(todoID uint, tagID uint) error
+	DetachFunc     func(todoID uint, tagID uint) error
+}
+
+func (m *MockTagRepository) FindAll(userID uint) ([]model.Tag, error) {
+	return m.FindAllFunc(userID)
+}
+
+func (m *MockTagRepository) FindByID(userID uint, id uint) (*model.Tag, error) {
+	return m.FindByIDFunc(userID, id)
+}
+
+func (m *MockTagRepository) FindByName(userID uint, name string) (*model.Tag, error) {
+	return m.FindByNameFunc(userID, name)
+}
+
+func (m *MockTagRepository) Create(tag *model.Tag) (*model.Tag, error) {
+	return m.CreateFunc(tag)
+}
+
+func (m *MockTagRepository) Update(tag *model.Tag) (*model.Tag, error) {
+	return m.UpdateFunc(tag)
+}
+
+func (m *MockTagRepository) Merge(from *model.Tag, into *model.Tag) error {
+	return m.MergeFunc(from, into)
+}
+
+func (m *MockTagRepository) Delete(tag *model.Tag) error {
+	return m.DeleteFunc(tag)
+}
+
+func (m *MockTagRepository) Attach(todoID uint, tagID uint) error {
+	return m.AttachFunc(todoID, tagID)
+}
+
+func (m *MockTagRepository) Detach(todoID uint, tagID uint) error {
+	return m.DetachFunc(todoID, tagID)
+}
+
+func (m *MockTagRepository) WithContext(ctx context.Context) repository.TagRepository {
+	return m
+}
+
+// 既存のタグ（id 1: urgent, id 2: backend）。名前は大文字小文字を区別しない
+func existingTags() *MockTagRepository {
+	tags := []model.Tag{{ID: 1, UserID: 1, Name: "urgent"}, {ID: 2, UserID: 1, Name: "backend"}}
+
+	return &MockTagRepository{
+		FindByIDFunc: func(userID uint, id uint) (*model.Tag, error) {
+			for _, tag := range tags {
+				if tag.ID == id && tag.UserID == userID {
+					return &tag, nil
+				}
+			}
+			return nil, errors.New("record not found")
+		},
+		FindByNameFunc: func(userID uint, name string) (*model.Tag, error) {
+			for _, tag := range tags {
+				if strings.EqualFold(tag.Name, name) && tag.UserID == userID {
+					return &tag, nil
+				}
+			}
+			return nil, errors.New("record not found")
+		},
+		CreateFunc: func(tag *model.Tag) (*model.Tag, error) {
+			tag.ID = 3
+			return tag, nil
+		},
+		UpdateFunc: func(tag *model.Tag) (*model.Tag, error) {
+			return tag, nil
+		},
+	}
+}
+
+// --- Create ---
+func TestTagService_Create(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		tagName    string
+		expectErr  error
+		expectName string
+	}{
+		{name: "success", tagName: "  errands ", expectName: "errands"},
+		{name: "duplicate ignoring case", tagName: "URGENT", expectErr: service.ErrTagExists},
+		{name: "empty", tagName: " ", expectErr: service.ErrValidation},
+		{name: "comma", tagName: "a,b", expectErr: service.ErrValidation},
+		{name: "too long", tagName: strings.Repeat("あ", 51), expectErr: service.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			svc := service.NewTagService(existingTags(), &MockTodoRepository{})
+
+			tag, err := svc.Create(ctx, 1, tt.tagName)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tag.Name != tt.expectName || tag.UserID != 1 {
+				t.Errorf("unexpected tag: %+v", tag)
+			}
+		})
+	}
+}
+
+// --- Rename ---
+func TestTagService_Rename(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		id        uint
+		tagName   string
+		expectErr error
+	}{
+		{name: "success", id: 1, tagName: "critical"},
+		{name: "change case only", id: 1, tagName: "Urgent"},
+		{name: "conflict with another tag", id: 1, tagName: "Backend", expectErr: service.ErrTagExists},
+		{name: "not found", id: 9, tagName: "x", expectErr: service.ErrTagNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			svc := service.NewTagService(existingTags(), &MockTodoRepository{})
+
+			tag, err := svc.Rename(ctx, 1, tt.id, tt.tagName)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tag.Name != tt.tagName {
+				t.Errorf("name mismatch: %s", tag.Name)
+			}
+		})
+	}
+}
+
+// --- Merge ---
+func TestTagService_Merge(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		id        uint
+		into      uint
+		expectErr error
+	}{
+		{name: "success", id: 2, into: 1},
+		{name: "into itself", id: 1, into: 1, expectErr: service.ErrValidation},
+		{name: "source not found", id: 9, into: 1, expectErr: service.ErrTagNotFound},
+		{name: "target not found", id: 1, into: 9, expectErr: service.ErrTagNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var merged [2]uint
+			mockRepo := existingTags()
+			mockRepo.MergeFunc = func(from *model.Tag, into *model.Tag) error {
+				merged = [2]uint{from.ID, into.ID}
+				return nil
+			}
+
+			svc := service.NewTagService(mockRepo, &MockTodoRepository{})
+
+			tag, err := svc.Merge(ctx, 1, tt.id, tt.into)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tag.ID != tt.into || merged != [2]uint{tt.id, tt.into} {
+				t.Errorf("unexpected merge: tag=%d merged=%v", tag.ID, merged)
+			}
+		})
+	}
+}
+
+// --- Attach ---
+// 他人の todo やタグには付けられない
+func TestTagService_Attach(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		todoID    uint
+		tagID     uint
+		expectErr error
+	}{
+		{name: "success", todoID: 1, tagID: 1},
+		{name: "todo not found", todoID: 9, tagID: 1, expectErr: service.ErrTodoNotFound},
+		{name: "tag not found", todoID: 1, tagID: 9, expectErr: service.ErrTagNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			attached := false
+			tagRepo := existingTags()
+			tagRepo.AttachFunc = func(todoID uint, tagID uint) error {
+				attached = true
+				return nil
+			}
+			todoRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					if id != 1 {
+						return nil, errors.New("record not found")
+					}
+					todo := &model.Todo{UserID: userID, Title: "task"}
+					if attached {
+						todo.Tags = []model.Tag{{ID: 1, Name: "urgent"}}
+					}
+					return todo, nil
+				},
+			}
+
+			svc := service.NewTagService(tagRepo, todoRepo)
+
+			todo, err := svc.Attach(ctx, 1, tt.todoID, tt.tagID)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				if attached {
+					t.Errorf("attach should not be called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(todo.Tags) != 1 {
+				t.Errorf("expected reloaded todo with tag, got %+v", todo.Tags)
+			}
+		})
+	}
+}
+
+// タグの絞り込みは空白を除き、大文字小文字違いの重複をまとめて渡す
+func TestTodoService_FindAll_Tags(t *testing.T) {
+
+	var got repository.TodoQuery
+	mockRepo := &MockTodoRepository{
+		FindAllFunc: func(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
+			got = query
+			return nil, nil
+		},
+	}
+
+	svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+	_, err := svc.FindAll(ctx, 1, service.TodoListParams{
+		TagsAny:  []string{"urgent", " Urgent ", "backend"},
+		TagsAll:  []string{"a", "", "A"},
+		TagsNone: nil,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(got.TagsAny, ",") != "urgent,backend" {
+		t.Errorf("tags_any mismatch: %v", got.TagsAny)
+	}
+	if strings.Join(got.TagsAll, ",") != "a" {
+		t.Errorf("tags_all mismatch: %v", got.TagsAll)
+	}
+	if got.TagsNone != nil {
+		t.Errorf("tags_none should be empty: %v", got.TagsNone)
+	}
+}
@@ -1,74 +1,1240 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"html"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/a5415091-collab/go-gin-todo-app/event"
 	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/patch"
+	"github.com/a5415091-collab/go-gin-todo-app/recurrence"
 	"github.com/a5415091-collab/go-gin-todo-app/repository"
 )
 
+const (
+	DefaultTodoPageLimit = 50
+	MaxTodoPageLimit     = 200
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	maxSearchTerms     = 16
+)
+
+var (
+	ErrValidation         = errors.New("validation failed")
+	ErrTodoNotFound       = repository.ErrTodoNotFound
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrEmptySearchQuery   = errors.New("search query is required")
+	ErrTooManySearchTerms = errors.New("too many search terms")
+	ErrPatchConflict      = patch.ErrTestFailed // JSON Patch の test 操作の失敗
+)
+
+const MaxTodoTitleLength = 100
+
+// PATCH の形式
+const (
+	PatchMerge = "merge" // RFC 7396 JSON Merge Patch
+	PatchJSON  = "json"  // RFC 6902 JSON Patch
+)
+
+// 期日ビュー
+const (
+	DueViewOverdue  = "overdue"  // 期日を過ぎた未完了
+	DueViewToday    = "today"    // 今日が期日（時刻指定は現在以降）
+	DueViewUpcoming = "upcoming" // 明日から days 日以内
+
+	DefaultUpcomingDays = 7
+	MaxUpcomingDays     = 90
+)
+
+const (
+	DefaultOccurrenceCount = 5
+	MaxOccurrenceCount     = 50
+)
+
+// サブタスクの深さの上限（ルートの todo が 1）
+const MaxTodoDepth = 5
+
+// TodoInput は作成・更新時の入力
+type TodoInput struct {
+	ProjectID    *uint // nil なら未分類
+	ParentID     *uint // 作成時のみ。移動は Move で行う
+	AutoComplete bool  // 子が全て完了したら自動で完了にする
+	Title        string
+	Due          string // "2006-01-02"（終日）または RFC3339（時刻指定）、空なら期日なし
+	Start        string // RFC3339、空なら開始日時なし
+	RRule        string // "FREQ=WEEKLY;BYDAY=MO" など、空なら繰り返しなし（期日が必要）
+	TZ           string // 時刻指定の繰り返しを展開する IANA タイムゾーン、空なら UTC
+}
+
+// TodoOccurrences は繰り返しの今後の期日（終日は "2006-01-02"、時刻指定は TZ での RFC3339）
+type TodoOccurrences struct {
+	RRule       string   `json:"rrule"`
+	TZ          string   `json:"tz"`
+	AllDay      bool     `json:"all_day"`
+	Occurrences []string `json:"occurrences"`
+}
+
+// TodoSearchResult は検索結果 1 件。
+// Snippet は HTML エスケープ済みで、一致箇所だけ <mark>...</mark> で囲む
+type TodoSearchResult struct {
+	Todo    model.Todo `json:"todo"`
+	Snippet string     `json:"snippet"`
+	Score   float64    `json:"score"`
+}
+
+// TodoListParams は GET /todos のクエリ
+type TodoListParams struct {
+	ProjectID   *uint // GET /projects/:id/todos
+	Limit       int
+	Cursor      string // 前のページの next_cursor
+	Done        *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Title       string
+	TagsAny     []string // タグ名（大文字小文字は区別しない）
+	TagsAll     []string
+	TagsNone    []string
+	Sort        string // created_at | updated_at | title
+	Order       string // asc | desc
+}
+
+// TodoPage は一覧の 1 ページ分
+type TodoPage struct {
+	Todos      []model.Todo `json:"todos"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// カーソルの中身（並び順が変わったら使えないように sort/order も持つ）
+type todoCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
 type TodoService interface {
-	FindAll(userID uint) ([]model.Todo, error)
-	FindByID(userID uint, id uint) (*model.Todo, error)
-	Create(userID uint, title string) (*model.Todo, error)
-	Update(userID uint, id uint, title string, done *bool) (*model.Todo, error)
-	Delete(userID uint, id uint) error
+	FindAll(ctx context.Context, userID uint, params TodoListParams) (*TodoPage, error)
+	Search(ctx context.Context, userID uint, q string, limit int) ([]TodoSearchResult, error)
+	FindDue(ctx context.Context, userID uint, view string, loc *time.Location, days int) ([]model.Todo, error)
+	FindByID(ctx context.Context, userID uint, id uint) (*model.Todo, error)
+	Occurrences(ctx context.Context, userID uint, id uint, count int) (*TodoOccurrences, error)
+	Children(ctx context.Context, userID uint, id uint) ([]model.Todo, error)
+	Move(ctx context.Context, userID uint, id uint, parentID *uint) (*model.Todo, error)
+	Create(ctx context.Context, userID uint, input TodoInput) (*model.Todo, error)
+	Update(ctx context.Context, userID uint, id uint, input TodoInput, done *bool, pre Precondition) (*model.Todo, error)
+	Patch(ctx context.Context, userID uint, id uint, kind string, body []byte, pre Precondition) (*model.Todo, error)
+	Delete(ctx context.Context, userID uint, id uint, pre Precondition) error
+	Restore(ctx context.Context, userID uint, id uint) (*model.Todo, error)
+	Bulk(ctx context.Context, userID uint, mode string, ops []BulkOperation) ([]BulkResult, error)
 }
 
 type todoService struct {
-	todoRepo repository.TodoRepository
+	todoRepo    repository.TodoRepository
+	projectRepo repository.ProjectRepository
+	events      EventPublisher
 }
 
-func NewTodoService(todoRepo repository.TodoRepository) TodoService {
-	return &todoService{todoRepo}
+// events には作成・更新・削除した todo を通知する（nil なら通知しない）
+func NewTodoService(todoRepo repository.TodoRepository, projectRepo repository.ProjectRepository, events EventPublisher) TodoService {
+	return &tracedTodoService{&todoService{todoRepo, projectRepo, events}}
 }
 
 // --- FindAll ---
-func (s *todoService) FindAll(userID uint) ([]model.Todo, error) {
-	return s.todoRepo.FindAll(userID)
+func (s *todoService) FindAll(ctx context.Context, userID uint, params TodoListParams) (*TodoPage, error) {
+	if params.Sort == "" {
+		params.Sort = repository.TodoSortCreatedAt
+	}
+	if params.Order == "" {
+		params.Order = "asc"
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultTodoPageLimit
+	}
+	if params.Limit > MaxTodoPageLimit {
+		params.Limit = MaxTodoPageLimit
+	}
+
+	if params.ProjectID != nil {
+		if _, err := s.projectRepo.FindByID(userID, *params.ProjectID); err != nil {
+			return nil, ErrProjectNotFound
+		}
+	}
+
+	query := repository.TodoQuery{
+		ProjectID:   params.ProjectID,
+		Done:        params.Done,
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+		UpdatedFrom: params.UpdatedFrom,
+		UpdatedTo:   params.UpdatedTo,
+		Title:       params.Title,
+		TagsAny:     normalizeTagNames(params.TagsAny),
+		TagsAll:     normalizeTagNames(params.TagsAll),
+		TagsNone:    normalizeTagNames(params.TagsNone),
+		SortBy:      params.Sort,
+		Desc:        params.Order == "desc",
+		Limit:       params.Limit + 1, // 次ページの有無を知るために 1 件多く取る
+	}
+
+	if params.Cursor != "" {
+		after, err := decodeTodoCursor(params.Cursor, params.Sort, params.Order)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	todos, err := s.todoRepo.FindAll(userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TodoPage{Todos: todos}
+	if len(todos) > params.Limit {
+		page.Todos = todos[:params.Limit]
+		last := page.Todos[len(page.Todos)-1]
+		page.NextCursor = encodeTodoCursor(repository.NewTodoCursor(&last, params.Sort), params.Sort, params.Order)
+	}
+	if page.Todos == nil {
+		page.Todos = []model.Todo{}
+	}
+	if err := s.fillProgress(userID, todoPointers(page.Todos)...); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// normalizeTagNames は空白を除き、大文字小文字違いの重複をまとめる
+func normalizeTagNames(names []string) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+func encodeTodoCursor(c repository.TodoCursor, sort, order string) string {
+	b, _ := json.Marshal(todoCursor{Sort: sort, Order: order, Value: c.Value, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeTodoCursor(s, sort, order string) (*repository.TodoCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c todoCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort || c.Order != order {
+		return nil, ErrInvalidCursor
+	}
+	return &repository.TodoCursor{Value: c.Value, ID: c.ID}, nil
+}
+
+// --- Search ---
+// q は空白区切りの語（前方一致）と "..." のフレーズ（完全一致）の AND
+func (s *todoService) Search(ctx context.Context, userID uint, q string, limit int) ([]TodoSearchResult, error) {
+	terms := parseSearchQuery(q)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, ErrTooManySearchTerms
+	}
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	hits, err := s.todoRepo.Search(userID, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TodoSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, TodoSearchResult{
+			Todo:    hit.Todo,
+			Snippet: renderSnippet(hit.Snippet),
+			Score:   hit.Score,
+		})
+	}
+	return results, nil
+}
+
+func parseSearchQuery(q string) []repository.SearchTerm {
+	var terms []repository.SearchTerm
+
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		if rest[0] == '"' {
+			// "..." はフレーズ（閉じていなければ最後まで）
+			end := strings.IndexByte(rest[1:], '"')
+			phrase := rest[1:]
+			rest = ""
+			if end >= 0 {
+				phrase, rest = phrase[:end], phrase[end+1:]
+			}
+			if text := strings.Join(strings.Fields(phrase), " "); hasWordChar(text) {
+				terms = append(terms, repository.SearchTerm{Text: text, Phrase: true})
+			}
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			word := rest
+			rest = ""
+			if end >= 0 {
+				word, rest = word[:end], word[end:]
+			}
+			// 前方一致はデフォルトなので末尾の * は不要
+			if text := strings.TrimRight(word, "*"); hasWordChar(text) {
+				terms = append(terms, repository.SearchTerm{Text: text})
+			}
+		}
+		rest = strings.TrimSpace(rest)
+	}
+	return terms
+}
+
+// 記号だけの語はトークンにならないので無視する
+func hasWordChar(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0
+}
+
+func renderSnippet(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, repository.HighlightStart, "<mark>")
+	return strings.ReplaceAll(s, repository.HighlightEnd, "</mark>")
 }
 
 // --- FindByID ---
-func (s *todoService) FindByID(userID uint, id uint) (*model.Todo, error) {
+func (s *todoService) FindByID(ctx context.Context, userID uint, id uint) (*model.Todo, error) {
 	todo, err := s.todoRepo.FindByID(userID, id)
 	if err != nil {
-		return nil, errors.New("todo not found")
+		return nil, ErrTodoNotFound
+	}
+	if err := s.fillProgress(userID, todo); err != nil {
+		return nil, err
 	}
 	return todo, nil
 }
 
+// --- FindDue ---
+// loc は利用者のタイムゾーン（「今日」の境界と終日の期日の解釈に使う）
+func (s *todoService) FindDue(ctx context.Context, userID uint, view string, loc *time.Location, days int) ([]model.Todo, error) {
+	now := time.Now().In(loc)
+	y, m, d := now.Date()
+	tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+
+	// 終日の期日は日付を UTC 0 時で保存している
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	tomorrowDate := today.AddDate(0, 0, 1)
+
+	var window repository.DueWindow
+	switch view {
+	case DueViewOverdue:
+		window = repository.DueWindow{TimedTo: &now, DateTo: &today}
+	case DueViewToday:
+		window = repository.DueWindow{TimedFrom: &now, TimedTo: &tomorrow, DateFrom: &today, DateTo: &tomorrowDate}
+	case DueViewUpcoming:
+		if days <= 0 {
+			days = DefaultUpcomingDays
+		}
+		if days > MaxUpcomingDays {
+			days = MaxUpcomingDays
+		}
+		end := time.Date(y, m, d+1+days, 0, 0, 0, 0, loc)
+		endDate := tomorrowDate.AddDate(0, 0, days)
+		window = repository.DueWindow{TimedFrom: &tomorrow, TimedTo: &end, DateFrom: &tomorrowDate, DateTo: &endDate}
+	default:
+		return nil, fmt.Errorf("%w: unknown view %q", ErrValidation, view)
+	}
+
+	todos, err := s.todoRepo.FindDue(userID, window)
+	if err != nil {
+		return nil, err
+	}
+	if todos == nil {
+		todos = []model.Todo{}
+	}
+	return todos, nil
+}
+
 // --- Create ---
-func (s *todoService) Create(userID uint, title string) (*model.Todo, error) {
+func (s *todoService) Create(ctx context.Context, userID uint, input TodoInput) (*model.Todo, error) {
+	var todo *model.Todo
+	err := s.inTx(func(tx *todoService) error {
+		var err error
+		todo, err = tx.create(userID, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
 
-	if strings.TrimSpace(title) == "" {
-		return nil, errors.New("title is required")
+// create は todo を作成して、自動完了済みの親を戻す（呼び出し元のトランザクションの中で使う）
+func (s *todoService) create(userID uint, input TodoInput) (*model.Todo, error) {
+	if err := validateTitle(input.Title); err != nil {
+		return nil, err
 	}
 
 	todo := &model.Todo{
-		Title:  title,
-		UserID: userID,
-		Done:   false,
+		Title:        input.Title,
+		UserID:       userID,
+		Done:         false,
+		AutoComplete: input.AutoComplete,
+	}
+	if input.ParentID != nil {
+		if err := s.checkParent(userID, *input.ParentID, 1); err != nil {
+			return nil, err
+		}
+		todo.ParentID = input.ParentID
+	}
+	if err := s.applyProject(todo, input); err != nil {
+		return nil, err
+	}
+	if err := applySchedule(todo, input); err != nil {
+		return nil, err
 	}
-	return s.todoRepo.Create(todo)
+	if err := applyRecurrence(todo, input, nil); err != nil {
+		return nil, err
+	}
+
+	todo, err := s.todoRepo.Create(todo)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(userID, event.TodoCreated, todo)
+	s.countCreated(1)
+
+	// 未完了の子が増えたので、自動完了済みの親を戻す
+	if todo.ParentID != nil {
+		if err := s.syncAncestors(userID, todo.ID); err != nil {
+			return nil, err
+		}
+	}
+	return todo, nil
 }
 
 // --- Update ---
-func (s *todoService) Update(userID uint, id uint, title string, done *bool) (*model.Todo, error) {
+func (s *todoService) Update(ctx context.Context, userID uint, id uint, input TodoInput, done *bool, pre Precondition) (*model.Todo, error) {
+	if err := validateTitle(input.Title); err != nil {
+		return nil, err
+	}
+
+	todo, err := s.todoRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrTodoNotFound
+	}
+	if err := s.checkPrecondition(userID, todo, pre); err != nil {
+		return nil, err
+	}
+
+	prevDue := todo.DueAt
+	todo.Title = input.Title
+	todo.AutoComplete = input.AutoComplete
+	if err := s.applyProject(todo, input); err != nil {
+		return nil, err
+	}
+	if err := applySchedule(todo, input); err != nil {
+		return nil, err
+	}
+	if err := applyRecurrence(todo, input, prevDue); err != nil {
+		return nil, err
+	}
+
+	return s.saveTodo(userID, todo, done)
+}
+
+// --- Patch ---
+// body（kind の形式）を PUT の body と同じ形の todo に適用して、変わった項目だけ検証して保存する。
+// 途中で失敗したら何も保存しない
+func (s *todoService) Patch(ctx context.Context, userID uint, id uint, kind string, body []byte, pre Precondition) (*model.Todo, error) {
+	todo, err := s.todoRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrTodoNotFound
+	}
+	if err := s.checkPrecondition(userID, todo, pre); err != nil {
+		return nil, err
+	}
+
+	current := newTodoDocument(todo)
+	patched, err := applyTodoPatch(current, kind, body)
+	if err != nil {
+		return nil, err
+	}
+	input, prevInput := patched.input(), current.input()
+
+	if patched.Title != current.Title {
+		if err := validateTitle(patched.Title); err != nil {
+			return nil, err
+		}
+		todo.Title = patched.Title
+	}
+	todo.AutoComplete = patched.AutoComplete
+
+	if err := s.applyProject(todo, input); err != nil {
+		return nil, err
+	}
+
+	// 保存済みの期日・開始日時は変わっていなければ検証し直さない
+	prevDue := todo.DueAt
+	scheduleChanged := input.Due != prevInput.Due || input.Start != prevInput.Start
+	if scheduleChanged {
+		if err := applySchedule(todo, input); err != nil {
+			return nil, err
+		}
+	}
+	if scheduleChanged || input.RRule != prevInput.RRule || input.TZ != prevInput.TZ {
+		if err := applyRecurrence(todo, input, prevDue); err != nil {
+			return nil, err
+		}
+	}
+
+	var done *bool
+	if patched.Done != current.Done {
+		done = &patched.Done
+	}
+	return s.saveTodo(userID, todo, done)
+}
+
+// saveTodo は todo を保存する。done が今と違えば完了状態を変え、
+// 繰り返しの次の回の作成と自動完了の親の更新も一緒に保存する
+func (s *todoService) saveTodo(userID uint, todo *model.Todo, done *bool) (*model.Todo, error) {
+	var created, updated []*model.Todo
+	wasDone := todo.Done
+	if done != nil && *done != todo.Done {
+		// 繰り返しの回を完了したら次の回を作る（系列は次の回に引き継ぐ）
+		if *done && todo.RRule != "" {
+			next, err := nextOccurrence(todo)
+			if err != nil {
+				return nil, err
+			}
+			if next != nil {
+				created = append(created, next)
+			}
+			todo.RRule, todo.RecurrenceTZ, todo.RecurrenceStart = "", "", nil
+		}
+		setDone(todo, *done)
+
+		// 自動完了の親を子の完了状態に合わせる（保存前なのでこの todo の状態は上書きで渡す）
+		ancestors, err := s.todoRepo.FindAncestors(userID, todo.ID)
+		if err != nil {
+			return nil, err
+		}
+		updated, err = s.completeAncestors(userID, ancestors, map[uint]bool{todo.ID: todo.Done})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(created) == 0 && len(updated) == 0 {
+		if _, err := s.todoRepo.Update(todo); err != nil {
+			return nil, err
+		}
+	} else if err := s.todoRepo.UpdateBatch(append([]*model.Todo{todo}, updated...), created); err != nil {
+		return nil, err
+	}
+
+	if err := s.fillProgress(userID, todo); err != nil {
+		return nil, err
+	}
+	s.publish(userID, event.TodoUpdated, append([]*model.Todo{todo}, updated...)...)
+	s.publish(userID, event.TodoCreated, created...)
+	s.countCreated(len(created))
+	// 状態が変わった親は updated に入っている
+	if !wasDone {
+		s.countCompleted(todo)
+	}
+	s.countCompleted(updated...)
+	return todo, nil
+}
+
+// --- Children ---
+func (s *todoService) Children(ctx context.Context, userID uint, id uint) ([]model.Todo, error) {
+	if _, err := s.todoRepo.FindByID(userID, id); err != nil {
+		return nil, ErrTodoNotFound
+	}
+
+	children, err := s.todoRepo.FindChildren(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if children == nil {
+		children = []model.Todo{}
+	}
+	if err := s.fillProgress(userID, todoPointers(children)...); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// --- Move ---
+// todo を子孫ごと parentID の下へ移す（nil ならルートへ）。
+// 自分の子孫の下には移せず、移した後の深さは MaxTodoDepth まで
+func (s *todoService) Move(ctx context.Context, userID uint, id uint, parentID *uint) (*model.Todo, error) {
 	todo, err := s.todoRepo.FindByID(userID, id)
 	if err != nil {
-		return nil, errors.New("todo not found")
+		return nil, ErrTodoNotFound
+	}
+	if equalID(todo.ParentID, parentID) {
+		return todo, s.fillProgress(userID, todo)
+	}
+
+	descendants, err := s.todoRepo.FindDescendants(userID, []uint{todo.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if *parentID == todo.ID {
+			return nil, fmt.Errorf("%w: a todo cannot be its own parent", ErrValidation)
+		}
+		height := 1
+		for _, node := range descendants {
+			if node.ID == *parentID {
+				return nil, fmt.Errorf("%w: cannot move a todo under its own subtask", ErrValidation)
+			}
+			height = max(height, node.Depth+1)
+		}
+		if err := s.checkParent(userID, *parentID, height); err != nil {
+			return nil, err
+		}
 	}
 
-	todo.Title = title
+	err = s.inTx(func(tx *todoService) error {
+		oldAncestors, err := tx.todoRepo.FindAncestors(userID, todo.ID)
+		if err != nil {
+			return err
+		}
+
+		todo.ParentID = parentID
+		if _, err := tx.todoRepo.Update(todo); err != nil {
+			return err
+		}
+		tx.publish(userID, event.TodoUpdated, todo)
+
+		// 移動元と移動先の親の自動完了を合わせる
+		if err := tx.saveCompletedAncestors(userID, oldAncestors); err != nil {
+			return err
+		}
+		if parentID != nil {
+			return tx.syncAncestors(userID, todo.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	if done != nil {
-		todo.Done = *done
+	if err := s.fillProgress(userID, todo); err != nil {
+		return nil, err
 	}
-	return s.todoRepo.Update(todo)
+	return todo, nil
+}
+
+// --- Occurrences ---
+// 現在の期日から count 件の期日を返す
+func (s *todoService) Occurrences(ctx context.Context, userID uint, id uint, count int) (*TodoOccurrences, error) {
+	todo, err := s.todoRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrTodoNotFound
+	}
+	if todo.RRule == "" {
+		return nil, fmt.Errorf("%w: todo is not recurring", ErrValidation)
+	}
+
+	if count <= 0 {
+		count = DefaultOccurrenceCount
+	}
+	if count > MaxOccurrenceCount {
+		count = MaxOccurrenceCount
+	}
+
+	rule, dtstart, err := todoSeries(todo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TodoOccurrences{
+		RRule:       todo.RRule,
+		TZ:          todo.RecurrenceTZ,
+		AllDay:      todo.DueAllDay,
+		Occurrences: []string{},
+	}
+	for _, t := range rule.Take(dtstart, todo.DueAt.In(dtstart.Location()), count) {
+		if todo.DueAllDay {
+			result.Occurrences = append(result.Occurrences, t.Format(time.DateOnly))
+		} else {
+			result.Occurrences = append(result.Occurrences, t.Format(time.RFC3339))
+		}
+	}
+	return result, nil
+}
+
+// setDone は完了状態を変え、CompletedAt を合わせる
+func setDone(todo *model.Todo, done bool) {
+	if done && !todo.Done {
+		now := time.Now()
+		todo.CompletedAt = &now
+	}
+	if !done {
+		todo.CompletedAt = nil
+	}
+	todo.Done = done
+}
+
+// applyProject は所属プロジェクトを検証して todo に設定する。
+// アーカイブ済みのプロジェクトには追加・移動できない（元から所属している Todo はそのまま）
+func (s *todoService) applyProject(todo *model.Todo, input TodoInput) error {
+	if input.ProjectID == nil {
+		todo.ProjectID = nil
+		return nil
+	}
+	if todo.ProjectID != nil && *todo.ProjectID == *input.ProjectID {
+		return nil
+	}
+
+	project, err := s.projectRepo.FindByID(todo.UserID, *input.ProjectID)
+	if err != nil {
+		return fmt.Errorf("%w: project %d not found", ErrValidation, *input.ProjectID)
+	}
+	if project.ArchivedAt != nil {
+		return fmt.Errorf("%w: project %d is archived", ErrValidation, *input.ProjectID)
+	}
+
+	projectID := project.ID
+	todo.ProjectID = &projectID
+	return nil
+}
+
+// applySchedule は期日・開始日時を検証して todo に設定する
+func applySchedule(todo *model.Todo, input TodoInput) error {
+	todo.DueAt, todo.DueAllDay, todo.StartAt = nil, false, nil
+
+	if input.Due != "" {
+		due, allDay, err := parseDue(input.Due)
+		if err != nil {
+			return err
+		}
+		todo.DueAt, todo.DueAllDay = &due, allDay
+	}
+
+	if input.Start != "" {
+		start, err := time.Parse(time.RFC3339, input.Start)
+		if err != nil {
+			return fmt.Errorf("%w: start_at must be RFC3339", ErrValidation)
+		}
+
+		if todo.DueAt != nil {
+			// 終日の場合は開始日時（入力されたオフセットでの日付）が期日の日付以前であればよい
+			tooLate := start.After(*todo.DueAt)
+			if todo.DueAllDay {
+				y, m, d := start.Date()
+				tooLate = time.Date(y, m, d, 0, 0, 0, 0, time.UTC).After(*todo.DueAt)
+			}
+			if tooLate {
+				return fmt.Errorf("%w: start_at must not be after due_at", ErrValidation)
+			}
+		}
+
+		start = start.UTC()
+		todo.StartAt = &start
+	}
+	return nil
+}
+
+// applyRecurrence は繰り返しのルールを検証して todo に設定する。
+// ルール・タイムゾーン・期日が変わらなければ系列の DTSTART（COUNT の起点）を保つ
+func applyRecurrence(todo *model.Todo, input TodoInput, prevDue *time.Time) error {
+	if input.RRule == "" {
+		todo.RRule, todo.RecurrenceTZ, todo.RecurrenceStart = "", "", nil
+		return nil
+	}
+
+	rule, err := recurrence.Parse(input.RRule)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if todo.DueAt == nil {
+		return fmt.Errorf("%w: rrule requires due_at", ErrValidation)
+	}
+
+	tz := input.TZ
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("%w: invalid tz %q", ErrValidation, input.TZ)
+	}
+
+	unchanged := todo.RecurrenceStart != nil &&
+		todo.RRule == rule.String() && todo.RecurrenceTZ == tz &&
+		prevDue != nil && prevDue.Equal(*todo.DueAt)
+	if !unchanged {
+		start := *todo.DueAt
+		todo.RecurrenceStart = &start
+	}
+	todo.RRule, todo.RecurrenceTZ = rule.String(), tz
+	return nil
+}
+
+// todoSeries は todo の繰り返しのルールと DTSTART を返す。
+// 終日は日付（UTC 0 時）のまま、時刻指定は RecurrenceTZ の時刻として展開する
+func todoSeries(todo *model.Todo) (*recurrence.Rule, time.Time, error) {
+	rule, err := recurrence.Parse(todo.RRule)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	loc := time.UTC
+	if !todo.DueAllDay {
+		if loc, err = time.LoadLocation(todo.RecurrenceTZ); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+
+	start := todo.DueAt
+	if todo.RecurrenceStart != nil {
+		start = todo.RecurrenceStart
+	}
+	return rule, start.In(loc), nil
+}
+
+// nextOccurrence は現在の期日の次の回を返す（系列が終わっていれば nil）。
+// 開始日時は期日との間隔を保ってずらす
+func nextOccurrence(todo *model.Todo) (*model.Todo, error) {
+	rule, dtstart, err := todoSeries(todo)
+	if err != nil {
+		return nil, err
+	}
+
+	due, ok := rule.Next(dtstart, *todo.DueAt)
+	if !ok {
+		return nil, nil
+	}
+	due = due.UTC()
+
+	next := &model.Todo{
+		UserID:          todo.UserID,
+		ProjectID:       todo.ProjectID,
+		Title:           todo.Title,
+		DueAt:           &due,
+		DueAllDay:       todo.DueAllDay,
+		RRule:           todo.RRule,
+		RecurrenceTZ:    todo.RecurrenceTZ,
+		RecurrenceStart: todo.RecurrenceStart,
+		Tags:            todo.Tags,
+	}
+	if todo.StartAt != nil {
+		start := due.Add(todo.StartAt.Sub(*todo.DueAt))
+		next.StartAt = &start
+	}
+	return next, nil
+}
+
+// parseDue は "2006-01-02" を終日（UTC 0 時）、RFC3339 を時刻指定（UTC）として解釈する
+func parseDue(s string) (time.Time, bool, error) {
+	if date, err := time.Parse(time.DateOnly, s); err == nil {
+		return date, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: due_at must be YYYY-MM-DD or RFC3339", ErrValidation)
+	}
+	return t.UTC(), false, nil
 }
 
 // --- Delete ---
-func (s *todoService) Delete(userID uint, id uint) error {
-	return s.todoRepo.Delete(userID, id)
+// 子孫も一緒に削除する
+// 条件付きのときは、確認した Version のまま削除できた場合だけ削除する
+func (s *todoService) Delete(ctx context.Context, userID uint, id uint, pre Precondition) error {
+	var version uint
+	if !pre.empty() {
+		todo, err := s.todoRepo.FindByID(userID, id)
+		if err != nil {
+			return ErrTodoNotFound
+		}
+		if err := s.checkPrecondition(userID, todo, pre); err != nil {
+			return err
+		}
+		version = todo.Version
+	}
+
+	ancestors, err := s.todoRepo.FindAncestors(userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.todoRepo.Delete(userID, id, version); err != nil {
+		return err
+	}
+	s.publishDeleted(userID, id)
+
+	// 未完了の子が消えて、残りが全て完了になった親を自動完了する
+	return s.saveCompletedAncestors(userID, ancestors)
+}
+
+// --- Restore ---
+// ゴミ箱の todo を一緒に削除されたサブタスクごと元に戻す。
+// 元の親が削除済みか、戻すと深さの上限を超える場合はルートに戻す
+func (s *todoService) Restore(ctx context.Context, userID uint, id uint) (*model.Todo, error) {
+	todo, err := s.todoRepo.FindDeleted(userID, id)
+	if err != nil {
+		return nil, ErrTodoNotFound
+	}
+
+	descendants, err := s.todoRepo.FindDeletedDescendants(userID, todo)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint{todo.ID}
+	height := 1
+	for _, node := range descendants {
+		ids = append(ids, node.ID)
+		height = max(height, node.Depth+1)
+	}
+
+	if todo.ParentID != nil {
+		err := s.checkParent(userID, *todo.ParentID, height)
+		if errors.Is(err, ErrValidation) {
+			todo.ParentID = nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.todoRepo.Restore(todo, ids); err != nil {
+		return nil, err
+	}
+	if todo.ParentID != nil {
+		if err := s.syncAncestors(userID, todo.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	restored, err := s.FindByID(ctx, userID, todo.ID)
+	if err != nil {
+		return nil, err
+	}
+	// クライアントからは削除済みなので、戻した todo は作成として通知する
+	s.publish(userID, event.TodoCreated, restored)
+	return restored, nil
+}
+
+// todoDocument は PATCH を適用する todo の表現（PUT の body と同じ項目名）。
+// 省略できる項目は未設定なら null
+type todoDocument struct {
+	Title        string  `json:"title"`
+	Done         bool    `json:"done"`
+	AutoComplete bool    `json:"auto_complete"`
+	ProjectID    *uint   `json:"project_id"`
+	DueAt        *string `json:"due_at"`
+	StartAt      *string `json:"start_at"`
+	RRule        *string `json:"rrule"`
+	TZ           *string `json:"tz"`
+}
+
+// 削除（null）できない項目
+var requiredTodoFields = []string{"title", "done", "auto_complete"}
+
+func newTodoDocument(todo *model.Todo) todoDocument {
+	doc := todoDocument{
+		Title:        todo.Title,
+		Done:         todo.Done,
+		AutoComplete: todo.AutoComplete,
+		ProjectID:    todo.ProjectID,
+	}
+	optional := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+
+	if todo.DueAt != nil {
+		if todo.DueAllDay {
+			doc.DueAt = optional(todo.DueAt.Format("2006-01-02"))
+		} else {
+			doc.DueAt = optional(todo.DueAt.UTC().Format(time.RFC3339Nano))
+		}
+	}
+	if todo.StartAt != nil {
+		doc.StartAt = optional(todo.StartAt.UTC().Format(time.RFC3339Nano))
+	}
+	doc.RRule = optional(todo.RRule)
+	doc.TZ = optional(todo.RecurrenceTZ)
+	return doc
+}
+
+func (d todoDocument) input() TodoInput {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return TodoInput{
+		ProjectID:    d.ProjectID,
+		AutoComplete: d.AutoComplete,
+		Title:        d.Title,
+		Due:          value(d.DueAt),
+		Start:        value(d.StartAt),
+		RRule:        value(d.RRule),
+		TZ:           value(d.TZ),
+	}
+}
+
+// applyTodoPatch は current に kind の形式の body を適用して、結果を項目ごとに検証する
+func applyTodoPatch(current todoDocument, kind string, body []byte) (todoDocument, error) {
+	var doc any
+	data, _ := json.Marshal(current)
+	_ = json.Unmarshal(data, &doc)
+
+	switch kind {
+	case PatchMerge:
+		var p any
+		if err := json.Unmarshal(body, &p); err != nil {
+			return todoDocument{}, fmt.Errorf("%w: invalid JSON: %v", ErrValidation, err)
+		}
+		if _, ok := p.(map[string]any); !ok {
+			return todoDocument{}, fmt.Errorf("%w: merge patch must be a JSON object", ErrValidation)
+		}
+		doc = patch.Merge(doc, p)
+
+	case PatchJSON:
+		var ops []patch.Operation
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return todoDocument{}, fmt.Errorf("%w: JSON Patch must be an array of operations: %v", ErrValidation, err)
+		}
+		var err error
+		doc, err = patch.Apply(doc, ops)
+		if errors.Is(err, ErrPatchConflict) {
+			return todoDocument{}, err
+		}
+		if err != nil {
+			return todoDocument{}, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+	default:
+		return todoDocument{}, fmt.Errorf("%w: unsupported patch format %q", ErrValidation, kind)
+	}
+
+	fields, ok := doc.(map[string]any)
+	if !ok {
+		return todoDocument{}, fmt.Errorf("%w: patched todo must be a JSON object", ErrValidation)
+	}
+	for _, key := range requiredTodoFields {
+		if fields[key] == nil {
+			return todoDocument{}, fmt.Errorf("%w: %s cannot be null or removed", ErrValidation, key)
+		}
+	}
+
+	// 知らない項目（"ttile" などの打ち間違い）と型の違いは項目名付きで返す
+	data, _ = json.Marshal(fields)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var patched todoDocument
+	if err := dec.Decode(&patched); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return todoDocument{}, fmt.Errorf("%w: %s must be %s", ErrValidation, typeErr.Field, typeErr.Type)
+		}
+		return todoDocument{}, fmt.Errorf("%w: %v", ErrValidation, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return patched, nil
+}
+
+func validateTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("%w: title is required", ErrValidation)
+	}
+	if utf8.RuneCountInString(title) > MaxTodoTitleLength {
+		return fmt.Errorf("%w: title must be at most %d characters", ErrValidation, MaxTodoTitleLength)
+	}
+	return nil
+}
+
+// checkPrecondition は読み込んだ todo の ETag が pre を満たすか確認する
+// （保存時は Version で、確認した後に更新されていないことを確かめる）
+func (s *todoService) checkPrecondition(userID uint, todo *model.Todo, pre Precondition) error {
+	if pre.empty() {
+		return nil
+	}
+	if err := s.fillProgress(userID, todo); err != nil {
+		return err
+	}
+	return pre.Check(TodoETag(todo))
+}
+
+// checkParent は parentID の下に高さ height の部分木を置けるか確認する
+func (s *todoService) checkParent(userID uint, parentID uint, height int) error {
+	if _, err := s.todoRepo.FindByID(userID, parentID); err != nil {
+		return fmt.Errorf("%w: parent %d not found", ErrValidation, parentID)
+	}
+
+	ancestors, err := s.todoRepo.FindAncestors(userID, parentID)
+	if err != nil {
+		return err
+	}
+	if depth := len(ancestors) + 1; depth+height > MaxTodoDepth {
+		return fmt.Errorf("%w: subtasks can be nested at most %d levels", ErrValidation, MaxTodoDepth)
+	}
+	return nil
+}
+
+// inTx は fn を 1 つのトランザクションで実行する。
+// fn の中の tx で通知したイベントは、コミットした後にまとめて送る（取り消したら送らない）
+func (s *todoService) inTx(fn func(tx *todoService) error) error {
+	events := &eventBuffer{}
+	err := s.todoRepo.Transaction(func(repo repository.TodoRepository) error {
+		return fn(&todoService{todoRepo: repo, projectRepo: s.projectRepo, events: events})
+	})
+	if err != nil {
+		return err
+	}
+	events.flush(s.events)
+	return nil
+}
+
+// syncAncestors は id の祖先の自動完了を保存済みの子の状態に合わせる
+func (s *todoService) syncAncestors(userID uint, id uint) error {
+	ancestors, err := s.todoRepo.FindAncestors(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.saveCompletedAncestors(userID, ancestors)
+}
+
+func (s *todoService) saveCompletedAncestors(userID uint, ancestors []model.Todo) error {
+	updated, err := s.completeAncestors(userID, ancestors, map[uint]bool{})
+	if err != nil || len(updated) == 0 {
+		return err
+	}
+	if err := s.todoRepo.UpdateBatch(updated, nil); err != nil {
+		return err
+	}
+	s.publish(userID, event.TodoUpdated, updated...)
+	s.countCompleted(updated...)
+	return nil
+}
+
+// completeAncestors は近い方から順に、AutoComplete の祖先を子が全て完了していれば完了、
+// そうでなければ未完了にする。状態が変わらなかった祖先で止め、変わった祖先を返す。
+// done は保存前の完了状態（id → done）で、保存済みの値より優先する
+func (s *todoService) completeAncestors(userID uint, ancestors []model.Todo, done map[uint]bool) ([]*model.Todo, error) {
+	var updated []*model.Todo
+	for i := range ancestors {
+		parent := &ancestors[i]
+		if !parent.AutoComplete {
+			break
+		}
+
+		children, err := s.todoRepo.FindChildren(userID, parent.ID)
+		if err != nil {
+			return nil, err
+		}
+		allDone := len(children) > 0
+		for _, child := range children {
+			childDone, ok := done[child.ID]
+			if !ok {
+				childDone = child.Done
+			}
+			allDone = allDone && childDone
+		}
+
+		if allDone == parent.Done {
+			break
+		}
+		setDone(parent, allDone)
+		done[parent.ID] = allDone
+		updated = append(updated, parent)
+	}
+	return updated, nil
+}
+
+// fillProgress は子を持つ todo に子孫の完了率を設定する。
+// 子の完了率の平均（子孫を持たない子は完了なら 100、未完了なら 0）
+func (s *todoService) fillProgress(userID uint, todos ...*model.Todo) error {
+	ids := make([]uint, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	nodes, err := s.todoRepo.FindDescendants(userID, ids)
+	if err != nil {
+		return err
+	}
+	children := make(map[uint][]repository.TodoNode)
+	for _, node := range nodes {
+		children[node.ParentID] = append(children[node.ParentID], node)
+	}
+
+	var progress func(id uint, done bool, depth int) int
+	progress = func(id uint, done bool, depth int) int {
+		kids := children[id]
+		if len(kids) == 0 || depth > MaxTodoDepth {
+			if done {
+				return 100
+			}
+			return 0
+		}
+		sum := 0
+		for _, kid := range kids {
+			sum += progress(kid.ID, kid.Done, depth+1)
+		}
+		return sum / len(kids)
+	}
+
+	for _, todo := range todos {
+		todo.Progress = nil
+		if len(children[todo.ID]) > 0 {
+			p := progress(todo.ID, todo.Done, 1)
+			todo.Progress = &p
+		}
+	}
+	return nil
+}
+
+func todoPointers(todos []model.Todo) []*model.Todo {
+	result := make([]*model.Todo, len(todos))
+	for i := range todos {
+		result[i] = &todos[i]
+	}
+	return result
+}
+
+func equalID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -1,24 +1,55 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
 	"github.com/a5415091-collab/go-gin-todo-app/service"
 )
 
 // --- Mock Repository 定義 ---
+// ctx はサービスを呼ぶときの共通の context（テストでは trace を使わない）
+var ctx = context.Background()
+
 type MockTodoRepository struct {
-	FindAllFunc  func(userID uint) ([]model.Todo, error)
-	FindByIDFunc func(userID uint, id uint) (*model.Todo, error)
-	CreateFunc   func(todo *model.Todo) (*model.Todo, error)
-	UpdateFunc   func(todo *model.Todo) (*model.Todo, error)
-	DeleteFunc   func(userID uint, id uint) error
+	FindAllFunc         func(userID uint, query repository.TodoQuery) ([]model.Todo, error)
+	SearchFunc          func(userID uint, terms []repository.SearchTerm, limit int) ([]repository.TodoSearchHit, error)
+	FindDueFunc         func(userID uint, window repository.DueWindow) ([]model.Todo, error)
+	FindByIDFunc        func(userID uint, id uint) (*model.Todo, error)
+	CreateFunc          func(todo *model.Todo) (*model.Todo, error)
+	UpdateFunc          func(todo *model.Todo) (*model.Todo, error)
+	UpdateBatchFunc     func(updated []*model.Todo, created []*model.Todo) error
+	FindChildrenFunc    func(userID uint, parentID uint) ([]model.Todo, error)
+	FindAncestorsFunc   func(userID uint, id uint) ([]model.Todo, error)
+	FindDescendantsFunc func(userID uint, rootIDs []uint) ([]repository.TodoNode, error)
+	DeleteFunc          func(userID uint, id uint, version uint) error
+	FindTrashFunc       func(userID uint) ([]model.Todo, error)
+	FindDeletedFunc     func(userID uint, id uint) (*model.Todo, error)
+	FindDeletedDescFunc func(userID uint, todo *model.Todo) ([]repository.TodoNode, error)
+	RestoreFunc         func(todo *model.Todo, ids []uint) error
+	PurgeFunc           func(userID uint, ids []uint) error
+	EmptyTrashFunc      func(userID uint) (int64, error)
+	PurgeBeforeFunc     func(before time.Time) (int64, error)
+	FindChangesFunc     func(userID uint, since int64, limit int) ([]model.Todo, error)
+	SyncSequenceFunc    func(userID uint) (int64, int64, error)
+	TransactionFunc     func(fn func(repo repository.TodoRepository) error) error
+}
+
+func (m *MockTodoRepository) FindAll(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
+	return m.FindAllFunc(userID, query)
+}
+
+func (m *MockTodoRepository) Search(userID uint, terms []repository.SearchTerm, limit int) ([]repository.TodoSearchHit, error) {
+	return m.SearchFunc(userID, terms, limit)
 }
 
-func (m *MockTodoRepository) FindAll(userID uint) ([]model.Todo, error) {
-	return m.FindAllFunc(userID)
+func (m *MockTodoRepository) FindDue(userID uint, window repository.DueWindow) ([]model.Todo, error) {
+	return m.FindDueFunc(userID, window)
 }
 
 func (m *MockTodoRepository) FindByID(userID uint, id uint) (*model.Todo, error) {
@@ -33,37 +64,143 @@ func (m *MockTodoRepository) Update(todo *model.Todo) (*model.Todo, error) {
 	return m.UpdateFunc(todo)
 }
 
-func (m *MockTodoRepository) Delete(userID uint, id uint) error {
-	return m.DeleteFunc(userID, id)
+func (m *MockTodoRepository) UpdateBatch(updated []*model.Todo, created []*model.Todo) error {
+	return m.UpdateBatchFunc(updated, created)
+}
+
+func (m *MockTodoRepository) FindChildren(userID uint, parentID uint) ([]model.Todo, error) {
+	return m.FindChildrenFunc(userID, parentID)
+}
+
+// 親子関係を使わないテストでは未設定のままでよい（親も子もない）
+func (m *MockTodoRepository) FindAncestors(userID uint, id uint) ([]model.Todo, error) {
+	if m.FindAncestorsFunc == nil {
+		return nil, nil
+	}
+	return m.FindAncestorsFunc(userID, id)
+}
+
+func (m *MockTodoRepository) FindDescendants(userID uint, rootIDs []uint) ([]repository.TodoNode, error) {
+	if m.FindDescendantsFunc == nil {
+		return nil, nil
+	}
+	return m.FindDescendantsFunc(userID, rootIDs)
+}
+
+func (m *MockTodoRepository) Delete(userID uint, id uint, version uint) error {
+	return m.DeleteFunc(userID, id, version)
+}
+
+func (m *MockTodoRepository) FindTrash(userID uint) ([]model.Todo, error) {
+	return m.FindTrashFunc(userID)
+}
+
+func (m *MockTodoRepository) FindDeleted(userID uint, id uint) (*model.Todo, error) {
+	return m.FindDeletedFunc(userID, id)
+}
+
+func (m *MockTodoRepository) FindDeletedDescendants(userID uint, todo *model.Todo) ([]repository.TodoNode, error) {
+	return m.FindDeletedDescFunc(userID, todo)
+}
+
+func (m *MockTodoRepository) Restore(todo *model.Todo, ids []uint) error {
+	return m.RestoreFunc(todo, ids)
+}
+
+func (m *MockTodoRepository) Purge(userID uint, ids []uint) error {
+	return m.PurgeFunc(userID, ids)
+}
+
+func (m *MockTodoRepository) EmptyTrash(userID uint) (int64, error) {
+	return m.EmptyTrashFunc(userID)
+}
+
+func (m *MockTodoRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	return m.PurgeBeforeFunc(before)
+}
+
+func (m *MockTodoRepository) FindChanges(userID uint, since int64, limit int) ([]model.Todo, error) {
+	return m.FindChangesFunc(userID, since, limit)
+}
+
+func (m *MockTodoRepository) SyncSequence(userID uint) (int64, int64, error) {
+	return m.SyncSequenceFunc(userID)
+}
+
+// TransactionFunc が nil ならそのまま fn を呼ぶ
+func (m *MockTodoRepository) Transaction(fn func(repo repository.TodoRepository) error) error {
+	if m.TransactionFunc == nil {
+		return fn(m)
+	}
+	return m.TransactionFunc(fn)
+}
+
+// WithContext は同じモックを返す（ctx はクエリの trace にだけ使う）
+func (m *MockTodoRepository) WithContext(ctx context.Context) repository.TodoRepository {
+	return m
 }
 
 // --- FindAll ---
 func TestTodoService_FindAll(t *testing.T) {
 
 	tests := []struct {
-		name       string
-		userID     uint
-		mockFind   func(userID uint) ([]model.Todo, error)
-		expectErr  bool
-		expectSize int
+		name         string
+		userID       uint
+		params       service.TodoListParams
+		mockFind     func(userID uint, query repository.TodoQuery) ([]model.Todo, error)
+		expectErr    bool
+		expectSize   int
+		expectCursor bool
 	}{
 		{
 			name:   "success find all",
 			userID: 1,
-			mockFind: func(userID uint) ([]model.Todo, error) {
+			params: service.TodoListParams{},
+			mockFind: func(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
 				t1 := model.Todo{UserID: userID, Title: "task1", Done: false}
 				t1.ID = 1
 				t2 := model.Todo{UserID: userID, Title: "task2", Done: true}
 				t2.ID = 2
 				return []model.Todo{t1, t2}, nil
 			},
-			expectErr:  false,
-			expectSize: 2,
+			expectErr:    false,
+			expectSize:   2,
+			expectCursor: false,
+		},
+		{
+			name:   "more rows than limit returns next cursor",
+			userID: 1,
+			params: service.TodoListParams{Limit: 2},
+			mockFind: func(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
+				if query.Limit != 3 {
+					return nil, errors.New("expected limit+1 to be requested")
+				}
+				todos := make([]model.Todo, 3)
+				for i := range todos {
+					todos[i].ID = uint(i + 1)
+					todos[i].UserID = userID
+				}
+				return todos, nil
+			},
+			expectErr:    false,
+			expectSize:   2,
+			expectCursor: true,
+		},
+		{
+			name:   "broken cursor",
+			userID: 1,
+			params: service.TodoListParams{Cursor: "not-a-cursor"},
+			mockFind: func(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
+				return nil, nil
+			},
+			expectErr:  true,
+			expectSize: 0,
 		},
 		{
 			name:   "failed find all",
 			userID: 1,
-			mockFind: func(userID uint) ([]model.Todo, error) {
+			params: service.TodoListParams{},
+			mockFind: func(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
 				return nil, errors.New("db error")
 			},
 			expectErr:  true,
@@ -78,9 +215,9 @@ func TestTodoService_FindAll(t *testing.T) {
 				FindAllFunc: tt.mockFind,
 			}
 
-			svc := service.NewTodoService(mockRepo)
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
 
-			result, err := svc.FindAll(tt.userID)
+			result, err := svc.FindAll(ctx, tt.userID, tt.params)
 
 			if tt.expectErr && err == nil {
 				t.Errorf("expected error but got none")
@@ -89,13 +226,155 @@ func TestTodoService_FindAll(t *testing.T) {
 				t.Errorf("unexpected error: %v", err)
 			}
 
-			if len(result) != tt.expectSize {
-				t.Errorf("expected %d items, got %d", tt.expectSize, len(result))
+			if tt.expectErr {
+				return
+			}
+			if len(result.Todos) != tt.expectSize {
+				t.Errorf("expected %d items, got %d", tt.expectSize, len(result.Todos))
+			}
+			if (result.NextCursor != "") != tt.expectCursor {
+				t.Errorf("next cursor mismatch: %q", result.NextCursor)
+			}
+		})
+	}
+}
+
+// next_cursor を渡すと最後の行の位置から続きを取得すること
+func TestTodoService_FindAll_Cursor(t *testing.T) {
+
+	var lastQuery repository.TodoQuery
+	mockRepo := &MockTodoRepository{
+		FindAllFunc: func(userID uint, query repository.TodoQuery) ([]model.Todo, error) {
+			lastQuery = query
+			todos := make([]model.Todo, query.Limit)
+			for i := range todos {
+				todos[i].ID = uint(i + 10)
+				todos[i].Title = "task"
+			}
+			return todos, nil
+		},
+	}
+
+	svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+	params := service.TodoListParams{Limit: 2, Sort: "title", Order: "desc"}
+	first, err := svc.FindAll(ctx, 1, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params.Cursor = first.NextCursor
+	if _, err := svc.FindAll(ctx, 1, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lastQuery.After == nil || lastQuery.After.ID != 11 || lastQuery.After.Value != "task" {
+		t.Errorf("unexpected cursor position: %+v", lastQuery.After)
+	}
+	if !lastQuery.Desc || lastQuery.SortBy != "title" {
+		t.Errorf("sort not applied: %+v", lastQuery)
+	}
+
+	// 並び順を変えたら同じカーソルは使えない
+	params.Order = "asc"
+	if _, err := svc.FindAll(ctx, 1, params); !errors.Is(err, service.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+// --- Search ---
+func TestTodoService_Search(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		q           string
+		expectErr   error
+		expectTerms []repository.SearchTerm
+	}{
+		{
+			name:        "words are prefix terms",
+			q:           "  buy  milk* ",
+			expectTerms: []repository.SearchTerm{{Text: "buy"}, {Text: "milk"}},
+		},
+		{
+			name:        "quoted phrase",
+			q:           `report "weekly   sync" q3`,
+			expectTerms: []repository.SearchTerm{{Text: "report"}, {Text: "weekly sync", Phrase: true}, {Text: "q3"}},
+		},
+		{
+			name:        "unterminated phrase runs to end",
+			q:           `"monthly report`,
+			expectTerms: []repository.SearchTerm{{Text: "monthly report", Phrase: true}},
+		},
+		{
+			name:        "fts operators are plain words",
+			q:           `NOT title:x OR -`,
+			expectTerms: []repository.SearchTerm{{Text: "NOT"}, {Text: "title:x"}, {Text: "OR"}},
+		},
+		{
+			name:      "empty query",
+			q:         `  "" * `,
+			expectErr: service.ErrEmptySearchQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var gotTerms []repository.SearchTerm
+			mockRepo := &MockTodoRepository{
+				SearchFunc: func(userID uint, terms []repository.SearchTerm, limit int) ([]repository.TodoSearchHit, error) {
+					gotTerms = terms
+					return nil, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			_, err := svc.Search(ctx, 1, tt.q, 0)
+
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr != nil {
+				return
+			}
+			if len(gotTerms) != len(tt.expectTerms) {
+				t.Fatalf("terms mismatch: expected %v, got %v", tt.expectTerms, gotTerms)
+			}
+			for i := range gotTerms {
+				if gotTerms[i] != tt.expectTerms[i] {
+					t.Errorf("term %d mismatch: expected %v, got %v", i, tt.expectTerms[i], gotTerms[i])
+				}
 			}
 		})
 	}
 }
 
+// スニペットは HTML エスケープされ、一致箇所だけ <mark> になること
+func TestTodoService_Search_Snippet(t *testing.T) {
+
+	mockRepo := &MockTodoRepository{
+		SearchFunc: func(userID uint, terms []repository.SearchTerm, limit int) ([]repository.TodoSearchHit, error) {
+			todo := model.Todo{UserID: userID, Title: "<b>fix</b> bug"}
+			todo.ID = 1
+			snippet := "<b>" + repository.HighlightStart + "fix" + repository.HighlightEnd + "</b> bug"
+			return []repository.TodoSearchHit{{Todo: todo, Snippet: snippet, Score: 1.5}}, nil
+		},
+	}
+
+	svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+	results, err := svc.Search(ctx, 1, "fix", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "&lt;b&gt;<mark>fix</mark>&lt;/b&gt; bug"
+	if len(results) != 1 || results[0].Snippet != expected {
+		t.Errorf("snippet mismatch: expected %q, got %+v", expected, results)
+	}
+}
+
 // --- FindByID ---
 func TestTodoService_FindByID(t *testing.T) {
 
@@ -139,9 +418,9 @@ func TestTodoService_FindByID(t *testing.T) {
 				FindByIDFunc: tt.mockFind,
 			}
 
-			svc := service.NewTodoService(mockRepo)
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
 
-			result, err := svc.FindByID(tt.userID, tt.id)
+			result, err := svc.FindByID(ctx, tt.userID, tt.id)
 
 			if tt.expectErr && err == nil {
 				t.Errorf("expected error but got none")
@@ -208,9 +487,9 @@ func TestTodoService_Create(t *testing.T) {
 				},
 			}
 
-			svc := service.NewTodoService(mockRepo)
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
 
-			_, err := svc.Create(1, tt.title)
+			_, err := svc.Create(ctx, 1, service.TodoInput{Title: tt.title})
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
@@ -273,9 +552,9 @@ func TestTodoService_Update(t *testing.T) {
 				UpdateFunc:   tt.mockUpdate,
 			}
 
-			svc := service.NewTodoService(mockRepo)
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
 
-			result, err := svc.Update(tt.userID, tt.id, tt.title, tt.done)
+			result, err := svc.Update(ctx, tt.userID, tt.id, service.TodoInput{Title: tt.title}, tt.done, service.Precondition{})
 
 			if tt.expectErr && err == nil {
 				t.Errorf("expected error but got none")
@@ -294,32 +573,205 @@ func ptrBool(b bool) *bool {
 	return &b
 }
 
-// --- Delete ---
-func TestTodoService_Delete(t *testing.T) {
+// 期日・開始日時の解釈と検証
+func TestTodoService_Create_Schedule(t *testing.T) {
 
 	tests := []struct {
-		name       string
-		userID     uint
-		id         uint
-		mockDelete func(userID uint, id uint) error
-		expectErr  bool
+		name         string
+		input        service.TodoInput
+		expectErr    bool
+		expectAllDay bool
+		expectDue    string
 	}{
 		{
-			name:   "success delete",
-			userID: 1,
-			id:     10,
-			mockDelete: func(userID uint, id uint) error {
-				return nil
-			},
+			name:      "no due date",
+			input:     service.TodoInput{Title: "task"},
 			expectErr: false,
 		},
 		{
-			name:   "failed delete",
-			userID: 1,
-			id:     10,
-			mockDelete: func(userID uint, id uint) error {
-				return errors.New("delete failed")
+			name:         "all-day due date",
+			input:        service.TodoInput{Title: "task", Due: "2026-03-31"},
+			expectErr:    false,
+			expectAllDay: true,
+			expectDue:    "2026-03-31T00:00:00Z",
+		},
+		{
+			name:         "timed due date is stored in UTC",
+			input:        service.TodoInput{Title: "task", Due: "2026-03-31T09:00:00+09:00"},
+			expectErr:    false,
+			expectAllDay: false,
+			expectDue:    "2026-03-31T00:00:00Z",
+		},
+		{
+			name:         "start on the all-day due date",
+			input:        service.TodoInput{Title: "task", Due: "2026-03-31", Start: "2026-03-31T23:00:00+09:00"},
+			expectErr:    false,
+			expectAllDay: true,
+			expectDue:    "2026-03-31T00:00:00Z",
+		},
+		{
+			name:      "start after timed due date",
+			input:     service.TodoInput{Title: "task", Due: "2026-03-31T09:00:00Z", Start: "2026-03-31T10:00:00Z"},
+			expectErr: true,
+		},
+		{
+			name:      "start after all-day due date",
+			input:     service.TodoInput{Title: "task", Due: "2026-03-31", Start: "2026-04-01T00:00:00Z"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid due format",
+			input:     service.TodoInput{Title: "task", Due: "31/03/2026"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid start format",
+			input:     service.TodoInput{Title: "task", Start: "tomorrow"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				CreateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Create(ctx, 1, tt.input)
+
+			if tt.expectErr {
+				if !errors.Is(err, service.ErrValidation) {
+					t.Errorf("expected validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if todo.DueAllDay != tt.expectAllDay {
+				t.Errorf("all-day mismatch: expected %v, got %v", tt.expectAllDay, todo.DueAllDay)
+			}
+			if tt.expectDue == "" && todo.DueAt != nil {
+				t.Errorf("expected no due date, got %v", todo.DueAt)
+			}
+			if tt.expectDue != "" && (todo.DueAt == nil || todo.DueAt.Format(time.RFC3339) != tt.expectDue) {
+				t.Errorf("due mismatch: expected %s, got %v", tt.expectDue, todo.DueAt)
+			}
+		})
+	}
+}
+
+// done の変化に合わせて CompletedAt が設定・解除されること
+func TestTodoService_Update_CompletedAt(t *testing.T) {
+
+	completed := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		current         model.Todo
+		done            *bool
+		expectCompleted bool
+		expectUnchanged bool
+	}{
+		{
+			name:            "complete sets completed_at",
+			current:         model.Todo{Title: "task", Done: false},
+			done:            ptrBool(true),
+			expectCompleted: true,
+		},
+		{
+			name:            "already done keeps completed_at",
+			current:         model.Todo{Title: "task", Done: true, CompletedAt: &completed},
+			done:            ptrBool(true),
+			expectCompleted: true,
+			expectUnchanged: true,
+		},
+		{
+			name:            "reopen clears completed_at",
+			current:         model.Todo{Title: "task", Done: true, CompletedAt: &completed},
+			done:            ptrBool(false),
+			expectCompleted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					current := tt.current
+					return &current, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Update(ctx, 1, 1, service.TodoInput{Title: "task"}, tt.done, service.Precondition{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (todo.CompletedAt != nil) != tt.expectCompleted {
+				t.Errorf("completed_at mismatch: %v", todo.CompletedAt)
+			}
+			if tt.expectUnchanged && !todo.CompletedAt.Equal(completed) {
+				t.Errorf("completed_at should not change: %v", todo.CompletedAt)
+			}
+		})
+	}
+}
+
+// 期日ビューの範囲が利用者のタイムゾーンの日付境界で計算されること
+func TestTodoService_FindDue(t *testing.T) {
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	y, m, d := time.Now().In(tokyo).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, tokyo)
+
+	tests := []struct {
+		name      string
+		view      string
+		days      int
+		check     func(w repository.DueWindow) bool
+		expectErr bool
+	}{
+		{
+			name: "overdue",
+			view: service.DueViewOverdue,
+			check: func(w repository.DueWindow) bool {
+				return w.TimedFrom == nil && w.TimedTo != nil &&
+					w.DateFrom == nil && w.DateTo.Equal(today)
+			},
+		},
+		{
+			name: "today",
+			view: service.DueViewToday,
+			check: func(w repository.DueWindow) bool {
+				return w.TimedTo.Equal(tomorrow) &&
+					w.DateFrom.Equal(today) && w.DateTo.Equal(today.AddDate(0, 0, 1))
 			},
+		},
+		{
+			name: "upcoming 3 days",
+			view: service.DueViewUpcoming,
+			days: 3,
+			check: func(w repository.DueWindow) bool {
+				return w.TimedFrom.Equal(tomorrow) && w.TimedTo.Equal(tomorrow.AddDate(0, 0, 3)) &&
+					w.DateFrom.Equal(today.AddDate(0, 0, 1)) && w.DateTo.Equal(today.AddDate(0, 0, 4))
+			},
+		},
+		{
+			name:      "unknown view",
+			view:      "someday",
 			expectErr: true,
 		},
 	}
@@ -327,20 +779,846 @@ func TestTodoService_Delete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
+			var got repository.DueWindow
 			mockRepo := &MockTodoRepository{
-				DeleteFunc: tt.mockDelete,
+				FindDueFunc: func(userID uint, window repository.DueWindow) ([]model.Todo, error) {
+					got = window
+					return nil, nil
+				},
 			}
 
-			svc := service.NewTodoService(mockRepo)
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
 
-			err := svc.Delete(tt.userID, tt.id)
+			todos, err := svc.FindDue(ctx, 1, tt.view, tokyo, tt.days)
 
-			if tt.expectErr && err == nil {
-				t.Errorf("expected error but got none")
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error=%v, got %v", tt.expectErr, err)
 			}
-			if !tt.expectErr && err != nil {
-				t.Errorf("unexpected error: %v", err)
+			if tt.expectErr {
+				return
+			}
+			if todos == nil {
+				t.Errorf("expected empty slice, got nil")
+			}
+			if !tt.check(got) {
+				t.Errorf("unexpected window: %+v", got)
+			}
+		})
+	}
+}
+
+// 所属プロジェクトの検証（アーカイブ済みには追加・移動できない）
+func TestTodoService_Project(t *testing.T) {
+
+	archivedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ptrUint := func(i uint) *uint { return &i }
+
+	projects := map[uint]*model.Project{
+		1: {Name: "active"},
+		2: {Name: "archived", ArchivedAt: &archivedAt},
+	}
+
+	tests := []struct {
+		name          string
+		currentID     *uint
+		inputID       *uint
+		expectErr     bool
+		expectProject *uint
+	}{
+		{name: "assign active project", inputID: ptrUint(1), expectProject: ptrUint(1)},
+		{name: "unassign", currentID: ptrUint(1), inputID: nil, expectProject: nil},
+		{name: "move into archived project", currentID: ptrUint(1), inputID: ptrUint(2), expectErr: true},
+		{name: "stay in archived project", currentID: ptrUint(2), inputID: ptrUint(2), expectProject: ptrUint(2)},
+		{name: "unknown project", inputID: ptrUint(9), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					return &model.Todo{UserID: userID, Title: "task", ProjectID: tt.currentID}, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+			}
+			projectRepo := &MockProjectRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Project, error) {
+					p, ok := projects[id]
+					if !ok {
+						return nil, errors.New("record not found")
+					}
+					p.ID = id
+					return p, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, projectRepo, nil)
+
+			todo, err := svc.Update(ctx, 1, 1, service.TodoInput{Title: "task", ProjectID: tt.inputID}, nil, service.Precondition{})
+
+			if tt.expectErr {
+				if !errors.Is(err, service.ErrValidation) {
+					t.Errorf("expected ErrValidation, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (todo.ProjectID == nil) != (tt.expectProject == nil) ||
+				(todo.ProjectID != nil && *todo.ProjectID != *tt.expectProject) {
+				t.Errorf("project mismatch: expected %v, got %v", tt.expectProject, todo.ProjectID)
+			}
+		})
+	}
+}
+
+// --- Recurrence ---
+func TestTodoService_Create_Recurrence(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		input       service.TodoInput
+		expectErr   bool
+		expectRRule string
+		expectTZ    string
+	}{
+		{
+			name:        "normalized rrule with default tz",
+			input:       service.TodoInput{Title: "task", Due: "2026-10-19", RRule: "rrule:freq=weekly;byday=mo"},
+			expectRRule: "FREQ=WEEKLY;BYDAY=MO",
+			expectTZ:    "UTC",
+		},
+		{
+			name:        "timed with tz",
+			input:       service.TodoInput{Title: "task", Due: "2026-10-19T09:00:00+09:00", RRule: "FREQ=DAILY", TZ: "Asia/Tokyo"},
+			expectRRule: "FREQ=DAILY",
+			expectTZ:    "Asia/Tokyo",
+		},
+		{
+			name:      "rrule without due",
+			input:     service.TodoInput{Title: "task", RRule: "FREQ=DAILY"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid rrule",
+			input:     service.TodoInput{Title: "task", Due: "2026-10-19", RRule: "FREQ=HOURLY"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid tz",
+			input:     service.TodoInput{Title: "task", Due: "2026-10-19", RRule: "FREQ=DAILY", TZ: "Mars/Olympus"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				CreateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Create(ctx, 1, tt.input)
+
+			if tt.expectErr {
+				if !errors.Is(err, service.ErrValidation) {
+					t.Errorf("expected ErrValidation, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if todo.RRule != tt.expectRRule || todo.RecurrenceTZ != tt.expectTZ {
+				t.Errorf("recurrence mismatch: %q %q", todo.RRule, todo.RecurrenceTZ)
+			}
+			if todo.RecurrenceStart == nil || !todo.RecurrenceStart.Equal(*todo.DueAt) {
+				t.Errorf("recurrence_start should equal due_at: %v", todo.RecurrenceStart)
+			}
+		})
+	}
+}
+
+// 繰り返しの回を完了すると次の回が作られ、系列が引き継がれること
+func TestTodoService_Update_NextOccurrence(t *testing.T) {
+
+	date := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	at := func(s string) *time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		t = t.UTC()
+		return &t
+	}
+
+	tests := []struct {
+		name        string
+		current     model.Todo
+		input       service.TodoInput
+		expectNext  *time.Time
+		expectStart *time.Time
+	}{
+		{
+			name: "monthly on the 31st skips to march",
+			current: model.Todo{Title: "rent", DueAt: date(2026, 1, 31), DueAllDay: true,
+				RRule: "FREQ=MONTHLY", RecurrenceTZ: "UTC", RecurrenceStart: date(2026, 1, 31)},
+			input:      service.TodoInput{Title: "rent", Due: "2026-01-31", RRule: "FREQ=MONTHLY"},
+			expectNext: date(2026, 3, 31),
+		},
+		{
+			name: "daily keeps wall clock across dst",
+			current: model.Todo{Title: "standup", DueAt: at("2026-03-07T09:00:00-05:00"),
+				StartAt:         at("2026-03-07T08:30:00-05:00"),
+				RRule:           "FREQ=DAILY",
+				RecurrenceTZ:    "America/New_York",
+				RecurrenceStart: at("2026-03-01T09:00:00-05:00")},
+			input: service.TodoInput{Title: "standup", Due: "2026-03-07T09:00:00-05:00",
+				Start: "2026-03-07T08:30:00-05:00", RRule: "FREQ=DAILY", TZ: "America/New_York"},
+			expectNext:  at("2026-03-08T09:00:00-04:00"),
+			expectStart: at("2026-03-08T08:30:00-04:00"),
+		},
+		{
+			name: "count is counted from the series start",
+			current: model.Todo{Title: "pill", DueAt: date(2026, 10, 3), DueAllDay: true,
+				RRule: "FREQ=DAILY;COUNT=3", RecurrenceTZ: "UTC", RecurrenceStart: date(2026, 10, 1)},
+			input:      service.TodoInput{Title: "pill", Due: "2026-10-03", RRule: "FREQ=DAILY;COUNT=3"},
+			expectNext: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var created *model.Todo
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					current := tt.current
+					current.UserID = userID
+					return &current, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					return todo, nil
+				},
+				UpdateBatchFunc: func(updated []*model.Todo, next []*model.Todo) error {
+					created = next[0]
+					return nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Update(ctx, 1, 1, tt.input, ptrBool(true), service.Precondition{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !todo.Done || todo.RRule != "" || todo.RecurrenceStart != nil {
+				t.Errorf("completed todo should leave the series: %+v", todo)
+			}
+
+			if tt.expectNext == nil {
+				if created != nil {
+					t.Errorf("expected no next occurrence, got %v", created.DueAt)
+				}
+				return
+			}
+			if created == nil {
+				t.Fatalf("expected next occurrence")
+			}
+			if !created.DueAt.Equal(*tt.expectNext) {
+				t.Errorf("next due mismatch: expected %s, got %s", tt.expectNext, created.DueAt)
+			}
+			if tt.expectStart != nil && !created.StartAt.Equal(*tt.expectStart) {
+				t.Errorf("next start mismatch: expected %s, got %s", tt.expectStart, created.StartAt)
+			}
+			if created.Done || created.UserID != 1 || created.RRule != tt.current.RRule ||
+				!created.RecurrenceStart.Equal(*tt.current.RecurrenceStart) {
+				t.Errorf("series should carry over: %+v", created)
+			}
+		})
+	}
+}
+
+func TestTodoService_Occurrences(t *testing.T) {
+
+	due := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	timed := time.Date(2026, 10, 31, 13, 0, 0, 0, time.UTC) // New York 9:00 EDT
+
+	tests := []struct {
+		name      string
+		current   model.Todo
+		count     int
+		expectErr bool
+		expected  []string
+	}{
+		{
+			name: "all day month end",
+			current: model.Todo{DueAt: &due, DueAllDay: true,
+				RRule: "FREQ=MONTHLY", RecurrenceTZ: "UTC", RecurrenceStart: &due},
+			count:    3,
+			expected: []string{"2026-01-31", "2026-03-31", "2026-05-31"},
+		},
+		{
+			name: "timed in series tz",
+			current: model.Todo{DueAt: &timed,
+				RRule: "FREQ=DAILY", RecurrenceTZ: "America/New_York", RecurrenceStart: &timed},
+			count:    2,
+			expected: []string{"2026-10-31T09:00:00-04:00", "2026-11-01T09:00:00-05:00"},
+		},
+		{
+			name:      "not recurring",
+			current:   model.Todo{DueAt: &due},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					current := tt.current
+					return &current, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			result, err := svc.Occurrences(ctx, 1, 1, tt.count)
+
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error=%v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if len(result.Occurrences) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, result.Occurrences)
+			}
+			for i := range tt.expected {
+				if result.Occurrences[i] != tt.expected[i] {
+					t.Errorf("occurrence %d mismatch: expected %s, got %s", i, tt.expected[i], result.Occurrences[i])
+				}
+			}
+		})
+	}
+}
+
+// --- Delete ---
+func TestTodoService_Delete(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		userID     uint
+		id         uint
+		mockDelete func(userID uint, id uint, version uint) error
+		expectErr  bool
+	}{
+		{
+			name:   "success delete",
+			userID: 1,
+			id:     10,
+			mockDelete: func(userID uint, id uint, version uint) error {
+				return nil
+			},
+			expectErr: false,
+		},
+		{
+			name:   "failed delete",
+			userID: 1,
+			id:     10,
+			mockDelete: func(userID uint, id uint, version uint) error {
+				return errors.New("delete failed")
+			},
+			expectErr: true,
+		},
+		{
+			name:   "not found",
+			userID: 1,
+			id:     10,
+			mockDelete: func(userID uint, id uint, version uint) error {
+				return repository.ErrTodoNotFound
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockTodoRepository{
+				DeleteFunc: tt.mockDelete,
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			err := svc.Delete(ctx, tt.userID, tt.id, service.Precondition{})
+
+			if tt.expectErr && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// todoTree はテスト用の親子関係（id → todo）をリポジトリの検索として返す
+func todoTree(todos ...model.Todo) *MockTodoRepository {
+	byID := make(map[uint]*model.Todo)
+	for i := range todos {
+		byID[todos[i].ID] = &todos[i]
+	}
+	childrenOf := func(parentID uint) []model.Todo {
+		var children []model.Todo
+		for _, todo := range todos {
+			if todo.ParentID != nil && *todo.ParentID == parentID {
+				children = append(children, *byID[todo.ID])
+			}
+		}
+		return children
+	}
+
+	return &MockTodoRepository{
+		FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+			todo, ok := byID[id]
+			if !ok {
+				return nil, errors.New("record not found")
+			}
+			copied := *todo
+			return &copied, nil
+		},
+		FindChildrenFunc: func(userID uint, parentID uint) ([]model.Todo, error) {
+			return childrenOf(parentID), nil
+		},
+		FindAncestorsFunc: func(userID uint, id uint) ([]model.Todo, error) {
+			var ancestors []model.Todo
+			for todo := byID[id]; todo.ParentID != nil; {
+				todo = byID[*todo.ParentID]
+				ancestors = append(ancestors, *todo)
+			}
+			return ancestors, nil
+		},
+		FindDescendantsFunc: func(userID uint, rootIDs []uint) ([]repository.TodoNode, error) {
+			var nodes []repository.TodoNode
+			var walk func(id uint, depth int)
+			walk = func(id uint, depth int) {
+				for _, child := range childrenOf(id) {
+					nodes = append(nodes, repository.TodoNode{ID: child.ID, ParentID: id, Done: child.Done, Depth: depth})
+					walk(child.ID, depth+1)
+				}
+			}
+			for _, id := range rootIDs {
+				walk(id, 1)
+			}
+			return nodes, nil
+		},
+		UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+			return todo, nil
+		},
+	}
+}
+
+func treeTodo(id uint, parentID *uint, done bool) model.Todo {
+	todo := model.Todo{UserID: 1, Title: "task", ParentID: parentID, Done: done}
+	todo.ID = id
+	return todo
+}
+
+// --- Move ---
+// 1 ─ 2 ─ 3 ─ 4 と 5 ─ 6 の木で移動先を検証する（MaxTodoDepth は 5）
+func TestTodoService_Move(t *testing.T) {
+
+	ptrUint := func(u uint) *uint { return &u }
+
+	tests := []struct {
+		name      string
+		id        uint
+		parentID  *uint
+		expectErr error
+	}{
+		{name: "move to root", id: 3, parentID: nil},
+		{name: "move under another todo", id: 2, parentID: ptrUint(5)},
+		{name: "own parent", id: 2, parentID: ptrUint(2), expectErr: service.ErrValidation},
+		{name: "under own subtask", id: 2, parentID: ptrUint(4), expectErr: service.ErrValidation},
+		{name: "leaf to deepest level", id: 6, parentID: ptrUint(4)},
+		{name: "subtree too deep", id: 5, parentID: ptrUint(4), expectErr: service.ErrValidation},
+		{name: "parent not found", id: 2, parentID: ptrUint(9), expectErr: service.ErrValidation},
+		{name: "todo not found", id: 9, parentID: nil, expectErr: service.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			updated := false
+			mockRepo := todoTree(
+				treeTodo(1, nil, false),
+				treeTodo(2, ptrUint(1), false),
+				treeTodo(3, ptrUint(2), false),
+				treeTodo(4, ptrUint(3), false),
+				treeTodo(5, nil, false),
+				treeTodo(6, ptrUint(5), false),
+			)
+			mockRepo.UpdateFunc = func(todo *model.Todo) (*model.Todo, error) {
+				updated = true
+				return todo, nil
+			}
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Move(ctx, 1, tt.id, tt.parentID)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				if updated {
+					t.Errorf("update should not be called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !updated {
+				t.Errorf("expected update")
+			}
+			if (todo.ParentID == nil) != (tt.parentID == nil) || (todo.ParentID != nil && *todo.ParentID != *tt.parentID) {
+				t.Errorf("parent mismatch: %v", todo.ParentID)
+			}
+		})
+	}
+}
+
+// --- Update (親の自動完了) ---
+// 親 1（AutoComplete）の子 2, 3 のうち 2 を変更する
+func TestTodoService_Update_AutoCompleteParent(t *testing.T) {
+
+	ptrUint := func(u uint) *uint { return &u }
+
+	tests := []struct {
+		name         string
+		siblingDone  bool
+		parentDone   bool
+		autoComplete bool
+		done         bool
+		expectParent *bool // nil なら親は更新されない
+	}{
+		{name: "last child completes parent", siblingDone: true, autoComplete: true, done: true, expectParent: ptrBool(true)},
+		{name: "other child still open", siblingDone: false, autoComplete: true, done: true},
+		{name: "reopening child reopens parent", siblingDone: true, parentDone: true, autoComplete: true, done: false, expectParent: ptrBool(false)},
+		{name: "parent without auto complete", siblingDone: true, autoComplete: false, done: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			parent := treeTodo(1, nil, tt.parentDone)
+			parent.AutoComplete = tt.autoComplete
+			mockRepo := todoTree(
+				parent,
+				treeTodo(2, ptrUint(1), !tt.done),
+				treeTodo(3, ptrUint(1), tt.siblingDone),
+			)
+			var batch []*model.Todo
+			mockRepo.UpdateBatchFunc = func(updated []*model.Todo, created []*model.Todo) error {
+				batch = updated
+				return nil
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Update(ctx, 1, 2, service.TodoInput{Title: "task"}, ptrBool(tt.done), service.Precondition{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if todo.Done != tt.done {
+				t.Errorf("done mismatch: %v", todo.Done)
+			}
+
+			if tt.expectParent == nil {
+				if batch != nil {
+					t.Errorf("parent should not be updated: %+v", batch)
+				}
+				return
+			}
+			if len(batch) != 2 || batch[0].ID != 2 || batch[1].ID != 1 {
+				t.Fatalf("expected todo and parent in one batch, got %+v", batch)
+			}
+			if batch[1].Done != *tt.expectParent || (batch[1].CompletedAt != nil) != *tt.expectParent {
+				t.Errorf("parent mismatch: %+v", batch[1])
+			}
+		})
+	}
+}
+
+// --- FindByID (進捗) ---
+// 1 の子は 2（完了）と 3（未完了、子 4 完了・5 未完了）で (100 + 50) / 2 = 75
+func TestTodoService_FindByID_Progress(t *testing.T) {
+
+	ptrUint := func(u uint) *uint { return &u }
+
+	svc := service.NewTodoService(todoTree(
+		treeTodo(1, nil, false),
+		treeTodo(2, ptrUint(1), true),
+		treeTodo(3, ptrUint(1), false),
+		treeTodo(4, ptrUint(3), true),
+		treeTodo(5, ptrUint(3), false),
+	), &MockProjectRepository{}, nil)
+
+	tests := []struct {
+		id     uint
+		expect *int
+	}{
+		{id: 1, expect: ptrInt(75)},
+		{id: 3, expect: ptrInt(50)},
+		{id: 2, expect: nil}, // 子のない todo には進捗を付けない
+	}
+
+	for _, tt := range tests {
+		todo, err := svc.FindByID(ctx, 1, tt.id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if (todo.Progress == nil) != (tt.expect == nil) || (todo.Progress != nil && *todo.Progress != *tt.expect) {
+			t.Errorf("todo %d: expected progress %v, got %v", tt.id, tt.expect, todo.Progress)
+		}
+	}
+}
+
+func ptrInt(i int) *int {
+	return &i
+}
+
+// --- Restore ---
+// ゴミ箱の 10（子 11 と一緒に削除）を戻す。木は 1 ─ 2 ─ 3 ─ 4
+func TestTodoService_Restore(t *testing.T) {
+
+	ptrUint := func(u uint) *uint { return &u }
+
+	tests := []struct {
+		name         string
+		id           uint
+		parentID     *uint
+		expectParent *uint
+		expectErr    error
+	}{
+		{name: "back under its parent", id: 10, parentID: ptrUint(2), expectParent: ptrUint(2)},
+		{name: "parent deleted goes to root", id: 10, parentID: ptrUint(9), expectParent: nil},
+		{name: "too deep goes to root", id: 10, parentID: ptrUint(4), expectParent: nil},
+		{name: "root stays root", id: 10, parentID: nil, expectParent: nil},
+		{name: "not in trash", id: 1, expectErr: service.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var restored []uint
+			var restoredParent *uint
+			mockRepo := todoTree(
+				treeTodo(1, nil, false),
+				treeTodo(2, ptrUint(1), false),
+				treeTodo(3, ptrUint(2), false),
+				treeTodo(4, ptrUint(3), false),
+				treeTodo(10, nil, false), // 戻した後の FindByID 用
+			)
+			mockRepo.FindDeletedFunc = func(userID uint, id uint) (*model.Todo, error) {
+				if id != 10 {
+					return nil, errors.New("record not found")
+				}
+				todo := treeTodo(10, tt.parentID, false)
+				return &todo, nil
+			}
+			mockRepo.FindDeletedDescFunc = func(userID uint, todo *model.Todo) ([]repository.TodoNode, error) {
+				return []repository.TodoNode{{ID: 11, ParentID: 10, Depth: 1}}, nil
+			}
+			mockRepo.RestoreFunc = func(todo *model.Todo, ids []uint) error {
+				restored = ids
+				restoredParent = todo.ParentID
+				return nil
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			_, err := svc.Restore(ctx, 1, tt.id)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				if restored != nil {
+					t.Errorf("restore should not be called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(restored) != 2 || restored[0] != 10 || restored[1] != 11 {
+				t.Errorf("expected todo and subtask restored, got %v", restored)
+			}
+			if (restoredParent == nil) != (tt.expectParent == nil) || (restoredParent != nil && *restoredParent != *tt.expectParent) {
+				t.Errorf("parent mismatch: expected %v, got %v", tt.expectParent, restoredParent)
+			}
+		})
+	}
+}
+
+// --- Patch ---
+func TestTodoService_Patch(t *testing.T) {
+
+	due := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	// 終日の期日より後の UTC 日付だが、作成時のオフセット（-05:00）では期日当日だった開始日時
+	start := time.Date(2026, 3, 3, 4, 0, 0, 0, time.UTC)
+	allDay := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		current   model.Todo
+		kind      string
+		body      string
+		expectErr error
+		check     func(t *testing.T, todo *model.Todo)
+	}{
+		{
+			name:    "merge toggles only done",
+			current: model.Todo{Title: "keep", DueAt: &due},
+			kind:    service.PatchMerge,
+			body:    `{"done": true}`,
+			check: func(t *testing.T, todo *model.Todo) {
+				if !todo.Done || todo.CompletedAt == nil || todo.Title != "keep" || todo.DueAt == nil {
+					t.Errorf("unexpected todo: %+v", todo)
+				}
+			},
+		},
+		{
+			name:    "merge null clears optional field",
+			current: model.Todo{Title: "keep", DueAt: &due},
+			kind:    service.PatchMerge,
+			body:    `{"due_at": null}`,
+			check: func(t *testing.T, todo *model.Todo) {
+				if todo.DueAt != nil {
+					t.Errorf("due_at should be cleared: %v", todo.DueAt)
+				}
+			},
+		},
+		{
+			name:    "unchanged schedule is not revalidated",
+			current: model.Todo{Title: "old", DueAt: &allDay, DueAllDay: true, StartAt: &start},
+			kind:    service.PatchMerge,
+			body:    `{"title": "new"}`,
+			check: func(t *testing.T, todo *model.Todo) {
+				if todo.Title != "new" || todo.StartAt == nil || !todo.DueAllDay {
+					t.Errorf("unexpected todo: %+v", todo)
+				}
+			},
+		},
+		{
+			name:    "json patch replace title",
+			current: model.Todo{Title: "old"},
+			kind:    service.PatchJSON,
+			body:    `[{"op": "test", "path": "/title", "value": "old"}, {"op": "replace", "path": "/title", "value": "new"}]`,
+			check: func(t *testing.T, todo *model.Todo) {
+				if todo.Title != "new" {
+					t.Errorf("title mismatch: %s", todo.Title)
+				}
+			},
+		},
+		{
+			name:      "json patch test failure",
+			current:   model.Todo{Title: "old"},
+			kind:      service.PatchJSON,
+			body:      `[{"op": "test", "path": "/title", "value": "other"}, {"op": "replace", "path": "/title", "value": "new"}]`,
+			expectErr: service.ErrPatchConflict,
+		},
+		{
+			name:      "required field removed",
+			current:   model.Todo{Title: "old"},
+			kind:      service.PatchMerge,
+			body:      `{"title": null}`,
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "empty title",
+			current:   model.Todo{Title: "old"},
+			kind:      service.PatchMerge,
+			body:      `{"title": " "}`,
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "unknown field",
+			current:   model.Todo{Title: "old"},
+			kind:      service.PatchMerge,
+			body:      `{"ttile": "typo"}`,
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "wrong type",
+			current:   model.Todo{Title: "old"},
+			kind:      service.PatchMerge,
+			body:      `{"done": "yes"}`,
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "not an object",
+			current:   model.Todo{Title: "old"},
+			kind:      service.PatchMerge,
+			body:      `[{"op": "remove", "path": "/title"}]`,
+			expectErr: service.ErrValidation,
+		},
+		{
+			name:      "removing due of recurring todo",
+			current:   model.Todo{Title: "old", DueAt: &due, RRule: "FREQ=DAILY", RecurrenceTZ: "UTC", RecurrenceStart: &due},
+			kind:      service.PatchMerge,
+			body:      `{"due_at": null}`,
+			expectErr: service.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			updated := false
+			mockRepo := &MockTodoRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Todo, error) {
+					current := tt.current
+					current.ID, current.UserID = id, userID
+					return &current, nil
+				},
+				UpdateFunc: func(todo *model.Todo) (*model.Todo, error) {
+					updated = true
+					return todo, nil
+				},
+			}
+
+			svc := service.NewTodoService(mockRepo, &MockProjectRepository{}, nil)
+
+			todo, err := svc.Patch(ctx, 1, 1, tt.kind, []byte(tt.body), service.Precondition{})
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				if updated {
+					t.Errorf("nothing should be saved on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !updated {
+				t.Errorf("expected update")
 			}
+			tt.check(t, todo)
 		})
 	}
 }
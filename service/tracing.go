@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/a5415091-collab/go-gin-todo-app/service")
+
+// startSpan はサービスのメソッド 1 回分の span を始める
+func startSpan(ctx context.Context, name string, userID uint) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attribute.Int64("user.id", int64(userID))))
+}
+
+// endSpan はエラーを記録して span を終える。入力の誤りや見つからないなどはクライアントの問題なので失敗にはしない
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if !errors.Is(err, ErrValidation) && !errors.Is(err, ErrTodoNotFound) && !errors.Is(err, ErrPreconditionFailed) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// with は ctx を付けたリポジトリを使う todoService を返す（クエリの span をリクエストの trace に入れる）
+func (s *todoService) with(ctx context.Context) *todoService {
+	return &todoService{
+		todoRepo:    s.todoRepo.WithContext(ctx),
+		projectRepo: s.projectRepo.WithContext(ctx),
+		events:      s.events,
+	}
+}
+
+func (s *authService) with(ctx context.Context) *authService {
+	return &authService{
+		userRepo:  s.userRepo.WithContext(ctx),
+		tokenRepo: s.tokenRepo.WithContext(ctx),
+	}
+}
+
+// --- TodoService ---
+
+// tracedTodoService はメソッドごとに span を作ってから todoService を呼ぶ
+type tracedTodoService struct {
+	next *todoService
+}
+
+func (s *tracedTodoService) FindAll(ctx context.Context, userID uint, params TodoListParams) (page *TodoPage, err error) {
+	ctx, span := startSpan(ctx, "TodoService.FindAll", userID)
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).FindAll(ctx, userID, params)
+}
+
+func (s *tracedTodoService) Search(ctx context.Context, userID uint, q string, limit int) (results []TodoSearchResult, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Search", userID)
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Search(ctx, userID, q, limit)
+}
+
+func (s *tracedTodoService) FindDue(ctx context.Context, userID uint, view string, loc *time.Location, days int) (todos []model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.FindDue", userID)
+	span.SetAttributes(attribute.String("todo.view", view))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).FindDue(ctx, userID, view, loc, days)
+}
+
+func (s *tracedTodoService) FindByID(ctx context.Context, userID uint, id uint) (todo *model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.FindByID", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).FindByID(ctx, userID, id)
+}
+
+func (s *tracedTodoService) Occurrences(ctx context.Context, userID uint, id uint, count int) (occurrences *TodoOccurrences, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Occurrences", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Occurrences(ctx, userID, id, count)
+}
+
+func (s *tracedTodoService) Children(ctx context.Context, userID uint, id uint) (todos []model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Children", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Children(ctx, userID, id)
+}
+
+func (s *tracedTodoService) Move(ctx context.Context, userID uint, id uint, parentID *uint) (todo *model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Move", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Move(ctx, userID, id, parentID)
+}
+
+func (s *tracedTodoService) Create(ctx context.Context, userID uint, input TodoInput) (todo *model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Create", userID)
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Create(ctx, userID, input)
+}
+
+func (s *tracedTodoService) Update(ctx context.Context, userID uint, id uint, input TodoInput, done *bool, pre Precondition) (todo *model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Update", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Update(ctx, userID, id, input, done, pre)
+}
+
+func (s *tracedTodoService) Patch(ctx context.Context, userID uint, id uint, kind string, body []byte, pre Precondition) (todo *model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Patch", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)), attribute.String("todo.patch", kind))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Patch(ctx, userID, id, kind, body, pre)
+}
+
+func (s *tracedTodoService) Delete(ctx context.Context, userID uint, id uint, pre Precondition) (err error) {
+	ctx, span := startSpan(ctx, "TodoService.Delete", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Delete(ctx, userID, id, pre)
+}
+
+func (s *tracedTodoService) Restore(ctx context.Context, userID uint, id uint) (todo *model.Todo, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Restore", userID)
+	span.SetAttributes(attribute.Int64("todo.id", int64(id)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Restore(ctx, userID, id)
+}
+
+func (s *tracedTodoService) Bulk(ctx context.Context, userID uint, mode string, ops []BulkOperation) (results []BulkResult, err error) {
+	ctx, span := startSpan(ctx, "TodoService.Bulk", userID)
+	span.SetAttributes(attribute.String("bulk.mode", mode), attribute.Int("bulk.operations", len(ops)))
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Bulk(ctx, userID, mode, ops)
+}
+
+// --- AuthService ---
+
+// tracedAuthService はメソッドごとに span を作ってから authService を呼ぶ（email やトークンは属性に入れない）
+type tracedAuthService struct {
+	next *authService
+}
+
+func (s *tracedAuthService) Signup(ctx context.Context, email, password string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Signup")
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Signup(ctx, email, password)
+}
+
+func (s *tracedAuthService) Login(ctx context.Context, email, password string) (user *model.User, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Login(ctx, email, password)
+}
+
+func (s *tracedAuthService) IssueTokens(ctx context.Context, userID uint) (pair *TokenPair, err error) {
+	ctx, span := startSpan(ctx, "AuthService.IssueTokens", userID)
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).IssueTokens(ctx, userID)
+}
+
+func (s *tracedAuthService) Refresh(ctx context.Context, refreshToken string) (pair *TokenPair, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Refresh")
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Refresh(ctx, refreshToken)
+}
+
+func (s *tracedAuthService) Logout(ctx context.Context, userID uint, jti string, expiresAt time.Time) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.Logout", userID)
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).Logout(ctx, userID, jti, expiresAt)
+}
+
+func (s *tracedAuthService) LogoutAll(ctx context.Context, userID uint) (err error) {
+	ctx, span := startSpan(ctx, "AuthService.LogoutAll", userID)
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).LogoutAll(ctx, userID)
+}
+
+func (s *tracedAuthService) IsRevoked(ctx context.Context, jti string) (revoked bool, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.IsRevoked")
+	defer func() { endSpan(span, err) }()
+	return s.next.with(ctx).IsRevoked(ctx, jti)
+}
+
+// PurgeExpired はリクエストの外で定期実行するので span を作らない
+func (s *tracedAuthService) PurgeExpired(now time.Time) (int64, error) {
+	return s.next.PurgeExpired(now)
+}
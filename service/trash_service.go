@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+// TrashItem はゴミ箱の todo と、完全に削除される予定日時
+type TrashItem struct {
+	Todo      model.Todo `json:"todo"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   time.Time  `json:"purge_at"`
+}
+
+// ゴミ箱からの復元は親や自動完了の扱いが必要なので TodoService.Restore で行う
+type TrashService interface {
+	FindAll(ctx context.Context, userID uint) ([]TrashItem, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	Empty(ctx context.Context, userID uint) (int64, error)
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+type trashService struct {
+	todoRepo  repository.TodoRepository
+	retention time.Duration
+}
+
+// retention は削除してから完全に削除するまでの期間
+func NewTrashService(todoRepo repository.TodoRepository, retention time.Duration) TrashService {
+	return &trashService{todoRepo, retention}
+}
+
+func (s *trashService) with(ctx context.Context) *trashService {
+	return &trashService{s.todoRepo.WithContext(ctx), s.retention}
+}
+
+// --- FindAll ---
+func (s *trashService) FindAll(ctx context.Context, userID uint) ([]TrashItem, error) {
+	s = s.with(ctx)
+	todos, err := s.todoRepo.FindTrash(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TrashItem, len(todos))
+	for i, todo := range todos {
+		items[i] = TrashItem{
+			Todo:      todo,
+			DeletedAt: todo.DeletedAt.Time,
+			PurgeAt:   todo.DeletedAt.Time.Add(s.retention),
+		}
+	}
+	return items, nil
+}
+
+// --- Delete ---
+// ゴミ箱の todo を一緒に削除されたサブタスクごと完全に削除する
+func (s *trashService) Delete(ctx context.Context, userID uint, id uint) error {
+	s = s.with(ctx)
+	todo, err := s.todoRepo.FindDeleted(userID, id)
+	if err != nil {
+		return ErrTodoNotFound
+	}
+
+	descendants, err := s.todoRepo.FindDeletedDescendants(userID, todo)
+	if err != nil {
+		return err
+	}
+	ids := []uint{todo.ID}
+	for _, node := range descendants {
+		ids = append(ids, node.ID)
+	}
+
+	return s.todoRepo.Purge(userID, ids)
+}
+
+// --- Empty ---
+func (s *trashService) Empty(ctx context.Context, userID uint) (int64, error) {
+	s = s.with(ctx)
+	return s.todoRepo.EmptyTrash(userID)
+}
+
+// --- PurgeExpired ---
+// 全ユーザーの、保存期間を過ぎた todo を完全に削除する（定期実行用）
+func (s *trashService) PurgeExpired(now time.Time) (int64, error) {
+	return s.todoRepo.PurgeDeletedBefore(now.Add(-s.retention))
+}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+	"gorm.io/gorm"
+)
+
+const retention = 30 * 24 * time.Hour
+
+// --- FindAll ---
+func TestTrashService_FindAll(t *testing.T) {
+
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo := &MockTodoRepository{
+		FindTrashFunc: func(userID uint) ([]model.Todo, error) {
+			todo := model.Todo{UserID: userID, Title: "old"}
+			todo.DeletedAt = gorm.DeletedAt{Time: deletedAt, Valid: true}
+			return []model.Todo{todo}, nil
+		},
+	}
+
+	svc := service.NewTrashService(mockRepo, retention)
+
+	items, err := svc.FindAll(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if !items[0].DeletedAt.Equal(deletedAt) || !items[0].PurgeAt.Equal(deletedAt.Add(retention)) {
+		t.Errorf("unexpected dates: %+v", items[0])
+	}
+}
+
+// --- Delete ---
+func TestTrashService_Delete(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		id        uint
+		expectErr error
+		expectIDs []uint
+	}{
+		{name: "with subtasks", id: 1, expectIDs: []uint{1, 2, 3}},
+		{name: "not in trash", id: 9, expectErr: service.ErrTodoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var purged []uint
+			mockRepo := &MockTodoRepository{
+				FindDeletedFunc: func(userID uint, id uint) (*model.Todo, error) {
+					if id != 1 {
+						return nil, errors.New("record not found")
+					}
+					todo := treeTodo(1, nil, false)
+					return &todo, nil
+				},
+				FindDeletedDescFunc: func(userID uint, todo *model.Todo) ([]repository.TodoNode, error) {
+					return []repository.TodoNode{{ID: 2, ParentID: 1, Depth: 1}, {ID: 3, ParentID: 2, Depth: 2}}, nil
+				},
+				PurgeFunc: func(userID uint, ids []uint) error {
+					purged = ids
+					return nil
+				},
+			}
+
+			svc := service.NewTrashService(mockRepo, retention)
+
+			err := svc.Delete(ctx, 1, tt.id)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				if purged != nil {
+					t.Errorf("purge should not be called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(purged) != len(tt.expectIDs) {
+				t.Fatalf("expected %v, got %v", tt.expectIDs, purged)
+			}
+			for i := range purged {
+				if purged[i] != tt.expectIDs[i] {
+					t.Errorf("expected %v, got %v", tt.expectIDs, purged)
+				}
+			}
+		})
+	}
+}
+
+// --- PurgeExpired ---
+// 保存期間より前に削除されたものだけが対象になる
+func TestTrashService_PurgeExpired(t *testing.T) {
+
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	var before time.Time
+	mockRepo := &MockTodoRepository{
+		PurgeBeforeFunc: func(b time.Time) (int64, error) {
+			before = b
+			return 3, nil
+		},
+	}
+
+	svc := service.NewTrashService(mockRepo, retention)
+
+	purged, err := svc.PurgeExpired(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purged != 3 {
+		t.Errorf("expected 3 purged, got %d", purged)
+	}
+	if !before.Equal(now.Add(-retention)) {
+		t.Errorf("cutoff mismatch: %v", before)
+	}
+}
@@ -0,0 +1,535 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/event"
+	"github.com/a5415091-collab/go-gin-todo-app/logger"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+)
+
+const (
+	MaxWebhooksPerUser      = 10
+	MaxWebhookURLLength     = 2000
+	MinWebhookSecretLength  = 16
+	MaxWebhookAttempts      = 8  // 最初の送信と再送 7 回
+	WebhookDisableThreshold = 20 // 連続してこの回数失敗したら webhook を止める
+	DefaultDeliveryLimit    = 20
+	MaxDeliveryLimit        = 100
+)
+
+const (
+	webhookQueueSize    = 1024
+	webhookPollInterval = 5 * time.Second // 再送の予定を確認する間隔
+	webhookBatchSize    = 100
+	maxWebhookBackoff   = time.Hour
+	webhookUserAgent    = "go-gin-todo-app-webhook"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookDisabled         = errors.New("webhook is disabled")
+)
+
+// WebhookEvents は webhook で受け取れるイベントの種類
+var WebhookEvents = []string{event.TodoCreated, event.TodoUpdated, event.TodoDeleted}
+
+// WebhookInput は作成・更新時の入力
+type WebhookInput struct {
+	URL    string
+	Events []string
+	Secret string // 空なら作成時は自動で生成し、更新時は変えない
+	Active *bool  // 更新時のみ。true にすると失敗の回数を数え直す
+}
+
+// WebhookOptions は送信の設定
+type WebhookOptions struct {
+	Timeout   time.Duration // 1 回の送信を待つ上限
+	RetryBase time.Duration // 最初の再送までの間隔（以降は倍ずつ、最大 1 時間）
+	Retention time.Duration // 送信の記録を残す期間
+
+	// AllowPrivateTargets はループバック・プライベート・リンクローカルなどのアドレスにも送る（ローカルでの開発用）
+	AllowPrivateTargets bool
+}
+
+type WebhookService interface {
+	FindAll(ctx context.Context, userID uint) ([]model.Webhook, error)
+	FindByID(ctx context.Context, userID uint, id uint) (*model.Webhook, error)
+	Create(ctx context.Context, userID uint, input WebhookInput) (*model.Webhook, error)
+	Update(ctx context.Context, userID uint, id uint, input WebhookInput) (*model.Webhook, error)
+	Delete(ctx context.Context, userID uint, id uint) error
+	Deliveries(ctx context.Context, userID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, userID uint, webhookID uint, deliveryID uint) (*model.WebhookDelivery, error)
+
+	// EventPublisher。イベントは Run が送信待ちとして保存して送る
+	Publish(userID uint, typ string, data any)
+	// Run は ctx が終わるまで送信待ちを送り続ける
+	Run(ctx context.Context)
+	// ProcessDue は now までに送る予定の送信待ちを送って、送った数を返す
+	ProcessDue(ctx context.Context, now time.Time) (int, error)
+	PurgeExpired(now time.Time) (int64, error)
+}
+
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	options     WebhookOptions
+	queue       chan webhookEvent
+	wake        chan struct{}
+}
+
+// webhookPayload は送る body（GET /events の data と同じ形で id はない）
+type webhookPayload struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+type webhookEvent struct {
+	userID uint
+	typ    string
+	data   any
+	time   time.Time
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, options WebhookOptions) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		client:      newWebhookClient(options.Timeout, options.AllowPrivateTargets),
+		options:     options,
+		queue:       make(chan webhookEvent, webhookQueueSize),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// with は ctx を付けたリポジトリを使う webhookService を返す（キューと送信先のクライアントは共有する）
+func (s *webhookService) with(ctx context.Context) *webhookService {
+	next := *s
+	next.webhookRepo = s.webhookRepo.WithContext(ctx)
+	return &next
+}
+
+// SignWebhook は送信の署名（X-Webhook-Signature）を返す。
+// 受け取る側は X-Webhook-Timestamp と body から同じ値を計算して比べる
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// --- FindAll ---
+func (s *webhookService) FindAll(ctx context.Context, userID uint) ([]model.Webhook, error) {
+	s = s.with(ctx)
+	webhooks, err := s.webhookRepo.FindAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []model.Webhook{}
+	}
+	return webhooks, nil
+}
+
+// --- FindByID ---
+func (s *webhookService) FindByID(ctx context.Context, userID uint, id uint) (*model.Webhook, error) {
+	s = s.with(ctx)
+	webhook, err := s.webhookRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// --- Create ---
+func (s *webhookService) Create(ctx context.Context, userID uint, input WebhookInput) (*model.Webhook, error) {
+	s = s.with(ctx)
+	events, err := s.validateInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.webhookRepo.Count(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxWebhooksPerUser {
+		return nil, fmt.Errorf("%w: at most %d webhooks are allowed", ErrValidation, MaxWebhooksPerUser)
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	webhook := &model.Webhook{
+		UserID: userID,
+		URL:    input.URL,
+		Events: events,
+		Secret: secret,
+		Active: true,
+	}
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// --- Update ---
+func (s *webhookService) Update(ctx context.Context, userID uint, id uint, input WebhookInput) (*model.Webhook, error) {
+	s = s.with(ctx)
+	events, err := s.validateInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.webhookRepo.FindByID(userID, id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	webhook.URL = input.URL
+	webhook.Events = events
+	if input.Secret != "" {
+		webhook.Secret = input.Secret
+	}
+	if input.Active != nil && *input.Active != webhook.Active {
+		webhook.Active = *input.Active
+		webhook.FailureCount = 0
+		webhook.DisabledAt = nil
+		if !webhook.Active {
+			now := time.Now()
+			webhook.DisabledAt = &now
+		}
+	}
+	if err := s.webhookRepo.Update(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// --- Delete ---
+func (s *webhookService) Delete(ctx context.Context, userID uint, id uint) error {
+	s = s.with(ctx)
+	webhook, err := s.webhookRepo.FindByID(userID, id)
+	if err != nil {
+		return ErrWebhookNotFound
+	}
+	return s.webhookRepo.Delete(webhook)
+}
+
+// --- Deliveries ---
+// 送信の記録を新しい順に返す
+func (s *webhookService) Deliveries(ctx context.Context, userID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	s = s.with(ctx)
+	if limit <= 0 {
+		limit = DefaultDeliveryLimit
+	}
+	if limit > MaxDeliveryLimit {
+		limit = MaxDeliveryLimit
+	}
+
+	if _, err := s.webhookRepo.FindByID(userID, webhookID); err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	deliveries, err := s.webhookRepo.FindDeliveries(userID, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
+
+// --- Redeliver ---
+// 過去の送信と同じ payload を新しい送信としてすぐに送る（元の記録はそのまま残る）
+func (s *webhookService) Redeliver(ctx context.Context, userID uint, webhookID uint, deliveryID uint) (*model.WebhookDelivery, error) {
+	s = s.with(ctx)
+	webhook, err := s.webhookRepo.FindByID(userID, webhookID)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	if !webhook.Active {
+		return nil, ErrWebhookDisabled
+	}
+
+	original, err := s.webhookRepo.FindDelivery(userID, webhookID, deliveryID)
+	if err != nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	now := time.Now()
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		UserID:        userID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return delivery, nil
+}
+
+// --- Publish ---
+// リクエストを待たせないように、保存と送信は Run に任せる
+func (s *webhookService) Publish(userID uint, typ string, data any) {
+	select {
+	case s.queue <- webhookEvent{userID, typ, data, time.Now()}:
+	default:
+		// Publish は保存が確定した後に呼ばれるのでリクエストの ctx がない
+		logger.Warn(context.Background(), "webhook queue is full, event dropped", "userID", userID, "event", typ)
+	}
+}
+
+// --- Run ---
+func (s *webhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	// 受け取り済みのイベントの保存はシャットダウンで取り消さない（取り消すとイベントが失われる）
+	saveCtx := context.WithoutCancel(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			// 受け取り済みのイベントは送信待ちとして保存しておく（次に起動したときに送る）
+			for {
+				select {
+				case ev := <-s.queue:
+					s.enqueue(saveCtx, ev)
+				default:
+					return
+				}
+			}
+		case ev := <-s.queue:
+			s.enqueue(saveCtx, ev)
+		case <-s.wake:
+		case <-ticker.C:
+		}
+		if _, err := s.ProcessDue(ctx, time.Now()); err != nil {
+			logger.Error(ctx, "failed to process webhook deliveries", "reason", err.Error())
+		}
+	}
+}
+
+// enqueue はイベントを受け取る webhook ごとに送信待ちとして保存する
+func (s *webhookService) enqueue(ctx context.Context, ev webhookEvent) {
+	s = s.with(ctx)
+	webhooks, err := s.webhookRepo.FindActive(ev.userID)
+	if err != nil {
+		logger.Error(ctx, "failed to find webhooks", "userID", ev.userID, "reason", err.Error())
+		return
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !slices.Contains(webhook.Events, ev.typ) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(webhookPayload{Type: ev.typ, Time: ev.time, Data: ev.data})
+			if err != nil {
+				logger.Error(ctx, "failed to encode webhook payload", "event", ev.typ, "reason", err.Error())
+				return
+			}
+		}
+
+		now := time.Now()
+		delivery := &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			UserID:        ev.userID,
+			Event:         ev.typ,
+			Payload:       payload,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: &now,
+		}
+		if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+			logger.Error(ctx, "failed to save webhook delivery", "webhookID", webhook.ID, "reason", err.Error())
+		}
+	}
+}
+
+// --- ProcessDue ---
+func (s *webhookService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	s = s.with(ctx)
+	deliveries, err := s.webhookRepo.FindDueDeliveries(now, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range deliveries {
+		// シャットダウン中。残りは送信待ちのまま、次に起動したときに送る
+		if ctx.Err() != nil {
+			return i, nil
+		}
+		if err := s.deliver(ctx, &deliveries[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// deliver は 1 件を送って結果を保存する。
+// 失敗したら RetryBase から倍ずつ間隔を空けて再送し、MaxWebhookAttempts 回で諦める。
+// webhook ごとに連続して WebhookDisableThreshold 回失敗したら webhook を止める
+//
+// 再送は jobs のキューを使わず、送信の記録（webhook_deliveries）の Status と NextAttemptAt で管理する。
+// 記録は Deliveries・Redeliver でそのままユーザーに見せるものなので、ジョブと二重に持たないようにしている
+func (s *webhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	ctx = logger.With(ctx, "webhook_id", delivery.WebhookID, "delivery_id", delivery.ID)
+	webhook, err := s.webhookRepo.FindByID(delivery.UserID, delivery.WebhookID)
+	if err != nil {
+		// 送信の記録ごと削除された webhook
+		logger.Warn(ctx, "webhook not found for delivery")
+		return nil
+	}
+	if !webhook.Active {
+		delivery.Status, delivery.NextAttemptAt, delivery.Error = model.DeliveryFailed, nil, ErrWebhookDisabled.Error()
+		return s.webhookRepo.UpdateDelivery(delivery)
+	}
+
+	status, sendErr := s.send(ctx, webhook, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// シャットダウンで送信を打ち切った。相手の失敗ではないので回数に数えず、送信待ちのまま残す
+		logger.Info(ctx, "webhook delivery interrupted by shutdown")
+		return nil
+	}
+	delivery.Attempts++
+	now := time.Now()
+	delivery.ResponseStatus = status
+
+	if sendErr == nil {
+		delivery.Status, delivery.NextAttemptAt, delivery.Error, delivery.DeliveredAt = model.DeliverySucceeded, nil, "", &now
+		if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+			return err
+		}
+		return s.webhookRepo.ResetFailures(webhook.ID)
+	}
+
+	logger.Warn(ctx, "webhook delivery failed", "attempt", delivery.Attempts, "reason", sendErr.Error())
+	delivery.Error = sendErr.Error()
+	if delivery.Attempts >= MaxWebhookAttempts {
+		delivery.Status, delivery.NextAttemptAt = model.DeliveryFailed, nil
+	} else {
+		next := now.Add(s.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		return err
+	}
+
+	failures, err := s.webhookRepo.IncrementFailures(webhook.ID)
+	if err != nil {
+		return err
+	}
+	if failures >= WebhookDisableThreshold {
+		logger.Warn(ctx, "webhook disabled after repeated failures", "failures", failures)
+		return s.webhookRepo.Disable(webhook.ID, now)
+	}
+	return nil
+}
+
+// backoff は attempts 回失敗した後、次に送るまでの間隔
+func (s *webhookService) backoff(attempts int) time.Duration {
+	d := s.options.RetryBase
+	for i := 1; i < attempts && d < maxWebhookBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxWebhookBackoff)
+}
+
+// send は署名付きで POST する。2xx 以外はエラー。ctx が取り消されたら送信を打ち切る
+func (s *webhookService) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// --- PurgeExpired ---
+// Retention を過ぎた送信の記録を削除する
+func (s *webhookService) PurgeExpired(now time.Time) (int64, error) {
+	return s.webhookRepo.DeleteDeliveriesBefore(now.Add(-s.options.Retention))
+}
+
+// validateInput は入力を確認して、重複を除いたイベントの種類を返す
+func (s *webhookService) validateInput(input WebhookInput) ([]string, error) {
+	if len(input.URL) > MaxWebhookURLLength {
+		return nil, fmt.Errorf("%w: url must be at most %d characters", ErrValidation, MaxWebhookURLLength)
+	}
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrValidation)
+	}
+	if !s.options.AllowPrivateTargets {
+		if err := checkWebhookHost(u.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(input.Events) == 0 {
+		return nil, fmt.Errorf("%w: events are required", ErrValidation)
+	}
+	var events []string
+	for _, typ := range input.Events {
+		if !slices.Contains(WebhookEvents, typ) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrValidation, typ)
+		}
+		if !slices.Contains(events, typ) {
+			events = append(events, typ)
+		}
+	}
+
+	if input.Secret != "" && len(input.Secret) < MinWebhookSecretLength {
+		return nil, fmt.Errorf("%w: secret must be at least %d characters", ErrValidation, MinWebhookSecretLength)
+	}
+	return events, nil
+}
@@ -0,0 +1,601 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a5415091-collab/go-gin-todo-app/event"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/repository"
+	"github.com/a5415091-collab/go-gin-todo-app/service"
+)
+
+// --- Mock ---
+type MockWebhookRepository struct {
+	FindAllFunc                func(userID uint) ([]model.Webhook, error)
+	FindByIDFunc               func(userID uint, id uint) (*model.Webhook, error)
+	FindActiveFunc             func(userID uint) ([]model.Webhook, error)
+	CountFunc                  func(userID uint) (int64, error)
+	CreateFunc                 func(webhook *model.Webhook) error
+	UpdateFunc                 func(webhook *model.Webhook) error
+	DeleteFunc                 func(webhook *model.Webhook) error
+	ResetFailuresFunc          func(id uint) error
+	IncrementFailuresFunc      func(id uint) (int, error)
+	DisableFunc                func(id uint, at time.Time) error
+	FindDeliveriesFunc         func(userID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	FindDeliveryFunc           func(userID uint, webhookID uint, id uint) (*model.WebhookDelivery, error)
+	FindDueDeliveriesFunc      func(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	CreateDeliveryFunc         func(delivery *model.WebhookDelivery) error
+	UpdateDeliveryFunc         func(delivery *model.WebhookDelivery) error
+	DeleteDeliveriesBeforeFunc func(before time.Time) (int64, error)
+}
+
+func (m *MockWebhookRepository) FindAll(userID uint) ([]model.Webhook, error) {
+	return m.FindAllFunc(userID)
+}
+
+func (m *MockWebhookRepository) FindByID(userID uint, id uint) (*model.Webhook, error) {
+	return m.FindByIDFunc(userID, id)
+}
+
+func (m *MockWebhookRepository) FindActive(userID uint) ([]model.Webhook, error) {
+	return m.FindActiveFunc(userID)
+}
+
+func (m *MockWebhookRepository) Count(userID uint) (int64, error) {
+	return m.CountFunc(userID)
+}
+
+func (m *MockWebhookRepository) Create(webhook *model.Webhook) error {
+	return m.CreateFunc(webhook)
+}
+
+func (m *MockWebhookRepository) Update(webhook *model.Webhook) error {
+	return m.UpdateFunc(webhook)
+}
+
+func (m *MockWebhookRepository) Delete(webhook *model.Webhook) error {
+	return m.DeleteFunc(webhook)
+}
+
+func (m *MockWebhookRepository) ResetFailures(id uint) error {
+	return m.ResetFailuresFunc(id)
+}
+
+func (m *MockWebhookRepository) IncrementFailures(id uint) (int, error) {
+	return m.IncrementFailuresFunc(id)
+}
+
+func (m *MockWebhookRepository) Disable(id uint, at time.Time) error {
+	return m.DisableFunc(id, at)
+}
+
+func (m *MockWebhookRepository) FindDeliveries(userID uint, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	return m.FindDeliveriesFunc(userID, webhookID, limit)
+}
+
+func (m *MockWebhookRepository) FindDelivery(userID uint, webhookID uint, id uint) (*model.WebhookDelivery, error) {
+	return m.FindDeliveryFunc(userID, webhookID, id)
+}
+
+func (m *MockWebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	return m.FindDueDeliveriesFunc(now, limit)
+}
+
+func (m *MockWebhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return m.CreateDeliveryFunc(delivery)
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return m.UpdateDeliveryFunc(delivery)
+}
+
+func (m *MockWebhookRepository) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	return m.DeleteDeliveriesBeforeFunc(before)
+}
+
+func (m *MockWebhookRepository) WithContext(ctx context.Context) repository.WebhookRepository {
+	return m
+}
+
+// ctxWebhookRepository は WithContext で受け取った ctx が取り消されていたら、DB と同じように失敗する
+type ctxWebhookRepository struct {
+	*MockWebhookRepository
+	ctx context.Context
+}
+
+func (r *ctxWebhookRepository) FindActive(userID uint) ([]model.Webhook, error) {
+	if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.MockWebhookRepository.FindActive(userID)
+}
+
+func (r *ctxWebhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	return r.MockWebhookRepository.CreateDelivery(delivery)
+}
+
+func (r *ctxWebhookRepository) WithContext(ctx context.Context) repository.WebhookRepository {
+	return &ctxWebhookRepository{r.MockWebhookRepository, ctx}
+}
+
+// 受け取る側は httptest のサーバー（127.0.0.1）なので、プライベートアドレスへの送信を許す
+var webhookOptions = service.WebhookOptions{Timeout: 5 * time.Second, RetryBase: 30 * time.Second, Retention: 24 * time.Hour, AllowPrivateTargets: true}
+
+// --- Create ---
+func TestWebhookService_Create(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		input        service.WebhookInput
+		count        int64
+		expectErr    error
+		expectEvents []string
+	}{
+		{name: "success", input: service.WebhookInput{URL: "https://example.com/hook", Events: []string{"todo.created", "todo.created", "todo.deleted"}}, expectEvents: []string{"todo.created", "todo.deleted"}},
+		{name: "with secret", input: service.WebhookInput{URL: "http://localhost:9000", Events: []string{"todo.updated"}, Secret: "0123456789abcdef"}, expectEvents: []string{"todo.updated"}},
+		{name: "relative url", input: service.WebhookInput{URL: "/hook", Events: []string{"todo.created"}}, expectErr: service.ErrValidation},
+		{name: "unsupported scheme", input: service.WebhookInput{URL: "ftp://example.com", Events: []string{"todo.created"}}, expectErr: service.ErrValidation},
+		{name: "no events", input: service.WebhookInput{URL: "https://example.com"}, expectErr: service.ErrValidation},
+		{name: "unknown event", input: service.WebhookInput{URL: "https://example.com", Events: []string{"todo.archived"}}, expectErr: service.ErrValidation},
+		{name: "short secret", input: service.WebhookInput{URL: "https://example.com", Events: []string{"todo.created"}, Secret: "short"}, expectErr: service.ErrValidation},
+		{name: "too many webhooks", input: service.WebhookInput{URL: "https://example.com", Events: []string{"todo.created"}}, count: service.MaxWebhooksPerUser, expectErr: service.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockWebhookRepository{
+				CountFunc: func(userID uint) (int64, error) {
+					return tt.count, nil
+				},
+				CreateFunc: func(webhook *model.Webhook) error {
+					webhook.ID = 1
+					return nil
+				},
+			}
+
+			svc := service.NewWebhookService(mockRepo, webhookOptions)
+
+			webhook, err := svc.Create(ctx, 1, tt.input)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !webhook.Active || webhook.UserID != 1 || strings.Join(webhook.Events, ",") != strings.Join(tt.expectEvents, ",") {
+				t.Errorf("unexpected webhook: %+v", webhook)
+			}
+			if tt.input.Secret != "" && webhook.Secret != tt.input.Secret {
+				t.Errorf("expected given secret, got %q", webhook.Secret)
+			}
+			if len(webhook.Secret) < service.MinWebhookSecretLength {
+				t.Errorf("expected generated secret, got %q", webhook.Secret)
+			}
+		})
+	}
+}
+
+// --- ProcessDue（送信・再送・自動で止める） ---
+func TestWebhookService_ProcessDue(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		status         int // 受け取る側が返すステータス
+		attempts       int // これまでに送った回数
+		failures       int // これまでに連続して失敗した回数
+		active         bool
+		expectStatus   string
+		expectBackoff  time.Duration // 次に送るまでの間隔（0 なら送信待ちではない）
+		expectSent     bool
+		expectReset    bool
+		expectDisabled bool
+	}{
+		{name: "success", status: 200, active: true, expectStatus: model.DeliverySucceeded, expectSent: true, expectReset: true},
+		{name: "first failure", status: 500, active: true, expectStatus: model.DeliveryPending, expectBackoff: 30 * time.Second, expectSent: true},
+		{name: "backoff doubles", status: 500, attempts: 3, active: true, expectStatus: model.DeliveryPending, expectBackoff: 8 * 30 * time.Second, expectSent: true},
+		{name: "redirect is failure", status: 302, active: true, expectStatus: model.DeliveryPending, expectBackoff: 30 * time.Second, expectSent: true},
+		{name: "last attempt", status: 500, attempts: service.MaxWebhookAttempts - 1, active: true, expectStatus: model.DeliveryFailed, expectSent: true},
+		{name: "disabled after repeated failures", status: 500, failures: service.WebhookDisableThreshold - 1, active: true, expectStatus: model.DeliveryPending, expectBackoff: 30 * time.Second, expectSent: true, expectDisabled: true},
+		{name: "disabled webhook is not sent", status: 200, active: false, expectStatus: model.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			payload := []byte(`{"type":"todo.created","data":{"ID":1}}`)
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				if tt.status == 302 {
+					http.Redirect(w, r, "/elsewhere", http.StatusFound)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			webhook := &model.Webhook{ID: 3, UserID: 1, URL: receiver.URL, Secret: "0123456789abcdef", Active: tt.active, FailureCount: tt.failures}
+			var saved *model.WebhookDelivery
+			reset, disabled := false, false
+			mockRepo := &MockWebhookRepository{
+				FindDueDeliveriesFunc: func(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+					return []model.WebhookDelivery{{ID: 7, WebhookID: 3, UserID: 1, Event: event.TodoCreated, Payload: payload, Status: model.DeliveryPending, Attempts: tt.attempts}}, nil
+				},
+				FindByIDFunc: func(userID uint, id uint) (*model.Webhook, error) {
+					return webhook, nil
+				},
+				UpdateDeliveryFunc: func(delivery *model.WebhookDelivery) error {
+					saved = delivery
+					return nil
+				},
+				ResetFailuresFunc: func(id uint) error {
+					reset = true
+					return nil
+				},
+				IncrementFailuresFunc: func(id uint) (int, error) {
+					webhook.FailureCount++
+					return webhook.FailureCount, nil
+				},
+				DisableFunc: func(id uint, at time.Time) error {
+					disabled = true
+					return nil
+				},
+			}
+
+			svc := service.NewWebhookService(mockRepo, webhookOptions)
+
+			before := time.Now()
+			processed, err := svc.ProcessDue(ctx, before)
+			if err != nil || processed != 1 {
+				t.Fatalf("unexpected result: %d, %v", processed, err)
+			}
+
+			if (received != nil) != tt.expectSent {
+				t.Fatalf("expected sent=%v", tt.expectSent)
+			}
+			if received != nil {
+				timestamp := received.Header.Get("X-Webhook-Timestamp")
+				if sig := received.Header.Get("X-Webhook-Signature"); sig != service.SignWebhook(webhook.Secret, timestamp, body) {
+					t.Errorf("invalid signature: %s", sig)
+				}
+				if string(body) != string(payload) || received.Header.Get("X-Webhook-Event") != event.TodoCreated || received.Header.Get("X-Webhook-Delivery") != "7" {
+					t.Errorf("unexpected request: %v %s", received.Header, body)
+				}
+			}
+
+			if saved == nil || saved.Status != tt.expectStatus {
+				t.Fatalf("expected status %s, got %+v", tt.expectStatus, saved)
+			}
+			if tt.expectSent && saved.Attempts != tt.attempts+1 {
+				t.Errorf("expected attempts %d, got %d", tt.attempts+1, saved.Attempts)
+			}
+			if tt.expectBackoff == 0 && saved.NextAttemptAt != nil {
+				t.Errorf("expected no next attempt, got %s", saved.NextAttemptAt)
+			}
+			if tt.expectBackoff != 0 {
+				if saved.NextAttemptAt == nil {
+					t.Fatalf("expected next attempt")
+				}
+				if d := saved.NextAttemptAt.Sub(before); d < tt.expectBackoff || d > tt.expectBackoff+5*time.Second {
+					t.Errorf("expected backoff %s, got %s", tt.expectBackoff, d)
+				}
+			}
+			if reset != tt.expectReset || disabled != tt.expectDisabled {
+				t.Errorf("expected reset=%v disabled=%v, got %v %v", tt.expectReset, tt.expectDisabled, reset, disabled)
+			}
+		})
+	}
+}
+
+// --- ProcessDue（シャットダウン） ---
+// 送信中に取り消したら送信を打ち切り、失敗として数えずに送信待ちのまま残す。残りの送信もしない
+func TestWebhookService_ProcessDueShutdown(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer receiver.Close()
+
+	webhook := &model.Webhook{ID: 3, UserID: 1, URL: receiver.URL, Secret: "0123456789abcdef", Active: true}
+	updated, failures := 0, 0
+	mockRepo := &MockWebhookRepository{
+		FindDueDeliveriesFunc: func(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+			return []model.WebhookDelivery{
+				{ID: 7, WebhookID: 3, UserID: 1, Event: event.TodoCreated, Status: model.DeliveryPending},
+				{ID: 8, WebhookID: 3, UserID: 1, Event: event.TodoCreated, Status: model.DeliveryPending},
+			}, nil
+		},
+		FindByIDFunc: func(userID uint, id uint) (*model.Webhook, error) {
+			return webhook, nil
+		},
+		UpdateDeliveryFunc: func(delivery *model.WebhookDelivery) error {
+			updated++
+			return nil
+		},
+		IncrementFailuresFunc: func(id uint) (int, error) {
+			failures++
+			return failures, nil
+		},
+	}
+
+	svc := service.NewWebhookService(mockRepo, service.WebhookOptions{Timeout: time.Minute, RetryBase: time.Second, Retention: time.Hour, AllowPrivateTargets: true})
+
+	start := time.Now()
+	processed, err := svc.ProcessDue(ctx, start)
+	if err != nil || processed != 1 {
+		t.Fatalf("expected to stop after the first delivery, got %d, %v", processed, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected send to be cut off, took %s", elapsed)
+	}
+	if requests != 1 || updated != 0 || failures != 0 {
+		t.Errorf("expected the delivery to stay pending, got requests=%d updated=%d failures=%d", requests, updated, failures)
+	}
+}
+
+// --- 内部のアドレスへの送信 ---
+func TestWebhookService_PrivateTargets(t *testing.T) {
+
+	strict := webhookOptions
+	strict.AllowPrivateTargets = false
+
+	// 作成・更新時は URL のホストが IP アドレスか localhost のときに断る
+	tests := []struct {
+		name      string
+		url       string
+		expectErr error
+	}{
+		{name: "public host", url: "https://example.com/hook"},
+		{name: "public ip", url: "https://93.184.216.34/hook"},
+		{name: "localhost", url: "http://localhost:9000", expectErr: service.ErrValidation},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", expectErr: service.ErrValidation},
+		{name: "ipv6 loopback", url: "http://[::1]/hook", expectErr: service.ErrValidation},
+		{name: "metadata", url: "http://169.254.169.254/latest/meta-data/", expectErr: service.ErrValidation},
+		{name: "rfc1918", url: "http://10.0.0.1/hook", expectErr: service.ErrValidation},
+		{name: "ipv4 mapped ipv6", url: "http://[::ffff:192.168.1.1]/hook", expectErr: service.ErrValidation},
+		{name: "unspecified", url: "http://0.0.0.0/hook", expectErr: service.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := &MockWebhookRepository{
+				CountFunc:  func(userID uint) (int64, error) { return 0, nil },
+				CreateFunc: func(webhook *model.Webhook) error { return nil },
+			}
+			svc := service.NewWebhookService(mockRepo, strict)
+
+			_, err := svc.Create(ctx, 1, service.WebhookInput{URL: tt.url, Events: []string{event.TodoCreated}})
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("expected %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+
+	// 保存済みの URL でも、接続する時点で内部のアドレスなら送らない（名前解決の結果が変わった場合など）
+	t.Run("blocked at connect time", func(t *testing.T) {
+		received := false
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = true
+		}))
+		defer receiver.Close()
+
+		webhook := &model.Webhook{ID: 3, UserID: 1, URL: receiver.URL, Secret: "0123456789abcdef", Active: true}
+		var saved *model.WebhookDelivery
+		mockRepo := &MockWebhookRepository{
+			FindDueDeliveriesFunc: func(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+				return []model.WebhookDelivery{{ID: 7, WebhookID: 3, UserID: 1, Event: event.TodoCreated, Payload: []byte(`{}`), Status: model.DeliveryPending}}, nil
+			},
+			FindByIDFunc:          func(userID uint, id uint) (*model.Webhook, error) { return webhook, nil },
+			UpdateDeliveryFunc:    func(delivery *model.WebhookDelivery) error { saved = delivery; return nil },
+			IncrementFailuresFunc: func(id uint) (int, error) { return 1, nil },
+		}
+		svc := service.NewWebhookService(mockRepo, strict)
+
+		if _, err := svc.ProcessDue(ctx, time.Now()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if received {
+			t.Errorf("expected no request to the private address")
+		}
+		if saved == nil || saved.Status != model.DeliveryPending || !strings.Contains(saved.Error, service.ErrWebhookTargetNotAllowed.Error()) {
+			t.Errorf("expected failed attempt, got %+v", saved)
+		}
+	})
+}
+
+// --- Publish / Run ---
+func TestWebhookService_Run(t *testing.T) {
+
+	var mu sync.Mutex
+	var payloads []string
+	done := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		payloads = append(payloads, string(body))
+		mu.Unlock()
+		close(done)
+	}))
+	defer receiver.Close()
+
+	webhooks := []model.Webhook{
+		{ID: 1, UserID: 1, URL: receiver.URL, Secret: "0123456789abcdef", Active: true, Events: model.StringList{event.TodoCreated}},
+		{ID: 2, UserID: 1, URL: receiver.URL, Secret: "0123456789abcdef", Active: true, Events: model.StringList{event.TodoDeleted}},
+	}
+	var deliveries []model.WebhookDelivery
+	mockRepo := &MockWebhookRepository{
+		FindActiveFunc: func(userID uint) ([]model.Webhook, error) {
+			return webhooks, nil
+		},
+		CreateDeliveryFunc: func(delivery *model.WebhookDelivery) error {
+			delivery.ID = uint(len(deliveries) + 1)
+			deliveries = append(deliveries, *delivery)
+			return nil
+		},
+		FindDueDeliveriesFunc: func(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+			due := deliveries
+			deliveries = nil
+			return due, nil
+		},
+		FindByIDFunc: func(userID uint, id uint) (*model.Webhook, error) {
+			return &webhooks[id-1], nil
+		},
+		UpdateDeliveryFunc: func(delivery *model.WebhookDelivery) error {
+			return nil
+		},
+		ResetFailuresFunc: func(id uint) error {
+			return nil
+		},
+	}
+
+	svc := service.NewWebhookService(mockRepo, webhookOptions)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	svc.Publish(1, event.TodoCreated, map[string]any{"ID": 5})
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 1 {
+		t.Fatalf("expected 1 delivery (only the todo.created webhook), got %v", payloads)
+	}
+	var payload struct {
+		Type string
+		Data struct{ ID int }
+	}
+	if err := json.Unmarshal([]byte(payloads[0]), &payload); err != nil || payload.Type != event.TodoCreated || payload.Data.ID != 5 {
+		t.Errorf("unexpected payload: %s", payloads[0])
+	}
+}
+
+// --- Run（シャットダウン） ---
+// 取り消した後も、受け取り済みのイベントは送信待ちとして保存する
+func TestWebhookService_RunShutdown(t *testing.T) {
+
+	webhooks := []model.Webhook{{ID: 1, UserID: 1, URL: "https://example.com/hook", Active: true, Events: model.StringList{event.TodoCreated}}}
+	var mu sync.Mutex
+	var saved []model.WebhookDelivery
+	mockRepo := &MockWebhookRepository{
+		FindActiveFunc: func(userID uint) ([]model.Webhook, error) {
+			return webhooks, nil
+		},
+		CreateDeliveryFunc: func(delivery *model.WebhookDelivery) error {
+			mu.Lock()
+			defer mu.Unlock()
+			saved = append(saved, *delivery)
+			return nil
+		},
+		FindDueDeliveriesFunc: func(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+			return nil, nil
+		},
+	}
+
+	svc := service.NewWebhookService(&ctxWebhookRepository{mockRepo, context.Background()}, webhookOptions)
+	for i := 1; i <= 3; i++ {
+		svc.Publish(1, event.TodoCreated, map[string]any{"ID": i})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(saved) != 3 {
+		t.Fatalf("expected 3 pending deliveries, got %d", len(saved))
+	}
+	for _, delivery := range saved {
+		if delivery.Status != model.DeliveryPending || delivery.NextAttemptAt == nil {
+			t.Errorf("expected pending delivery, got %+v", delivery)
+		}
+	}
+}
+
+// --- Redeliver ---
+func TestWebhookService_Redeliver(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		active     bool
+		deliveryID uint
+		expectErr  error
+	}{
+		{name: "success", active: true, deliveryID: 7},
+		{name: "disabled webhook", active: false, deliveryID: 7, expectErr: service.ErrWebhookDisabled},
+		{name: "unknown delivery", active: true, deliveryID: 99, expectErr: service.ErrWebhookDeliveryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var created *model.WebhookDelivery
+			mockRepo := &MockWebhookRepository{
+				FindByIDFunc: func(userID uint, id uint) (*model.Webhook, error) {
+					return &model.Webhook{ID: id, UserID: userID, Active: tt.active}, nil
+				},
+				FindDeliveryFunc: func(userID uint, webhookID uint, id uint) (*model.WebhookDelivery, error) {
+					if id != 7 {
+						return nil, errors.New("record not found")
+					}
+					return &model.WebhookDelivery{ID: 7, WebhookID: webhookID, Event: event.TodoUpdated, Payload: []byte(`{}`), Status: model.DeliveryFailed, Attempts: 8}, nil
+				},
+				CreateDeliveryFunc: func(delivery *model.WebhookDelivery) error {
+					delivery.ID = 8
+					created = delivery
+					return nil
+				},
+			}
+
+			svc := service.NewWebhookService(mockRepo, webhookOptions)
+
+			delivery, err := svc.Redeliver(ctx, 1, 3, tt.deliveryID)
+
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if delivery != created || delivery.ID != 8 || delivery.Status != model.DeliveryPending || delivery.Attempts != 0 ||
+				delivery.Event != event.TodoUpdated || delivery.NextAttemptAt == nil {
+				t.Errorf("unexpected delivery: %+v", delivery)
+			}
+		})
+	}
+}
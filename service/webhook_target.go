@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookTargetNotAllowed は webhook の送信先が内部のアドレス（ループバック・プライベート・リンクローカルなど）
+var ErrWebhookTargetNotAllowed = errors.New("webhook target address is not allowed")
+
+var errPrivateWebhookURL = fmt.Errorf("%w: url must not point to a loopback, private or link-local address", ErrValidation)
+
+// cgnatPrefix はキャリアグレード NAT の共有アドレス（100.64.0.0/10）。netip.Addr.IsPrivate には含まれない
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// allowedWebhookAddr はサーバーの内側に届かないアドレスか
+// （169.254.169.254 のようなクラウドのメタデータ、localhost、社内ネットワークへの送信を防ぐ）
+func allowedWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!cgnatPrefix.Contains(addr)
+}
+
+// checkWebhookHost は URL のホストが IP アドレスか localhost のときだけ、作成・更新の時点で確認する。
+// 名前解決の結果は変わりうる（DNS rebinding）ので、本当の確認は接続するときに webhookDialControl で行う
+func checkWebhookHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errPrivateWebhookURL
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !allowedWebhookAddr(addr) {
+		return errPrivateWebhookURL
+	}
+	return nil
+}
+
+// webhookDialControl は名前解決した後の接続先を確認する（リダイレクトは追わないので、送信先への接続はこれだけ）
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !allowedWebhookAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrWebhookTargetNotAllowed, addrPort.Addr())
+	}
+	return nil
+}
+
+// newWebhookClient は送信用のクライアント。
+// allowPrivate でなければ内部のアドレスに接続しない（プロキシを通すと接続先を確認できないので、環境変数のプロキシも使わない）
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: webhookDialControl}
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// リダイレクトは追わずに失敗として扱う
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
@@ -0,0 +1,78 @@
+// Package tracing は OpenTelemetry のトレースの初期化と、GORM のクエリの span。
+//
+// Init でグローバルの TracerProvider と W3C Trace Context（traceparent ヘッダー）のプロパゲーターを設定する。
+// 各層は otel.Tracer で span を作り、ctx で親子をつなぐ。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// span の送り先
+const (
+	ExporterNone   = "none"   // span を作らない（traceparent の受け渡しだけする）
+	ExporterStdout = "stdout" // 標準出力に JSON で書く（開発・テスト用）
+	ExporterOTLP   = "otlp"   // OTLP/HTTP で Collector などに送る
+)
+
+// ServiceName は service.name リソース属性
+const ServiceName = "go-gin-todo-app"
+
+// Options はトレースの設定
+type Options struct {
+	Exporter    string
+	Endpoint    string    // OTLP の送り先（例: http://localhost:4318）。空なら OTEL_EXPORTER_OTLP_ENDPOINT かデフォルト
+	SampleRatio float64   // 新しく始める trace を記録する割合（0〜1）。親の trace の判断には従う
+	Writer      io.Writer // stdout の出力先（nil なら os.Stdout）
+}
+
+// Init はグローバルの TracerProvider とプロパゲーターを設定する。
+// 返す shutdown はシャットダウン時に呼び、残っている span を送る
+func Init(ctx context.Context, options Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch options.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w := options.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if options.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(options.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %q", options.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", options.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
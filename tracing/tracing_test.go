@@ -0,0 +1,117 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/a5415091-collab/go-gin-todo-app/db"
+	"github.com/a5415091-collab/go-gin-todo-app/model"
+	"github.com/a5415091-collab/go-gin-todo-app/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// stdout の exporter が書く span の必要なところだけ
+type span struct {
+	Name        string
+	SpanContext struct{ TraceID, SpanID string }
+	Parent      struct{ TraceID, SpanID string }
+	Attributes  []struct {
+		Key   string
+		Value struct{ Value any }
+	}
+}
+
+func readSpans(t *testing.T, r io.Reader) map[string]span {
+	t.Helper()
+
+	spans := map[string]span{}
+	dec := json.NewDecoder(r)
+	for {
+		var s span
+		if err := dec.Decode(&s); errors.Is(err, io.EOF) {
+			return spans
+		} else if err != nil {
+			t.Fatalf("failed to decode span: %v", err)
+		}
+		spans[s.Name] = s
+	}
+}
+
+// traceparent の trace の中に、ルート → GORM のクエリの順に span がつながること
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	shutdown, err := tracing.Init(context.Background(), tracing.Options{
+		Exporter:    tracing.ExporterStdout,
+		SampleRatio: 1,
+		Writer:      &out,
+	})
+	if err != nil {
+		t.Fatalf("failed to init tracing: %v", err)
+	}
+
+	db.Init(filepath.Join(t.TempDir(), "tracing.db"))
+	t.Cleanup(func() { db.Close() })
+	if err := db.DB.Use(tracing.GORMPlugin{}); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+
+	// リクエストの外のクエリは記録しない
+	db.DB.Find(&[]model.Todo{})
+
+	r := gin.New()
+	r.Use(tracing.Middleware())
+	r.GET("/todos/:id", func(c *gin.Context) {
+		var todos []model.Todo
+		db.DB.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).Find(&todos)
+		c.Status(http.StatusOK)
+	})
+	r.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+	for _, path := range []string{"/todos/1", "/livez"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shutdown: %v", err)
+	}
+	spans := readSpans(t, &out)
+	if len(spans) != 2 {
+		t.Fatalf("expected route and query spans, got %v", spans)
+	}
+
+	route, ok := spans["GET /todos/:id"]
+	if !ok {
+		t.Fatalf("route span not found: %v", spans)
+	}
+	if route.SpanContext.TraceID != traceID || route.Parent.SpanID != parentID {
+		t.Errorf("expected route span in traceparent trace, got %+v", route)
+	}
+
+	query, ok := spans["gorm.query"]
+	if !ok {
+		t.Fatalf("query span not found: %v", spans)
+	}
+	if query.SpanContext.TraceID != traceID || query.Parent.SpanID != route.SpanContext.SpanID {
+		t.Errorf("expected query span under route span, got %+v", query)
+	}
+	attrs := map[string]any{}
+	for _, a := range query.Attributes {
+		attrs[a.Key] = a.Value.Value
+	}
+	if attrs["db.collection.name"] != "todos" || attrs["db.system.name"] != "sqlite" {
+		t.Errorf("unexpected query attributes: %v", attrs)
+	}
+}